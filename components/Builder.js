@@ -743,6 +743,10 @@ export function Builder() {
                                                 {localComposites[selectedFloasisNFTIdx]?.layers[
                                                     selectedCompositeLayerIdx
                                                 ]?.gElems.map((gElem, gElemIdx) => {
+                                                    // the metadata of the art, such as its bounding box, has no color
+                                                    if (gElem.name === "metadata") {
+                                                        return null;
+                                                    }
                                                     return (
                                                         <div key={gElemIdx} className="color-picker">
                                                             <input
@@ -13,6 +13,7 @@ if (process.env.NEXT_PUBLIC_FLOW_ENV === "testnet") {
         "0xNonFungibleToken": "0x631e88ae7f1d7c20",
         "0xMetadataViews": "0x631e88ae7f1d7c20",
         "0xFlowToken": "0x7e60df042a9c0868",
+        "0xIaNFTAnalogsPacked": process.env.NEXT_PUBLIC_FLOASIS_ITEMS_TESTNET_ACCOUNT, // fcl has an error if this alias is created after '0xIaNFTAnalogs', so keep this one first
         "0xIaNFTAnalogs": process.env.NEXT_PUBLIC_FLOASIS_TESTNET_ACCOUNT,
         "0xFLOASISNFT": process.env.NEXT_PUBLIC_FLOASIS_TESTNET_ACCOUNT,
         "0xFLOASISItemsStore": process.env.NEXT_PUBLIC_FLOASIS_ITEMS_TESTNET_ACCOUNT, // fcl has an error if this alias is created after '0xFLOASISItems', so keep this one first
@@ -31,6 +32,7 @@ if (process.env.NEXT_PUBLIC_FLOW_ENV === "testnet") {
         "0xNonFungibleToken": "0x1d7e57aa55817448",
         "0xMetadataViews": "0x1d7e57aa55817448",
         "0xFlowToken": "0x1654653399040a61",
+        "0xIaNFTAnalogsPacked": process.env.NEXT_PUBLIC_FLOASIS_ITEMS_MAINNET_ACCOUNT, // fcl has an error if this alias is created after '0xIaNFTAnalogs', so keep this one first
         "0xIaNFTAnalogs": process.env.NEXT_PUBLIC_FLOASIS_NFT_DEPLOYER_ADDRESS_MAINNET,
         "0xFLOASISNFT": process.env.NEXT_PUBLIC_FLOASIS_NFT_DEPLOYER_ADDRESS_MAINNET,
         "0xFLOASISItemsStore": process.env.NEXT_PUBLIC_DEPLOYER_ADDRESS_MAINNET, // fcl has an error if this alias is created after '0xFLOASISItems', so keep this one first
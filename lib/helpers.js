@@ -5,6 +5,7 @@ export function replaceCDCImports(str) {
         '"../../contracts/core/NonFungibleToken.cdc"': "0xNonFungibleToken",
         '"../../contracts/core/MetadataViews.cdc"': "0xMetadataViews",
         '"../../contracts/IaNFTAnalogs.cdc"': "0xIaNFTAnalogs",
+        '"../../contracts/IaNFTAnalogsPacked.cdc"': "0xIaNFTAnalogsPacked",
         '"../../contracts/FLOASISNFT.cdc"': "0xFLOASISNFT",
         '"../../contracts/FLOASISItems.cdc"': "0xFLOASISItems",
         '"../../contracts/FLOASISPrimitives.cdc"': "0xFLOASISPrimitives",
@@ -2,106 +2,143 @@ package art_prep
 
 import (
 	"context"
-	"floasis-items/flow/overflow/helpers"
+	"floasis-items/flow/overflow/flow_config"
+	"floasis-items/flow/overflow/ipfs_pin"
+	"floasis-items/flow/overflow/manifest"
 	"floasis-items/flow/overflow/svg_prep"
+	"floasis-items/flow/overflow/tx_args"
 	"fmt"
-	"log"
 	"os"
+	"path/filepath"
 
-	"github.com/joho/godotenv"
+	"github.com/ipfs/go-cid"
 	"github.com/onflow/cadence"
-	"github.com/web3-storage/go-w3s-client"
 )
 
-func PrepareArt(
-	artRepoPath string,
-	artIndexFileName string,
-	flowNetwork string,
-) (
-	cadence.Array,
-	cadence.Array,
-	cadence.Array,
-	cadence.Array,
-	cadence.Array,
-	cadence.Array,
-	cadence.Array,
-) {
+// Art is an ArtEntry ready to be added to the art library: its artwork
+// converted to IaNFTAnalogs.Svg structs and the IPFS CID of its thumbnail
+// computed, see PinThumbnails
+type Art struct {
+	Entry         manifest.ArtEntry
+	BaseArtwork   cadence.Struct
+	CardArtwork   cadence.Struct
+	ThumbnailPath string
+	ThumbnailCID  cid.Cid
+}
 
-	err := godotenv.Load(".env")
-	if err != nil {
-		log.Fatal("Error in art_pre.go when loading .env file")
-	}
-	WEB3_STORAGE_IPFS_API_KEY := os.Getenv("WEB3_STORAGE_IPFS_API_KEY")
-	c, _ := w3s.NewClient(w3s.WithToken(WEB3_STORAGE_IPFS_API_KEY))
+// ArtBatch is the art added to a series with one
+// FLOASISItemsStore/batch_add_art_to_artLibrary transaction
+type ArtBatch struct {
+	ArtistName string
+	SeriesName string
+	Art        []Art
+}
 
-	csvPath := fmt.Sprintf("%s/%s", artRepoPath, artIndexFileName)
-	csvData, err := helpers.ReadCsvFile(csvPath)
-	if err != nil {
-		panic(err)
+// Args names the arguments the way batch_add_art_to_artLibrary takes them,
+// so that no array can end up in the place of another
+func (batch ArtBatch) Args() tx_args.Args {
+	artNames := []cadence.Value{}
+	planetNames := []cadence.Value{}
+	baseArtwork := []cadence.Value{}
+	cardArtwork := []cadence.Value{}
+	artDescriptions := []cadence.Value{}
+	artThumbnails := []cadence.Value{}
+	artThumbnailPaths := []cadence.Value{}
+
+	for _, art := range batch.Art {
+		artNames = append(artNames, cadence.String(art.Entry.Name))
+		planetNames = append(planetNames, cadence.String(art.Entry.PlanetName))
+		baseArtwork = append(baseArtwork, art.BaseArtwork)
+		cardArtwork = append(cardArtwork, art.CardArtwork)
+		artDescriptions = append(artDescriptions, cadence.String(art.Entry.Description))
+		artThumbnails = append(artThumbnails, cadence.String(art.ThumbnailCID.String()))
+		artThumbnailPaths = append(artThumbnailPaths, cadence.String(art.Entry.ThumbnailFileName()))
 	}
 
-	art_names_cadence := []cadence.Value{}
-	planet_names_cadence := []cadence.Value{}
-	base_artwork_cadence := []cadence.Value{}
-	card_artwork_cadence := []cadence.Value{}
-	art_descriptions_cadence := []cadence.Value{}
-	art_thumbnails_cadence := []cadence.Value{}
-	art_thumbnail_paths_cadence := []cadence.Value{}
-
-	for _, line := range csvData {
-		art_name := line[0]
-		planet_name := line[1]
-		base_art_file_name := line[2]
-
-		card_art_file_name := line[3]
-		art_description := line[4]
-		art_thumbnail_file_name := line[5]
+	return tx_args.Args{
+		"artistName":        cadence.String(batch.ArtistName),
+		"seriesName":        cadence.String(batch.SeriesName),
+		"artNames":          cadence.NewArray(artNames),
+		"planetNames":       cadence.NewArray(planetNames),
+		"baseArtwork":       cadence.NewArray(baseArtwork),
+		"cardArtwork":       cadence.NewArray(cardArtwork),
+		"artDescriptions":   cadence.NewArray(artDescriptions),
+		"artThumbnails":     cadence.NewArray(artThumbnails),
+		"artThumbnailPaths": cadence.NewArray(artThumbnailPaths),
+	}
+}
 
-		base_art_file_path := fmt.Sprintf("%s/svg/%s.svg", artRepoPath, base_art_file_name)
+// PrepareArt converts the artwork of every entry for the IaNFTAnalogs contract
+// on flowNetwork and computes the IPFS CID of every thumbnail, without the
+// network. Artwork is read from the svg and png folders of artRepoPath, and
+// every file is checked before any is converted.
+func PrepareArt(artRepoPath string, entries []manifest.ArtEntry, flowNetwork string) ([]Art, error) {
+	if err := manifest.CheckArtFiles(artRepoPath, entries); err != nil {
+		return nil, err
+	}
 
-		base_art_file_data, base_art_file_data_err := os.ReadFile(base_art_file_path)
-		if base_art_file_data_err != nil {
-			log.Fatal((base_art_file_data_err))
+	// resolve the IaNFTAnalogs address once for all of the artwork, which
+	// also loads the .env next to flow.json
+	svg_converter, err := svg_prep.NewConverterForNetwork(flow_config.DefaultPath, flowNetwork)
+	if err != nil {
+		return nil, err
+	}
+	// packed art has to be read through IaNFTAnalogsPacked.unpack
+	svg_converter.SetPackRects(os.Getenv("FLOASIS_ITEMS_PACK_RECTS") == "true")
+
+	convert := func(fileName string) (cadence.Struct, error) {
+		svg_file_path := filepath.Join(artRepoPath, "svg", fileName+".svg")
+		svg_file_data, err := os.ReadFile(svg_file_path)
+		if err != nil {
+			return cadence.Struct{}, err
 		}
-
-		card_art_file_path := fmt.Sprintf("%s/svg/%s.svg", artRepoPath, card_art_file_name)
-		card_art_file_data, card_art_file_data_err := os.ReadFile(card_art_file_path)
-		if card_art_file_data_err != nil {
-			log.Fatal((card_art_file_data_err))
+		svg_struct, err := svg_converter.GetSvgStruct(string(svg_file_data))
+		if err != nil {
+			return cadence.Struct{}, fmt.Errorf("%s: %w", svg_file_path, err)
 		}
+		return svg_struct, nil
+	}
 
-		art_names_cadence = append(art_names_cadence, cadence.String(art_name))
-		planet_names_cadence = append(planet_names_cadence, cadence.String(planet_name))
-
-		base_svg_cadence_analog := svg_prep.GetSvgStruct(string(base_art_file_data), flowNetwork)
-		base_artwork_cadence = append(base_artwork_cadence, base_svg_cadence_analog)
-
-		card_svg_cadence_analog := svg_prep.GetSvgStruct(string(card_art_file_data), flowNetwork)
-		card_artwork_cadence = append(card_artwork_cadence, card_svg_cadence_analog)
-
-		art_descriptions_cadence = append(art_descriptions_cadence, cadence.String(art_description))
-
-		art_thumbnail_file_path := fmt.Sprintf("%s/png/%s.png", artRepoPath, art_thumbnail_file_name)
-		art_thumbnail_file_name_and_type := fmt.Sprintf("%s.png", art_thumbnail_file_name)
-
-		f, _ := os.Open(art_thumbnail_file_path)
-		cid, _ := c.Put(context.Background(), f)
-
-		art_thumbnails_cadence = append(art_thumbnails_cadence, cadence.String(cid.String()))
-		art_thumbnail_paths_cadence = append(art_thumbnail_paths_cadence, cadence.String(art_thumbnail_file_name_and_type))
+	art := []Art{}
+	for _, entry := range entries {
+		base_artwork, err := convert(entry.BaseArt)
+		if err != nil {
+			return nil, err
+		}
+		card_artwork, err := convert(entry.CardArt)
+		if err != nil {
+			return nil, err
+		}
 
-		fmt.Printf("Pinned to IPFS: https://%v.ipfs.w3s.link/%v\n", cid, art_thumbnail_file_name_and_type)
+		thumbnail_file_path := filepath.Join(artRepoPath, "png", entry.ThumbnailFileName())
+		thumbnail_cid, err := ipfs_pin.ComputeCID(context.Background(), thumbnail_file_path)
+		if err != nil {
+			return nil, err
+		}
 
+		art = append(art, Art{
+			Entry:         entry,
+			BaseArtwork:   base_artwork,
+			CardArtwork:   card_artwork,
+			ThumbnailPath: thumbnail_file_path,
+			ThumbnailCID:  thumbnail_cid,
+		})
 	}
 
-	art_names := cadence.NewArray(art_names_cadence)
-	planet_names := cadence.NewArray(planet_names_cadence)
-	base_artwork := cadence.NewArray(base_artwork_cadence)
-	card_artwork := cadence.NewArray(card_artwork_cadence)
-	art_descriptions := cadence.NewArray(art_descriptions_cadence)
-	art_thumbnails := cadence.NewArray(art_thumbnails_cadence)
-	art_thumbnail_paths := cadence.NewArray(art_thumbnail_paths_cadence)
-
-	return art_names, planet_names, base_artwork, card_artwork, art_descriptions, art_thumbnails, art_thumbnail_paths
+	return art, nil
+}
 
+// PinThumbnails pins the thumbnail of every prepared art with the backend
+// configured in the environment or the .env next to flow.json, see ipfs_pin,
+// and fails if a thumbnail changed since it was prepared. Thumbnails already
+// in the lockfile at lockPath, shared with inventory_prep, are not uploaded again.
+func PinThumbnails(art []Art, lockPath string) error {
+	if err := flow_config.LoadEnv(flow_config.DefaultPath); err != nil {
+		return err
+	}
+	files := []ipfs_pin.File{}
+	for _, a := range art {
+		files = append(files, ipfs_pin.File{Path: a.ThumbnailPath, CID: a.ThumbnailCID})
+	}
+	return ipfs_pin.PinFromEnv(context.Background(), lockPath, files)
 }
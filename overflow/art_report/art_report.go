@@ -0,0 +1,310 @@
+/*
+Reports what converted artwork will cost once it is on-chain: the size of the
+SVGs png2svg produced, the size of the IaNFTAnalogs.Svg arguments svg_prep
+builds from them, and an estimate of the storage every minted NFT consumes,
+since each NFT embeds its own copy of its base and card art.
+
+Sizes do not depend on the network: the arguments name the IaNFTAnalogs
+address with a fixed number of hex digits, so any converter measures them.
+*/
+
+package art_report
+
+import (
+	"encoding/json"
+	"floasis-items/flow/overflow/manifest"
+	"floasis-items/flow/overflow/svg_prep"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/JoshVarga/svgparser"
+	"github.com/onflow/cadence"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+)
+
+// Flow charges storage at 1 FLOW per 100 MB
+const bytesPerFLOW = 100_000_000
+
+// Rough per-value sizes of the Cadence storage encoding, used by
+// EstimateStorageBytes. They are deliberately on the high side.
+const (
+	storageValueOverhead     = 3  // CBOR head of a string or number
+	storageFieldOverhead     = 4  // CBOR heads of a composite field name and value
+	storageCompositeOverhead = 48 // composite slab header, type location and kind
+	storageArrayOverhead     = 24 // array slab header and element type
+)
+
+// NewConverter returns a converter to measure artwork with, for an
+// IaNFTAnalogs address that stands for any other, see the package doc
+func NewConverter() *svg_prep.Converter {
+	return svg_prep.NewConverter("0000000000000000")
+}
+
+// Budgets are the per-item limits a report flags. A zero value disables a check.
+type Budgets struct {
+	MaxRects         int `json:"maxRects"`
+	MaxGroups        int `json:"maxGroups"`
+	MaxColors        int `json:"maxColors"`
+	MaxSVGBytes      int `json:"maxSvgBytes"`
+	MaxArgumentBytes int `json:"maxArgumentBytes"`
+	MaxStorageBytes  int `json:"maxStorageBytes"`
+}
+
+// ItemStats are the conversion statistics of a single SVG, or of the base and
+// card art of an art list entry added up
+type ItemStats struct {
+	Name               string   `json:"name"`
+	Series             string   `json:"series,omitempty"`
+	Rects              int      `json:"rects"`
+	Groups             int      `json:"groups"`
+	Colors             int      `json:"colors"`
+	SVGBytes           int      `json:"svgBytes"`
+	ArgumentBytes      int      `json:"argumentBytes"`
+	StorageBytes       int      `json:"storageBytes"`
+	StorageFLOWPerMint float64  `json:"storageFlowPerMint"`
+	OverBudget         []string `json:"overBudget,omitempty"`
+	Error              string   `json:"error,omitempty"`
+}
+
+// Report holds the statistics of every art of an art library, or of every SVG
+// in a directory
+type Report struct {
+	Items              []ItemStats `json:"items"`
+	Budgets            Budgets     `json:"budgets"`
+	TotalSVGBytes      int         `json:"totalSvgBytes"`
+	TotalArgumentBytes int         `json:"totalArgumentBytes"`
+	TotalStorageBytes  int         `json:"totalStorageBytes"`
+}
+
+// OverBudget returns the items that exceed at least one budget or failed to convert
+func (r *Report) OverBudget() []ItemStats {
+	items := []ItemStats{}
+	for _, item := range r.Items {
+		if len(item.OverBudget) > 0 || item.Error != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// EstimateStorageBytes estimates how many bytes a value occupies in account
+// storage. It is an approximation of the atree/CBOR encoding, meant for
+// comparing items and catching outliers, not for exact fee calculation.
+func EstimateStorageBytes(value cadence.Value) int {
+	switch v := value.(type) {
+	case cadence.String:
+		return storageValueOverhead + len(v)
+	case cadence.Struct:
+		size := storageCompositeOverhead
+		if v.StructType != nil {
+			size += len(v.StructType.QualifiedIdentifier)
+		}
+		for i, field := range v.Fields {
+			if v.StructType != nil && i < len(v.StructType.Fields) {
+				size += len(v.StructType.Fields[i].Identifier)
+			}
+			size += storageFieldOverhead + EstimateStorageBytes(field)
+		}
+		return size
+	case cadence.Array:
+		size := storageArrayOverhead
+		for _, element := range v.Values {
+			size += EstimateStorageBytes(element)
+		}
+		return size
+	case cadence.Optional:
+		if v.Value == nil {
+			return 1
+		}
+		return 1 + EstimateStorageBytes(v.Value)
+	default:
+		// numbers, booleans and addresses
+		return storageValueOverhead + 8
+	}
+}
+
+// svgShapeStats counts the rects and groups of an SVG and collects its fill colors
+func svgShapeStats(svgString string) (rects int, groups int, fills map[string]bool, err error) {
+	root, err := svgparser.Parse(strings.NewReader(svgString), false)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	fills = map[string]bool{}
+	for _, child := range root.Children {
+		switch child.Name {
+		case "g":
+			groups++
+			fills[child.Attributes["fill"]] = true
+			rects += len(child.Children)
+		case "rect":
+			groups++
+			fills[child.Attributes["fill"]] = true
+			rects++
+		}
+	}
+	return rects, groups, fills, nil
+}
+
+// AnalyzeSVG collects the statistics of a single SVG
+func AnalyzeSVG(name string, svgString string, converter *svg_prep.Converter) ItemStats {
+	item, _ := analyzeSVG(name, svgString, converter)
+	return item
+}
+
+// analyzeSVG collects the statistics of a single SVG and its fill colors
+func analyzeSVG(name string, svgString string, converter *svg_prep.Converter) (ItemStats, map[string]bool) {
+	item := ItemStats{Name: name, SVGBytes: len(svgString)}
+
+	rects, groups, fills, err := svgShapeStats(svgString)
+	if err != nil {
+		item.Error = err.Error()
+		return item, nil
+	}
+	item.Rects, item.Groups, item.Colors = rects, groups, len(fills)
+
+	svgStruct, err := converter.GetSvgStruct(svgString)
+	if err != nil {
+		item.Error = err.Error()
+		return item, fills
+	}
+	argument, err := jsoncdc.Encode(svgStruct)
+	if err != nil {
+		item.Error = err.Error()
+		return item, fills
+	}
+	item.ArgumentBytes = len(argument)
+	item.StorageBytes = EstimateStorageBytes(svgStruct)
+	item.StorageFLOWPerMint = float64(item.StorageBytes) / bytesPerFLOW
+
+	return item, fills
+}
+
+// checkBudgets records every budget the item exceeds
+func checkBudgets(item *ItemStats, budgets Budgets) {
+	check := func(name string, value int, limit int) {
+		if limit > 0 && value > limit {
+			item.OverBudget = append(item.OverBudget, fmt.Sprintf("%s %d > %d", name, value, limit))
+		}
+	}
+	check("rects", item.Rects, budgets.MaxRects)
+	check("groups", item.Groups, budgets.MaxGroups)
+	check("colors", item.Colors, budgets.MaxColors)
+	check("svg bytes", item.SVGBytes, budgets.MaxSVGBytes)
+	check("argument bytes", item.ArgumentBytes, budgets.MaxArgumentBytes)
+	check("storage bytes", item.StorageBytes, budgets.MaxStorageBytes)
+}
+
+// add counts an item of the report toward the totals
+func (r *Report) add(item ItemStats) {
+	checkBudgets(&item, r.Budgets)
+	r.Items = append(r.Items, item)
+	r.TotalSVGBytes += item.SVGBytes
+	r.TotalArgumentBytes += item.ArgumentBytes
+	r.TotalStorageBytes += item.StorageBytes
+}
+
+// analyzeArt collects the statistics of the base and card art of an entry,
+// added up, with the colors they have in common counted once
+func analyzeArt(artRepoPath string, entry manifest.ArtEntry, converter *svg_prep.Converter) ItemStats {
+	item := ItemStats{Name: entry.Name}
+	colors := map[string]bool{}
+	for _, fileName := range []string{entry.BaseArt, entry.CardArt} {
+		svgFilePath := filepath.Join(artRepoPath, "svg", fileName+".svg")
+		data, err := os.ReadFile(svgFilePath)
+		if err != nil {
+			item.Error = err.Error()
+			return item
+		}
+		stats, fills := analyzeSVG(fileName, string(data), converter)
+		if stats.Error != "" {
+			item.Error = svgFilePath + ": " + stats.Error
+			return item
+		}
+		item.Rects += stats.Rects
+		item.Groups += stats.Groups
+		item.SVGBytes += stats.SVGBytes
+		item.ArgumentBytes += stats.ArgumentBytes
+		item.StorageBytes += stats.StorageBytes
+		for fill := range fills {
+			colors[fill] = true
+		}
+	}
+	item.Colors = len(colors)
+	item.StorageFLOWPerMint = float64(item.StorageBytes) / bytesPerFLOW
+	return item
+}
+
+// AnalyzeLibrary collects the statistics of every art of every series of the
+// library, one item per art list entry, since that is what an NFT embeds.
+// Artwork is read from the svg folder next to the series list.
+func AnalyzeLibrary(library manifest.Library, converter *svg_prep.Converter, budgets Budgets) *Report {
+	artRepoPath := filepath.Dir(library.Path)
+	report := Report{Items: []ItemStats{}, Budgets: budgets}
+	for _, series := range library.Series {
+		for _, entry := range series.Art {
+			item := analyzeArt(artRepoPath, entry, converter)
+			item.Series = series.Series.ArtistName + "/" + series.Series.SeriesName
+			report.add(item)
+		}
+	}
+	return &report
+}
+
+// thumbnailSuffix ends the file names of thumbnail SVGs, which are pinned to
+// IPFS and never go on-chain
+const thumbnailSuffix = "-thumbnail.svg"
+
+// AnalyzeDir collects the statistics of every SVG in svgDirPath, one item per
+// file, leaving out thumbnails
+func AnalyzeDir(svgDirPath string, converter *svg_prep.Converter, budgets Budgets) (*Report, error) {
+	files, err := os.ReadDir(svgDirPath)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+
+	report := Report{Items: []ItemStats{}, Budgets: budgets}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".svg" || strings.HasSuffix(file.Name(), thumbnailSuffix) {
+			continue
+		}
+		svg_file_path := filepath.Join(svgDirPath, file.Name())
+		svg_file_data, err := os.ReadFile(svg_file_path)
+		if err != nil {
+			return nil, err
+		}
+
+		report.add(AnalyzeSVG(strings.TrimSuffix(file.Name(), ".svg"), string(svg_file_data), converter))
+	}
+	return &report, nil
+}
+
+// WriteTable writes the report as an aligned text table
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "series\tname\trects\tgroups\tcolors\tsvg bytes\targ bytes\tstorage bytes\tFLOW/mint\tbudget\t")
+	for _, item := range r.Items {
+		status := "ok"
+		if item.Error != "" {
+			status = "error: " + strings.Join(strings.Fields(item.Error), " ")
+		} else if len(item.OverBudget) > 0 {
+			status = "OVER: " + strings.Join(item.OverBudget, ", ")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%.8f\t%s\t\n",
+			item.Series, item.Name, item.Rects, item.Groups, item.Colors, item.SVGBytes, item.ArgumentBytes, item.StorageBytes, item.StorageFLOWPerMint, status)
+	}
+	fmt.Fprintf(tw, "total\t\t\t\t\t%d\t%d\t%d\t\t\t\n", r.TotalSVGBytes, r.TotalArgumentBytes, r.TotalStorageBytes)
+	return tw.Flush()
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
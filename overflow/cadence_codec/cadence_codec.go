@@ -0,0 +1,443 @@
+/*
+Marshals Go structs to and from Cadence values, so that the structs of the
+FLOASIS contracts are described once, as Go types with cadence:"fieldName"
+tags, instead of being assembled field by field.
+
+A Go struct mirrors a Cadence struct by implementing Typed, and tagging its
+fields in the same order as the Cadence declaration:
+
+	type Planet struct {
+		Name         string        `cadence:"name"`
+		DiscoveredTs cadence.UFix64 `cadence:"discoveredTs"`
+	}
+
+	func (Planet) CadenceType() string { return "FLOASISPrimitives.Planet" }
+
+Go types map to Cadence types as follows: string to String, bool to Bool,
+uint64 to UInt64, int to Int, cadence.UFix64 to UFix64, cadence.Address to
+Address, pointers to optionals, slices to variable sized arrays, maps with
+string keys to dictionaries and Typed structs to structs.
+*/
+
+package cadence_codec
+
+import (
+	"floasis-items/flow/overflow/flow_config"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/onflow/cadence"
+)
+
+// Typed is implemented by Go structs that mirror a Cadence struct. CadenceType
+// returns the type name qualified by its contract, like "IaNFTAnalogs.Svg".
+type Typed interface {
+	CadenceType() string
+}
+
+var (
+	typedType   = reflect.TypeOf((*Typed)(nil)).Elem()
+	ufix64Type  = reflect.TypeOf(cadence.UFix64(0))
+	addressType = reflect.TypeOf(cadence.Address{})
+)
+
+// Codec converts between Go structs and Cadence values for contracts deployed
+// at known addresses. Struct types are cached, so a Codec is not safe for
+// concurrent use.
+type Codec struct {
+	addresses   map[string]string
+	structTypes map[reflect.Type]*cadence.StructType
+}
+
+// NewCodec returns a codec for the given contract addresses, keyed by
+// contract name. Addresses are given with or without the 0x prefix.
+func NewCodec(addresses map[string]string) *Codec {
+	codec := &Codec{
+		addresses:   map[string]string{},
+		structTypes: map[reflect.Type]*cadence.StructType{},
+	}
+	for contractName, address := range addresses {
+		codec.addresses[contractName] = strings.TrimPrefix(address, "0x")
+	}
+	return codec
+}
+
+// Address returns the address the codec uses for a contract
+func (codec *Codec) Address(contractName string) (string, bool) {
+	address, ok := codec.addresses[contractName]
+	return address, ok
+}
+
+// QualifiedIdentifier returns the type identifier of a contract type, like
+// "A.0123456789abcdef.IaNFTAnalogs.Svg" for "IaNFTAnalogs.Svg"
+func (codec *Codec) QualifiedIdentifier(cadenceType string) (string, error) {
+	contractName := strings.SplitN(cadenceType, ".", 2)[0]
+	address, ok := codec.addresses[contractName]
+	if !ok {
+		return "", fmt.Errorf("cadence_codec: no address for contract %s of %s", contractName, cadenceType)
+	}
+	return "A." + address + "." + cadenceType, nil
+}
+
+// field is a tagged Go struct field
+type field struct {
+	index int
+	name  string
+}
+
+// taggedFields returns the fields of a Go struct with a cadence tag, in declaration order
+func taggedFields(t reflect.Type) []field {
+	fields := []field{}
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("cadence")
+		if tag == "" || tag == "-" || !t.Field(i).IsExported() {
+			continue
+		}
+		fields = append(fields, field{index: i, name: tag})
+	}
+	return fields
+}
+
+// isTyped returns true if the Go type mirrors a Cadence struct
+func isTyped(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.Implements(typedType)
+}
+
+// cadenceTypeName returns the CadenceType of a Typed Go type
+func cadenceTypeName(t reflect.Type) string {
+	return reflect.Zero(t).Interface().(Typed).CadenceType()
+}
+
+// StructType returns the Cadence struct type of a Typed Go type, with every
+// field typed, including nested structs
+func (codec *Codec) StructType(t reflect.Type) (*cadence.StructType, error) {
+	if structType, ok := codec.structTypes[t]; ok {
+		return structType, nil
+	}
+	if !isTyped(t) {
+		return nil, fmt.Errorf("cadence_codec: %s does not implement CadenceType", t)
+	}
+	identifier, err := codec.QualifiedIdentifier(cadenceTypeName(t))
+	if err != nil {
+		return nil, err
+	}
+
+	// register the type before its fields, so that recursive types terminate
+	structType := &cadence.StructType{QualifiedIdentifier: identifier}
+	codec.structTypes[t] = structType
+
+	for _, f := range taggedFields(t) {
+		fieldType, err := codec.Type(t.Field(f.index).Type)
+		if err != nil {
+			delete(codec.structTypes, t)
+			return nil, fmt.Errorf("%s.%s: %w", cadenceTypeName(t), f.name, err)
+		}
+		structType.Fields = append(structType.Fields, cadence.Field{Identifier: f.name, Type: fieldType})
+	}
+	return structType, nil
+}
+
+// Type returns the Cadence type a Go type is marshaled to
+func (codec *Codec) Type(t reflect.Type) (cadence.Type, error) {
+	switch {
+	case t == ufix64Type:
+		return cadence.UFix64Type{}, nil
+	case t == addressType:
+		return cadence.AddressType{}, nil
+	case isTyped(t):
+		return codec.StructType(t)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return cadence.StringType{}, nil
+	case reflect.Bool:
+		return cadence.BoolType{}, nil
+	case reflect.Uint64:
+		return cadence.UInt64Type{}, nil
+	case reflect.Int:
+		return cadence.IntType{}, nil
+	case reflect.Ptr:
+		elementType, err := codec.Type(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return cadence.OptionalType{Type: elementType}, nil
+	case reflect.Slice:
+		elementType, err := codec.Type(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return cadence.VariableSizedArrayType{ElementType: elementType}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("cadence_codec: only maps with string keys are supported, not %s", t)
+		}
+		elementType, err := codec.Type(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return cadence.DictionaryType{KeyType: cadence.StringType{}, ElementType: elementType}, nil
+	}
+	return nil, fmt.Errorf("cadence_codec: %s has no Cadence equivalent", t)
+}
+
+// Marshal converts a Go value into a Cadence value
+func (codec *Codec) Marshal(v interface{}) (cadence.Value, error) {
+	return codec.marshal(reflect.ValueOf(v))
+}
+
+func (codec *Codec) marshal(v reflect.Value) (cadence.Value, error) {
+	t := v.Type()
+	switch {
+	case t == ufix64Type:
+		return cadence.UFix64(v.Uint()), nil
+	case t == addressType:
+		return v.Interface().(cadence.Address), nil
+	case isTyped(t):
+		structType, err := codec.StructType(t)
+		if err != nil {
+			return nil, err
+		}
+		fields := []cadence.Value{}
+		for _, f := range taggedFields(t) {
+			value, err := codec.marshal(v.Field(f.index))
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", cadenceTypeName(t), f.name, err)
+			}
+			fields = append(fields, value)
+		}
+		return cadence.NewStruct(fields).WithType(structType), nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return cadence.String(v.String()), nil
+	case reflect.Bool:
+		return cadence.Bool(v.Bool()), nil
+	case reflect.Uint64:
+		return cadence.UInt64(v.Uint()), nil
+	case reflect.Int:
+		return cadence.NewInt(int(v.Int())), nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			return cadence.NewOptional(nil), nil
+		}
+		value, err := codec.marshal(v.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return cadence.NewOptional(value), nil
+	case reflect.Slice:
+		arrayType, err := codec.Type(t)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]cadence.Value, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			value, err := codec.marshal(v.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			values = append(values, value)
+		}
+		return cadence.NewArray(values).WithType(arrayType.(cadence.ArrayType)), nil
+	case reflect.Map:
+		dictionaryType, err := codec.Type(t)
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+		pairs := make([]cadence.KeyValuePair, 0, len(keys))
+		for _, key := range keys {
+			value, err := codec.marshal(v.MapIndex(reflect.ValueOf(key).Convert(t.Key())))
+			if err != nil {
+				return nil, fmt.Errorf("[%q]: %w", key, err)
+			}
+			pairs = append(pairs, cadence.KeyValuePair{Key: cadence.String(key), Value: value})
+		}
+		return cadence.NewDictionary(pairs).WithType(dictionaryType.(cadence.DictionaryType)), nil
+	}
+	return nil, fmt.Errorf("cadence_codec: %s has no Cadence equivalent", t)
+}
+
+// Unmarshal converts a Cadence value, such as a script result, into the Go
+// value v points to. Struct types are matched by contract and type name, so
+// values from any address are accepted.
+func Unmarshal(value cadence.Value, v interface{}) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("cadence_codec: Unmarshal needs a non-nil pointer, not %T", v)
+	}
+	return unmarshal(value, target.Elem())
+}
+
+func unmarshal(value cadence.Value, v reflect.Value) error {
+	t := v.Type()
+
+	// optionals are only kept when the Go side is a pointer
+	if t.Kind() != reflect.Ptr {
+		if optional, ok := value.(cadence.Optional); ok {
+			if optional.Value == nil {
+				return fmt.Errorf("expected %s, found nil", t)
+			}
+			return unmarshal(optional.Value, v)
+		}
+	}
+
+	switch {
+	case t == ufix64Type:
+		ufix64, ok := value.(cadence.UFix64)
+		if !ok {
+			return fmt.Errorf("expected UFix64, found %T", value)
+		}
+		v.SetUint(uint64(ufix64))
+		return nil
+	case t == addressType:
+		address, ok := value.(cadence.Address)
+		if !ok {
+			return fmt.Errorf("expected Address, found %T", value)
+		}
+		v.Set(reflect.ValueOf(address))
+		return nil
+	case isTyped(t):
+		return unmarshalStruct(value, v)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		s, ok := value.(cadence.String)
+		if !ok {
+			return fmt.Errorf("expected String, found %T", value)
+		}
+		v.SetString(string(s))
+	case reflect.Bool:
+		b, ok := value.(cadence.Bool)
+		if !ok {
+			return fmt.Errorf("expected Bool, found %T", value)
+		}
+		v.SetBool(bool(b))
+	case reflect.Uint64:
+		n, ok := value.(cadence.UInt64)
+		if !ok {
+			return fmt.Errorf("expected UInt64, found %T", value)
+		}
+		v.SetUint(uint64(n))
+	case reflect.Int:
+		n, ok := value.(cadence.Int)
+		if !ok {
+			return fmt.Errorf("expected Int, found %T", value)
+		}
+		if !n.Value.IsInt64() {
+			return fmt.Errorf("Int %s does not fit into an int", n.Value)
+		}
+		v.SetInt(n.Value.Int64())
+	case reflect.Ptr:
+		if optional, ok := value.(cadence.Optional); ok {
+			if optional.Value == nil {
+				v.Set(reflect.Zero(t))
+				return nil
+			}
+			value = optional.Value
+		}
+		element := reflect.New(t.Elem())
+		if err := unmarshal(value, element.Elem()); err != nil {
+			return err
+		}
+		v.Set(element)
+	case reflect.Slice:
+		array, ok := value.(cadence.Array)
+		if !ok {
+			return fmt.Errorf("expected an array, found %T", value)
+		}
+		slice := reflect.MakeSlice(t, len(array.Values), len(array.Values))
+		for i, element := range array.Values {
+			if err := unmarshal(element, slice.Index(i)); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		v.Set(slice)
+	case reflect.Map:
+		dictionary, ok := value.(cadence.Dictionary)
+		if !ok {
+			return fmt.Errorf("expected a dictionary, found %T", value)
+		}
+		if t.Key().Kind() != reflect.String {
+			return fmt.Errorf("cadence_codec: only maps with string keys are supported, not %s", t)
+		}
+		m := reflect.MakeMapWithSize(t, len(dictionary.Pairs))
+		for _, pair := range dictionary.Pairs {
+			key, ok := pair.Key.(cadence.String)
+			if !ok {
+				return fmt.Errorf("expected String dictionary keys, found %T", pair.Key)
+			}
+			element := reflect.New(t.Elem()).Elem()
+			if err := unmarshal(pair.Value, element); err != nil {
+				return fmt.Errorf("[%q]: %w", string(key), err)
+			}
+			m.SetMapIndex(reflect.ValueOf(string(key)).Convert(t.Key()), element)
+		}
+		v.Set(m)
+	default:
+		return fmt.Errorf("cadence_codec: %s has no Cadence equivalent", t)
+	}
+	return nil
+}
+
+func unmarshalStruct(value cadence.Value, v reflect.Value) error {
+	t := v.Type()
+	typeName := cadenceTypeName(t)
+
+	s, ok := value.(cadence.Struct)
+	if !ok {
+		return fmt.Errorf("expected a %s struct, found %T", typeName, value)
+	}
+	if s.StructType == nil {
+		return fmt.Errorf("expected a %s struct, found a struct without a type", typeName)
+	}
+	if identifier := s.StructType.QualifiedIdentifier; identifier != typeName && !strings.HasSuffix(identifier, "."+typeName) {
+		return fmt.Errorf("expected a %s struct, found %s", typeName, identifier)
+	}
+	if len(s.StructType.Fields) != len(s.Fields) {
+		return fmt.Errorf("%s has %d field types but %d values", s.StructType.QualifiedIdentifier, len(s.StructType.Fields), len(s.Fields))
+	}
+
+	values := make(map[string]cadence.Value, len(s.Fields))
+	for i, f := range s.StructType.Fields {
+		values[f.Identifier] = s.Fields[i]
+	}
+	for _, f := range taggedFields(t) {
+		fieldValue, ok := values[f.name]
+		if !ok {
+			return fmt.Errorf("%s has no field %q", typeName, f.name)
+		}
+		if err := unmarshal(fieldValue, v.Field(f.index)); err != nil {
+			return fmt.Errorf("%s.%s: %w", typeName, f.name, err)
+		}
+	}
+	return nil
+}
+
+// NewCodecForNetwork resolves the addresses of the given contracts for the
+// network ("emulator", "testnet" or "mainnet") from flow.json
+func NewCodecForNetwork(flowJSONPath string, network string, contractNames ...string) (*Codec, error) {
+	config, err := flow_config.Load(flowJSONPath)
+	if err != nil {
+		return nil, err
+	}
+	addresses := map[string]string{}
+	for _, contractName := range contractNames {
+		address, err := config.ContractAddress(contractName, network)
+		if err != nil {
+			return nil, err
+		}
+		addresses[contractName] = address
+	}
+	return NewCodec(addresses), nil
+}
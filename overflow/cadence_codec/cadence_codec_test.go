@@ -0,0 +1,152 @@
+package cadence_codec_test
+
+import (
+	"floasis-items/flow/overflow/cadence_codec"
+	"floasis-items/flow/overflow/floasis_models"
+	"floasis-items/flow/overflow/svg_prep"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/onflow/cadence"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+)
+
+var testCodec = cadence_codec.NewCodec(map[string]string{
+	"IaNFTAnalogs":      "0x0000000000000001",
+	"FLOASISPrimitives": "0000000000000002",
+	"FLOASISItemsStore": "0000000000000003",
+})
+
+var testSvg = svg_prep.Svg{
+	Name: "svg",
+	Attributes: svg_prep.SvgAttributes{
+		Width: "32", Height: "32", BaseProfile: "tiny", Version: "1.2", ViewBox: "0 0 32 32",
+		Xmlns: "http://www.w3.org/2000/svg", Style: "shape-rendering: crispEdges;",
+	},
+	Children: []svg_prep.GElem{
+		{
+			Name:       "g0",
+			Type:       "rects",
+			Attributes: svg_prep.GElemAttributes{Fill: "#ff0000"},
+			Children: []svg_prep.Rect{
+				{Name: "rect", Type: "rect", Attributes: svg_prep.RectAttributes{X: "0", Y: "1", Width: "2", Height: "3"}},
+			},
+		},
+		// a group without children is an empty array, not a missing one
+		{Name: "g1", Type: "rects", Attributes: svg_prep.GElemAttributes{Fill: "#00ff00"}, Children: []svg_prep.Rect{}},
+	},
+}
+
+// roundTrip marshals v, sends the value through JSON-Cadence like a script
+// result and unmarshals it into a new value of the same type
+func roundTrip[T any](t *testing.T, v T) T {
+	t.Helper()
+	value, err := testCodec.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := jsoncdc.Encode(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := jsoncdc.Decode(nil, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual T
+	if err := cadence_codec.Unmarshal(decoded, &actual); err != nil {
+		t.Fatal(err)
+	}
+	return actual
+}
+
+func TestRoundTripSvg(t *testing.T) {
+	value, err := testCodec.Marshal(testSvg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identifier := value.Type().ID(); identifier != "A.0000000000000001.IaNFTAnalogs.Svg" {
+		t.Errorf("expected the address of IaNFTAnalogs in the type, found %s", identifier)
+	}
+	if actual := roundTrip(t, testSvg); !reflect.DeepEqual(actual, testSvg) {
+		t.Errorf("expected %+v, found %+v", testSvg, actual)
+	}
+}
+
+func TestRoundTripInventoryItem(t *testing.T) {
+	thumbnailPath := "athletian-hat-thumbnail.png"
+	item := floasis_models.InventoryItem{
+		ID:          7,
+		ItemName:    "Athletian Hat 0",
+		Description: "a hat",
+		Category:    "hat",
+		Thumbnail:   "bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi",
+		CreatedTs:   cadence.UFix64(1_666_000_000_12345678),
+		Quantity:    3,
+		Price:       cadence.UFix64(12_34567891),
+		IsActive:    true,
+		ArtItem: floasis_models.Art{
+			Planet:      floasis_models.Planet{Name: "Athleticus", DiscoveredTs: cadence.UFix64(1_00000000)},
+			Base:        testSvg,
+			Card:        testSvg,
+			Description: "Athletian Hat 0 description",
+			Thumbnail:   "bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi",
+		},
+		ArtName:            "Athletian Hat 0",
+		ArtSeries:          "series0",
+		PaymentRecipient:   cadence.BytesToAddress([]byte{1}),
+		RoyaltiesRecipient: cadence.BytesToAddress([]byte{2}),
+		NumSold:            1,
+	}
+
+	tests := []struct {
+		name          string
+		thumbnailPath *string
+	}{
+		{"without optional fields", nil},
+		{"with optional fields", &thumbnailPath},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item.ThumbnailPath = test.thumbnailPath
+			item.ArtItem.ThumbnailPath = test.thumbnailPath
+			if actual := roundTrip(t, item); !reflect.DeepEqual(actual, item) {
+				t.Errorf("expected %+v, found %+v", item, actual)
+			}
+		})
+	}
+}
+
+// renamedRect tags a field the Cadence Rect does not have
+type renamedRect struct {
+	Name  string `cadence:"name"`
+	Label string `cadence:"label"`
+}
+
+func (renamedRect) CadenceType() string { return "IaNFTAnalogs.Rect" }
+
+func TestUnmarshalMismatch(t *testing.T) {
+	rect, err := testCodec.Marshal(svg_prep.Rect{Name: "rect", Type: "rect"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		target   interface{}
+		expected string
+	}{
+		{"unknown tag", &renamedRect{}, `IaNFTAnalogs.Rect has no field "label"`},
+		{"other struct type", &svg_prep.GElem{}, "expected a IaNFTAnalogs.GElem struct, found A.0000000000000001.IaNFTAnalogs.Rect"},
+		{"not a struct", new(string), "expected String, found cadence.Struct"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := cadence_codec.Unmarshal(rect, test.target)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected an error containing %q, found %v", test.expected, err)
+			}
+		})
+	}
+}
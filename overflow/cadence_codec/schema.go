@@ -0,0 +1,169 @@
+package cadence_codec
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/parser"
+)
+
+// SchemaError is a difference between the Go model of a struct and its
+// declaration in a contract
+type SchemaError struct {
+	CadenceType string
+	Message     string
+}
+
+func (err SchemaError) Error() string {
+	return err.CadenceType + ": " + err.Message
+}
+
+// SchemaErrors collects every difference found by CheckSchema
+type SchemaErrors []SchemaError
+
+func (errs SchemaErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d schema difference(s) between the Go models and the contract:\n  %s", len(errs), strings.Join(messages, "\n  "))
+}
+
+// schemaField is a struct field as "name: Type", with types written the way
+// the contracts write them, but always qualified by their contract
+type schemaField struct {
+	name       string
+	typeString string
+}
+
+// goTypeString writes the Cadence type a Go type is marshaled to, see Codec.Type
+func goTypeString(t reflect.Type) (string, error) {
+	switch {
+	case t == ufix64Type:
+		return "UFix64", nil
+	case t == addressType:
+		return "Address", nil
+	case isTyped(t):
+		return cadenceTypeName(t), nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "String", nil
+	case reflect.Bool:
+		return "Bool", nil
+	case reflect.Uint64:
+		return "UInt64", nil
+	case reflect.Int:
+		return "Int", nil
+	case reflect.Ptr:
+		element, err := goTypeString(t.Elem())
+		return element + "?", err
+	case reflect.Slice:
+		element, err := goTypeString(t.Elem())
+		return "[" + element + "]", err
+	case reflect.Map:
+		element, err := goTypeString(t.Elem())
+		return "{String: " + element + "}", err
+	}
+	return "", fmt.Errorf("cadence_codec: %s has no Cadence equivalent", t)
+}
+
+// astTypeString writes a declared field type. Types nested in the contract
+// are qualified with its name, so that they compare equal to CadenceType.
+func astTypeString(t ast.Type, contractName string, nestedTypes map[string]bool) string {
+	switch t := t.(type) {
+	case *ast.NominalType:
+		names := []string{t.Identifier.Identifier}
+		for _, nested := range t.NestedIdentifiers {
+			names = append(names, nested.Identifier)
+		}
+		if len(names) == 1 && nestedTypes[names[0]] {
+			names = append([]string{contractName}, names...)
+		}
+		return strings.Join(names, ".")
+	case *ast.OptionalType:
+		return astTypeString(t.Type, contractName, nestedTypes) + "?"
+	case *ast.VariableSizedType:
+		return "[" + astTypeString(t.Type, contractName, nestedTypes) + "]"
+	case *ast.DictionaryType:
+		return "{" + astTypeString(t.KeyType, contractName, nestedTypes) + ": " + astTypeString(t.ValueType, contractName, nestedTypes) + "}"
+	}
+	return t.String()
+}
+
+// CheckSchema parses the source of a contract and compares the structs it
+// declares with the Go models of those structs: field names, field order and
+// field types all have to match, or transactions built from the models fail.
+// Every model has to be declared in the contract.
+func CheckSchema(contractCode []byte, models ...Typed) error {
+	program, err := parser.ParseProgram(contractCode, nil)
+	if err != nil {
+		return fmt.Errorf("cadence_codec: parsing contract: %w", err)
+	}
+	contracts := program.CompositeDeclarations()
+	if len(contracts) != 1 {
+		return fmt.Errorf("cadence_codec: expected a single contract declaration, found %d", len(contracts))
+	}
+	contract := contracts[0]
+	contractName := contract.Identifier.Identifier
+
+	nestedTypes := map[string]bool{}
+	for _, composite := range contract.Members.Composites() {
+		nestedTypes[composite.Identifier.Identifier] = true
+	}
+	declarations := contract.Members.CompositesByIdentifier()
+
+	errs := SchemaErrors{}
+	for _, model := range models {
+		typeName := model.CadenceType()
+		fail := func(format string, args ...interface{}) {
+			errs = append(errs, SchemaError{CadenceType: typeName, Message: fmt.Sprintf(format, args...)})
+		}
+
+		name := strings.TrimPrefix(typeName, contractName+".")
+		declaration, ok := declarations[name]
+		if name == typeName || !ok {
+			fail("not declared in contract %s", contractName)
+			continue
+		}
+
+		declared := []schemaField{}
+		for _, f := range declaration.Members.Fields() {
+			declared = append(declared, schemaField{
+				name:       f.Identifier.Identifier,
+				typeString: astTypeString(f.TypeAnnotation.Type, contractName, nestedTypes),
+			})
+		}
+
+		t := reflect.TypeOf(model)
+		modeled := []schemaField{}
+		for _, f := range taggedFields(t) {
+			typeString, err := goTypeString(t.Field(f.index).Type)
+			if err != nil {
+				fail("field %s: %s", f.name, err)
+			}
+			modeled = append(modeled, schemaField{name: f.name, typeString: typeString})
+		}
+
+		for i := 0; i < len(declared) || i < len(modeled); i++ {
+			switch {
+			case i >= len(modeled):
+				fail("field %d %s: %s is declared in the contract but missing from the Go model", i, declared[i].name, declared[i].typeString)
+			case i >= len(declared):
+				fail("field %d %s: %s is in the Go model but not declared in the contract", i, modeled[i].name, modeled[i].typeString)
+			case declared[i].name != modeled[i].name:
+				fail("field %d is %s in the contract but %s in the Go model", i, declared[i].name, modeled[i].name)
+			case declared[i].typeString != modeled[i].typeString:
+				fail("field %s is %s in the contract but %s in the Go model", declared[i].name, declared[i].typeString, modeled[i].typeString)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
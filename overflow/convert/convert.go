@@ -2,14 +2,22 @@
 package convert
 
 import (
+	"errors"
+	"flag"
 	"floasis-items/flow/overflow/png2svg"
 	"fmt"
+	"image"
 	"io/ioutil"
 	"math/rand"
 	"strings"
 	"time"
 )
 
+// ChromaKeyWarningShare is the default share of the image above which removing
+// the chroma key color prints a warning, since the key probably hit the artwork too.
+// Accessories are mostly background, but rarely more than half of it.
+const ChromaKeyWarningShare = 0.5
+
 func init() {
 	// Seed the random number generator
 	rand.Seed(time.Now().UTC().UnixNano())
@@ -23,6 +31,14 @@ type Config struct {
 	limit                 bool // limit colors to a maximum of 4096 (#abcdef -> #ace)
 	singlePixelRectangles bool // use only single pixel rectangles
 	verbose               bool
+	boundingBoxes         bool   // write content and color group bounding boxes to the SVG
+	chromaKey             string // background color to treat as transparent, "auto" for the corner color
+	chromaTolerance       int    // maximum per channel difference from the chroma key color
+	chromaFloodFill       bool   // only remove chroma key pixels connected to the edges
+	chromaWarningShare    float64
+	outlineColor          string // color of the generated outline, no outline if empty
+	outlineConnectivity   int    // 4 or 8 connected outline
+	outlineInside         bool   // replace the outermost artwork pixels instead of surrounding them
 }
 
 func NewConfig(
@@ -41,13 +57,103 @@ func NewConfig(
 		limit:                 limit,
 		singlePixelRectangles: singlePixelRectangles,
 		verbose:               verbose,
+		chromaWarningShare:    ChromaKeyWarningShare,
+		outlineConnectivity:   4,
 	}
 
 	return &c, "", nil
 
 }
 
+// SetBoundingBoxes can be used to write the bounding box of the artwork and of
+// each color group to the SVG as data-bbox attributes
+func (c *Config) SetBoundingBoxes(enabled bool) {
+	c.boundingBoxes = enabled
+}
+
+// SetChromaKey can be used to treat a solid background color as transparent.
+// The color is either on the "#rrggbb" form or "auto", for the color found
+// in the corners of the image. If floodFill is true, only background pixels
+// connected to the edges are removed, so the same color inside the artwork is kept.
+func (c *Config) SetChromaKey(color string, tolerance int, floodFill bool) {
+	c.chromaKey = color
+	c.chromaTolerance = tolerance
+	c.chromaFloodFill = floodFill
+}
+
+// SetChromaKeyWarningShare can be used to change the share of removed pixels,
+// between 0 and 1, above which a warning is printed
+func (c *Config) SetChromaKeyWarningShare(share float64) {
+	c.chromaWarningShare = share
+}
+
+// SetOutline can be used to generate a 1px outline of the given "#rrggbb" color
+// around the opaque regions of the image. Connectivity is 4 or 8, where 8 also
+// outlines diagonal neighbours. If inside is true, the outermost pixels of the
+// artwork become the outline, otherwise the outline surrounds the artwork.
+// The outline is written to its own group, so that it can be recolored on its own.
+func (c *Config) SetOutline(color string, connectivity int, inside bool) {
+	c.outlineColor = color
+	c.outlineConnectivity = connectivity
+	c.outlineInside = inside
+}
+
+// RegisterFlags adds flags for the conversion options to fs, which set the
+// options of the config once fs is parsed, for the tools that convert PNGs
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&c.boundingBoxes, "bbox", c.boundingBoxes, "record the bounding box of the artwork and of every color group in the SVG")
+	fs.StringVar(&c.chromaKey, "chroma-key", c.chromaKey, `background color to make transparent, "#rrggbb" or "auto" for the corner color, none if empty`)
+	fs.IntVar(&c.chromaTolerance, "chroma-tolerance", c.chromaTolerance, "maximum per channel difference from the chroma key color")
+	fs.BoolVar(&c.chromaFloodFill, "chroma-flood-fill", c.chromaFloodFill, "only remove chroma key pixels connected to the edges of the image")
+	fs.Float64Var(&c.chromaWarningShare, "chroma-warning", c.chromaWarningShare, "share of the image, between 0 and 1, above which removing the chroma key prints a warning")
+	fs.StringVar(&c.outlineColor, "outline", c.outlineColor, `color of a 1px outline around the artwork, "#rrggbb", none if empty`)
+	fs.IntVar(&c.outlineConnectivity, "outline-connectivity", c.outlineConnectivity, "4, or 8 to also outline diagonal neighbours")
+	fs.BoolVar(&c.outlineInside, "outline-inside", c.outlineInside, "make the outermost artwork pixels the outline instead of surrounding the artwork")
+}
+
+// removeChromaKey makes the chroma key color of the image transparent
+func (c *Config) removeChromaKey(pi *png2svg.PixelImage, pixelCount int) error {
+	var r, g, b int
+	if c.chromaKey == "auto" {
+		var ok bool
+		r, g, b, ok = pi.CornerColor()
+		if !ok {
+			fmt.Println("no background color found in the corners, nothing removed:", c.inputFilename)
+			return nil
+		}
+	} else {
+		var err error
+		r, g, b, err = png2svg.ParseHexColor(c.chromaKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	removed := pi.RemoveColor(r, g, b, c.chromaTolerance, c.chromaFloodFill)
+	share := float64(removed) / float64(pixelCount)
+	if c.verbose {
+		fmt.Printf("Removed %d background pixels of color #%02x%02x%02x\n", removed, r, g, b)
+	}
+	if share > c.chromaWarningShare {
+		fmt.Printf("warning: chroma key #%02x%02x%02x removed %.0f%% of %s, check that it did not hit the artwork\n", r, g, b, share*100, c.inputFilename)
+	}
+	return nil
+}
+
+// DefaultConfig returns the config Convert and ConvertPNGtoSVG use, to start
+// from when setting options
+func DefaultConfig() *Config {
+	c, _, _ := NewConfig("", "", false, false, false, true)
+	return c
+}
+
 func Convert(pngDirPath string, svgDirPath string) {
+	ConvertWithConfig(pngDirPath, svgDirPath, DefaultConfig())
+}
+
+// ConvertWithConfig converts every PNG in pngDirPath that does not have an SVG
+// in svgDirPath yet, using the options of the given config
+func ConvertWithConfig(pngDirPath string, svgDirPath string, template *Config) {
 
 	files, _ := ioutil.ReadDir(pngDirPath)
 
@@ -62,7 +168,12 @@ func Convert(pngDirPath string, svgDirPath string) {
 		_, err := ioutil.ReadFile(svg_file_path)
 		if err != nil {
 			fmt.Println("file at png path will be converted to SVG:", png_file_path)
-			ConvertPNGtoSVG(png_file_path, svg_file_path)
+			c := *template
+			c.inputFilename = png_file_path
+			c.outputFilename = svg_file_path
+			if err := c.Run(); err != nil {
+				fmt.Println("file at png path could not be converted to SVG:", png_file_path, err)
+			}
 		} else {
 			fmt.Println("file at svg path exists and will not be converted to SVG:", svg_file_path)
 		}
@@ -70,18 +181,17 @@ func Convert(pngDirPath string, svgDirPath string) {
 	}
 }
 
-// Run performs the user-selected operations
-func ConvertPNGtoSVG(png_path string, svg_path string) error {
-
-	var (
-		box          *png2svg.Box
-		x, y         int
-		expanded     bool
-		lastx, lasty int
-		lastLine     int // one message per line / y coordinate
-		done         bool
-	)
+// ConvertPNGtoSVGWithConfig converts a single PNG to an SVG, using the
+// options of the given config
+func ConvertPNGtoSVGWithConfig(png_path string, svg_path string, template *Config) error {
+	c := *template
+	c.inputFilename = png_path
+	c.outputFilename = svg_path
+	return c.Run()
+}
 
+// ConvertPNGtoSVG converts a single PNG to an SVG with the default options
+func ConvertPNGtoSVG(png_path string, svg_path string) error {
 	// c, quitMessage, err := NewConfigFromFlags()
 	c, quitMessage, err := NewConfig(
 		png_path,
@@ -99,15 +209,82 @@ func ConvertPNGtoSVG(png_path string, svg_path string) error {
 		return nil
 	}
 
+	return c.Run()
+}
+
+// Run performs the user-selected operations
+func (c *Config) Run() error {
 	img, err := png2svg.ReadPNG(c.inputFilename, c.verbose)
 	if err != nil {
 		return err
 	}
 
+	pi, err := c.convertImage(img)
+	if err != nil {
+		return err
+	}
+
+	// Write the SVG image to outputFilename
+	return pi.WriteSVG(c.outputFilename)
+}
+
+// ConvertImage converts an image that is already in memory, such as a
+// rasterized SVG, with the default options and returns the SVG document
+func ConvertImage(img image.Image) ([]byte, error) {
+	c, _, err := NewConfig("", "", false, false, false, false)
+	if err != nil {
+		return nil, err
+	}
+	pi, err := c.convertImage(img)
+	if err != nil {
+		return nil, err
+	}
+	if !pi.Done(0, 0) {
+		return nil, errors.New("the SVG representation does not cover all pixels")
+	}
+	return pi.Bytes(), nil
+}
+
+// convertImage covers every pixel of the image with rectangles
+func (c *Config) convertImage(img image.Image) (*png2svg.PixelImage, error) {
+
+	var (
+		box          *png2svg.Box
+		x, y         int
+		expanded     bool
+		lastx, lasty int
+		lastLine     int // one message per line / y coordinate
+		done         bool
+	)
+
 	height := img.Bounds().Max.Y - img.Bounds().Min.Y
 
 	pi := png2svg.NewPixelImage(img, c.verbose)
 	pi.SetColorOptimize(c.limit)
+	pi.SetBoundingBoxes(c.boundingBoxes)
+
+	if c.chromaKey != "" {
+		if err := c.removeChromaKey(pi, img.Bounds().Dx()*img.Bounds().Dy()); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.outlineColor != "" {
+		if c.outlineConnectivity != 4 && c.outlineConnectivity != 8 {
+			return nil, fmt.Errorf("outline connectivity must be 4 or 8, not %d", c.outlineConnectivity)
+		}
+		r, g, b, err := png2svg.ParseHexColor(c.outlineColor)
+		if err != nil {
+			return nil, err
+		}
+		outlined := pi.AddOutline(r, g, b, c.outlineConnectivity == 8, c.outlineInside)
+		if c.verbose {
+			fmt.Printf("Outlined %d pixels\n", outlined)
+		}
+	}
+
+	// A fully transparent image has nothing to cover
+	done = pi.Done(0, 0)
 
 	if c.verbose {
 		fmt.Print("Placing rectangles... 0%")
@@ -152,6 +329,5 @@ func ConvertPNGtoSVG(png_path string, svg_path string) error {
 		pi.CoverAllPixels()
 	}
 
-	// Write the SVG image to outputFilename
-	return pi.WriteSVG(c.outputFilename)
+	return pi, nil
 }
@@ -0,0 +1,80 @@
+/*
+Go models of the FLOASISPrimitives and FLOASISItemsStore structs, for use with
+cadence_codec. Field order follows the contract declarations.
+*/
+
+package floasis_models
+
+import (
+	"floasis-items/flow/overflow/svg_prep"
+
+	"github.com/onflow/cadence"
+)
+
+// Contracts are the contracts whose structs the models in this package, and
+// the IaNFTAnalogs models of svg_prep, refer to
+var Contracts = []string{"IaNFTAnalogs", "FLOASISPrimitives", "FLOASISItemsStore"}
+
+// Planet is the Go model of a FLOASISPrimitives.Planet
+type Planet struct {
+	Name         string         `cadence:"name"`
+	DiscoveredTs cadence.UFix64 `cadence:"discoveredTs"`
+}
+
+func (Planet) CadenceType() string { return "FLOASISPrimitives.Planet" }
+
+// Art is the Go model of a FLOASISPrimitives.Art
+type Art struct {
+	Planet        Planet       `cadence:"planet"`
+	Base          svg_prep.Svg `cadence:"base"`
+	Card          svg_prep.Svg `cadence:"card"`
+	Description   string       `cadence:"description"`
+	Thumbnail     string       `cadence:"thumbnail"`
+	ThumbnailPath *string      `cadence:"thumbnailPath"`
+}
+
+func (Art) CadenceType() string { return "FLOASISPrimitives.Art" }
+
+// Series is the Go model of a FLOASISPrimitives.Series
+type Series struct {
+	Art map[string]Art `cadence:"art"`
+}
+
+func (Series) CadenceType() string { return "FLOASISPrimitives.Series" }
+
+// Artist is the Go model of a FLOASISPrimitives.Artist
+type Artist struct {
+	Address cadence.Address   `cadence:"address"`
+	Series  map[string]Series `cadence:"series"`
+}
+
+func (Artist) CadenceType() string { return "FLOASISPrimitives.Artist" }
+
+// CompositeGroup is the Go model of a FLOASISPrimitives.CompositeGroup
+type CompositeGroup struct {
+	Group map[string]svg_prep.Svg `cadence:"group"`
+}
+
+func (CompositeGroup) CadenceType() string { return "FLOASISPrimitives.CompositeGroup" }
+
+// InventoryItem is the Go model of a FLOASISItemsStore.InventoryItem
+type InventoryItem struct {
+	ID                 uint64          `cadence:"id"`
+	ItemName           string          `cadence:"itemName"`
+	Description        string          `cadence:"description"`
+	Category           string          `cadence:"category"`
+	Thumbnail          string          `cadence:"thumbnail"`
+	ThumbnailPath      *string         `cadence:"thumbnailPath"`
+	CreatedTs          cadence.UFix64  `cadence:"createdTs"`
+	Quantity           uint64          `cadence:"quantity"`
+	Price              cadence.UFix64  `cadence:"price"`
+	IsActive           bool            `cadence:"isActive"`
+	ArtItem            Art             `cadence:"artItem"`
+	ArtName            string          `cadence:"artName"`
+	ArtSeries          string          `cadence:"artSeries"`
+	PaymentRecipient   cadence.Address `cadence:"paymentRecipient"`
+	RoyaltiesRecipient cadence.Address `cadence:"royaltiesRecipient"`
+	NumSold            uint64          `cadence:"numSold"`
+}
+
+func (InventoryItem) CadenceType() string { return "FLOASISItemsStore.InventoryItem" }
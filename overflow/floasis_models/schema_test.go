@@ -0,0 +1,61 @@
+package floasis_models
+
+import (
+	"floasis-items/flow/overflow/cadence_codec"
+	"floasis-items/flow/overflow/svg_prep"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// contractsDir is the contracts directory at the root of the repository
+var contractsDir = filepath.Join("..", "..", "contracts")
+
+func readContract(t *testing.T, contractName string) []byte {
+	t.Helper()
+	code, err := os.ReadFile(filepath.Join(contractsDir, contractName+".cdc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// TestSchemaMatchesContracts fails when a contract struct gains, loses,
+// reorders or retypes a field without the Go model following
+func TestSchemaMatchesContracts(t *testing.T) {
+	models := map[string][]cadence_codec.Typed{
+		"IaNFTAnalogs": {
+			svg_prep.Svg{}, svg_prep.SvgAttributes{},
+			svg_prep.GElem{}, svg_prep.GElemAttributes{},
+			svg_prep.Rect{}, svg_prep.RectAttributes{},
+		},
+		"FLOASISPrimitives": {Planet{}, Art{}, Series{}, Artist{}, CompositeGroup{}},
+		"FLOASISItemsStore": {InventoryItem{}},
+	}
+	for _, contractName := range Contracts {
+		t.Run(contractName, func(t *testing.T) {
+			if err := cadence_codec.CheckSchema(readContract(t, contractName), models[contractName]...); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestSchemaDetectsDrift makes sure a new contract field is reported
+func TestSchemaDetectsDrift(t *testing.T) {
+	code := string(readContract(t, "FLOASISPrimitives"))
+	declaration := "pub let discoveredTs: UFix64"
+	if !strings.Contains(code, declaration) {
+		t.Fatalf("FLOASISPrimitives no longer declares %q", declaration)
+	}
+	code = strings.Replace(code, declaration, declaration+"\n        pub let moons: [String]", 1)
+
+	err := cadence_codec.CheckSchema([]byte(code), Planet{})
+	if err == nil {
+		t.Fatal("expected the added Planet.moons field to be reported")
+	}
+	if !strings.Contains(err.Error(), "moons: [String] is declared in the contract but missing from the Go model") {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Resolves contract and account addresses from flow.json, the same way the
+flow CLI and overflow do, so that Go tooling does not have to read .env
+variables by hand.
+*/
+
+package flow_config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// Networks are the flow.json network names addresses can be resolved for
+var Networks = []string{"emulator", "testnet", "mainnet"}
+
+// EmulatorServiceAddress is the service account every emulator starts with
+const EmulatorServiceAddress = "f8d6e0586b0a20c7"
+
+var addressPattern = regexp.MustCompile(`^[0-9a-fA-F]{1,16}$`)
+
+type contract struct {
+	Source  string            `json:"source"`
+	Aliases map[string]string `json:"aliases"`
+}
+
+type account struct {
+	Address string `json:"address"`
+}
+
+// FlowConfig is the part of flow.json needed to find where contracts live
+type FlowConfig struct {
+	Contracts   map[string]contract                     `json:"contracts"`
+	Accounts    map[string]account                      `json:"accounts"`
+	Deployments map[string]map[string][]json.RawMessage `json:"deployments"`
+}
+
+// DefaultPath is where the tools find flow.json, run from the repository root
+const DefaultPath = "flow.json"
+
+// LoadEnv loads the .env next to the flow.json at path, if present, for the
+// settings Go tooling reads from the environment, like the IPFS credentials.
+// Variables that are already set in the environment take precedence.
+func LoadEnv(path string) error {
+	envPath := filepath.Join(filepath.Dir(path), ".env")
+	if _, err := os.Stat(envPath); err != nil {
+		return nil
+	}
+	if err := godotenv.Load(envPath); err != nil {
+		return fmt.Errorf("flow_config: loading %s: %w", envPath, err)
+	}
+	return nil
+}
+
+// Load reads the flow.json at path. The .env next to it is loaded first, see
+// LoadEnv, so that $VARIABLE values can be substituted.
+func Load(path string) (*FlowConfig, error) {
+	if err := LoadEnv(path); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config FlowConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("flow_config: %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// checkNetwork rejects network names flow.json does not use, instead of
+// silently falling back to another network
+func checkNetwork(network string) error {
+	for _, known := range Networks {
+		if network == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("flow_config: unknown network %q, expected one of %s", network, strings.Join(Networks, ", "))
+}
+
+// normalizeAddress substitutes $VARIABLES in a flow.json address and returns
+// it as 16 hex digits without the 0x prefix
+func normalizeAddress(value string, origin string) (string, error) {
+	missing := []string{}
+	expanded := os.Expand(value, func(name string) string {
+		v, ok := os.LookupEnv(name)
+		if !ok || v == "" {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("flow_config: %s uses %s, which is not set in the environment or .env", origin, strings.Join(missing, ", "))
+	}
+
+	address := strings.TrimPrefix(strings.TrimSpace(expanded), "0x")
+	if !addressPattern.MatchString(address) {
+		return "", fmt.Errorf("flow_config: %s resolved to %q, which is not a Flow address", origin, expanded)
+	}
+	return fmt.Sprintf("%016s", strings.ToLower(address)), nil
+}
+
+// AccountAddress resolves the address of a flow.json account
+func (config *FlowConfig) AccountAddress(accountName string) (string, error) {
+	acct, ok := config.Accounts[accountName]
+	if !ok {
+		return "", fmt.Errorf("flow_config: no account %q in flow.json", accountName)
+	}
+	return normalizeAddress(acct.Address, fmt.Sprintf("account %q", accountName))
+}
+
+// deployedBy returns the account that deploys the contract on the network, if any
+func (config *FlowConfig) deployedBy(contractName string, network string) (string, bool) {
+	for accountName, contracts := range config.Deployments[network] {
+		for _, raw := range contracts {
+			// deployments are either a contract name or an object with a name and arguments
+			var name string
+			if err := json.Unmarshal(raw, &name); err != nil {
+				var withArgs struct {
+					Name string `json:"name"`
+				}
+				if err := json.Unmarshal(raw, &withArgs); err != nil {
+					continue
+				}
+				name = withArgs.Name
+			}
+			if name == contractName {
+				return accountName, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ContractAddress resolves where a contract lives on the network: its alias if
+// it has one, otherwise the account that deploys it. On the emulator, contracts
+// without either are expected on the service account. The address is returned
+// as 16 hex digits without the 0x prefix, as used in Cadence type identifiers.
+func (config *FlowConfig) ContractAddress(contractName string, network string) (string, error) {
+	if err := checkNetwork(network); err != nil {
+		return "", err
+	}
+	c, ok := config.Contracts[contractName]
+	if !ok {
+		return "", fmt.Errorf("flow_config: no contract %q in flow.json", contractName)
+	}
+	if alias, ok := c.Aliases[network]; ok {
+		return normalizeAddress(alias, fmt.Sprintf("%s alias of %s", network, contractName))
+	}
+	if accountName, ok := config.deployedBy(contractName, network); ok {
+		return config.AccountAddress(accountName)
+	}
+	if network == "emulator" {
+		return EmulatorServiceAddress, nil
+	}
+	return "", fmt.Errorf("flow_config: %s has no %s alias and is not deployed on %s in flow.json", contractName, network, network)
+}
+
+// ResolveContractAddress loads flow.json at path and resolves a single contract address
+func ResolveContractAddress(path string, contractName string, network string) (string, error) {
+	config, err := Load(path)
+	if err != nil {
+		return "", err
+	}
+	return config.ContractAddress(contractName, network)
+}
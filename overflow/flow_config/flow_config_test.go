@@ -0,0 +1,142 @@
+package flow_config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testFlowJSON = `{
+	"contracts": {
+		"Aliased": {
+			"source": "./contracts/Aliased.cdc",
+			"aliases": {"testnet": "0x0000000000000001", "mainnet": "$TEST_FLOW_CONFIG_MAINNET"}
+		},
+		"Short": {"source": "./contracts/Short.cdc", "aliases": {"testnet": "0xABC"}},
+		"Deployed": {"source": "./contracts/Deployed.cdc"},
+		"WithArgs": {"source": "./contracts/WithArgs.cdc"},
+		"Local": {"source": "./contracts/Local.cdc"},
+		"Ghost": {"source": "./contracts/Ghost.cdc"},
+		"Unset": {"source": "./contracts/Unset.cdc", "aliases": {"mainnet": "$TEST_FLOW_CONFIG_UNSET"}},
+		"TooLong": {"source": "./contracts/TooLong.cdc", "aliases": {"testnet": "0x12345678901234567"}}
+	},
+	"accounts": {
+		"emulator-account": {"address": "01cf0e2f2f715450"},
+		"testnet-account": {"address": "$TEST_FLOW_CONFIG_ACCOUNT"}
+	},
+	"deployments": {
+		"emulator": {
+			"emulator-account": [{"name": "WithArgs", "args": [{"type": "String", "value": "hat"}]}]
+		},
+		"testnet": {
+			"testnet-account": ["Aliased", "Deployed"]
+		},
+		"mainnet": {
+			"ghost-account": ["Ghost"]
+		}
+	}
+}`
+
+// writeTestConfig writes the flow.json fixture and a .env next to it
+func writeTestConfig(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "flow.json")
+	if err := os.WriteFile(path, []byte(testFlowJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("TEST_FLOW_CONFIG_ACCOUNT=0xF\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// the .env is loaded into the environment of the test process
+	t.Cleanup(func() { os.Unsetenv("TEST_FLOW_CONFIG_ACCOUNT") })
+	return path
+}
+
+func TestContractAddress(t *testing.T) {
+	t.Setenv("TEST_FLOW_CONFIG_MAINNET", "0x1654653399040A61")
+	config, err := Load(writeTestConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		contract string
+		network  string
+		expected string
+	}{
+		// the alias wins over the deployment by testnet-account
+		{"Aliased", "testnet", "0000000000000001"},
+		{"Aliased", "mainnet", "1654653399040a61"},
+		{"Short", "testnet", "0000000000000abc"},
+		{"Deployed", "testnet", "000000000000000f"},
+		{"WithArgs", "emulator", "01cf0e2f2f715450"},
+		{"Local", "emulator", EmulatorServiceAddress},
+	}
+	for _, test := range tests {
+		t.Run(test.contract+" on "+test.network, func(t *testing.T) {
+			address, err := config.ContractAddress(test.contract, test.network)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if address != test.expected {
+				t.Errorf("expected %s, found %s", test.expected, address)
+			}
+		})
+	}
+}
+
+func TestContractAddressErrors(t *testing.T) {
+	config, err := Load(writeTestConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		contract string
+		network  string
+		expected string
+	}{
+		{"Aliased", "devnet", `flow_config: unknown network "devnet", expected one of emulator, testnet, mainnet`},
+		{"Missing", "testnet", `flow_config: no contract "Missing" in flow.json`},
+		{"Local", "testnet", "flow_config: Local has no testnet alias and is not deployed on testnet in flow.json"},
+		{"Ghost", "mainnet", `flow_config: no account "ghost-account" in flow.json`},
+		{"Unset", "mainnet", "flow_config: mainnet alias of Unset uses TEST_FLOW_CONFIG_UNSET, which is not set in the environment or .env"},
+		{"TooLong", "testnet", `flow_config: testnet alias of TooLong resolved to "0x12345678901234567", which is not a Flow address`},
+	}
+	for _, test := range tests {
+		t.Run(test.contract+" on "+test.network, func(t *testing.T) {
+			_, err := config.ContractAddress(test.contract, test.network)
+			if err == nil || err.Error() != test.expected {
+				t.Errorf("expected %q, found %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestAccountAddress(t *testing.T) {
+	config, err := Load(writeTestConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := config.AccountAddress("testnet-account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address != "000000000000000f" {
+		t.Errorf("expected the address from .env padded to 16 hex digits, found %s", address)
+	}
+	if _, err := config.AccountAddress("mainnet-account"); err == nil || err.Error() != `flow_config: no account "mainnet-account" in flow.json` {
+		t.Errorf("expected an unknown account error, found %v", err)
+	}
+}
+
+func TestResolveContractAddress(t *testing.T) {
+	address, err := ResolveContractAddress(writeTestConfig(t), "Deployed", "testnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address != "000000000000000f" {
+		t.Errorf("expected 000000000000000f, found %s", address)
+	}
+}
@@ -2,6 +2,7 @@ package helpers
 
 import (
 	"encoding/csv"
+	"io"
 	"os"
 )
 
@@ -20,3 +21,36 @@ func ReadCsvFile(filename string) ([][]string, error) {
 	}
 	return lines, nil
 }
+
+// AppendCsvFile appends records to the end of a CSV file, creating it if it
+// does not exist. The manifests in art/ are saved without a trailing newline,
+// so one is added first when needed.
+func AppendCsvFile(filename string, records [][]string) error {
+	fileContent, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer fileContent.Close()
+
+	info, err := fileContent.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > 0 {
+		lastByte := make([]byte, 1)
+		if _, err := fileContent.ReadAt(lastByte, info.Size()-1); err != nil && err != io.EOF {
+			return err
+		}
+		if lastByte[0] != '\n' {
+			if _, err := fileContent.Write([]byte("\n")); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer := csv.NewWriter(fileContent)
+	if err := writer.WriteAll(records); err != nil {
+		return err
+	}
+	return writer.Error()
+}
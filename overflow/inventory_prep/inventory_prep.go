@@ -2,107 +2,115 @@ package inventory_prep
 
 import (
 	"context"
-	"floasis-items/flow/overflow/helpers"
-	"fmt"
-	"log"
-	"os"
-	"strconv"
+	"floasis-items/flow/overflow/flow_config"
+	"floasis-items/flow/overflow/ipfs_pin"
+	"floasis-items/flow/overflow/manifest"
+	"floasis-items/flow/overflow/tx_args"
+	"path/filepath"
 
-	"github.com/joho/godotenv"
+	"github.com/ipfs/go-cid"
 	"github.com/onflow/cadence"
-	"github.com/web3-storage/go-w3s-client"
 )
 
-func PrepareInventory(
-	inventoryCSVPath string,
-	artRepoPath string,
-	flowNetwork string) (
-	cadence.Array,
-	cadence.Array,
-	cadence.Array,
-	cadence.Array,
-	cadence.Array,
-	cadence.Array,
-	cadence.Array,
-	cadence.Array,
-	cadence.Array,
-	cadence.Array) {
+// Item is an InventoryEntry ready to be added to the store, with the IPFS CID
+// of its thumbnail computed, see PinThumbnails
+type Item struct {
+	Entry         manifest.InventoryEntry
+	ThumbnailPath string
+	ThumbnailCID  cid.Cid
+}
 
-	err := godotenv.Load(".env")
-	if err != nil {
-		log.Fatal("Error in inventory_prep.go when loading .env file")
+// InventoryBatch is the inventory added to the store with one
+// FLOASISItemsStore/batch_add_inventory transaction
+type InventoryBatch struct {
+	Items                 []Item
+	AllPaymentsRecipient  cadence.Address
+	AllRoyaltiesRecipient cadence.Address
+}
+
+// Args names the arguments the way batch_add_inventory takes them, so that
+// no array can end up in the place of another
+func (batch InventoryBatch) Args() tx_args.Args {
+	itemNames := []cadence.Value{}
+	itemDescriptions := []cadence.Value{}
+	itemCategories := []cadence.Value{}
+	itemThumbnails := []cadence.Value{}
+	itemThumbnailPaths := []cadence.Value{}
+	itemQuantities := []cadence.Value{}
+	itemPrices := []cadence.Value{}
+	itemArtistNames := []cadence.Value{}
+	itemSeriesNames := []cadence.Value{}
+	itemArtNames := []cadence.Value{}
+
+	for _, item := range batch.Items {
+		itemNames = append(itemNames, cadence.String(item.Entry.Name))
+		itemDescriptions = append(itemDescriptions, cadence.String(item.Entry.Description))
+		itemCategories = append(itemCategories, cadence.String(item.Entry.Category))
+		itemThumbnails = append(itemThumbnails, cadence.String(item.ThumbnailCID.String()))
+		itemThumbnailPaths = append(itemThumbnailPaths, cadence.String(item.Entry.ThumbnailFileName()))
+		itemQuantities = append(itemQuantities, cadence.UInt64(item.Entry.Quantity))
+		itemPrices = append(itemPrices, item.Entry.Price)
+		itemArtistNames = append(itemArtistNames, cadence.String(item.Entry.ArtistName))
+		itemSeriesNames = append(itemSeriesNames, cadence.String(item.Entry.SeriesName))
+		itemArtNames = append(itemArtNames, cadence.String(item.Entry.ArtName))
 	}
-	WEB3_STORAGE_IPFS_API_KEY := os.Getenv("WEB3_STORAGE_IPFS_API_KEY")
-	c, _ := w3s.NewClient(w3s.WithToken(WEB3_STORAGE_IPFS_API_KEY))
 
-	csvData, err := helpers.ReadCsvFile(inventoryCSVPath)
-	if err != nil {
-		panic(err)
+	return tx_args.Args{
+		"itemNames":             cadence.NewArray(itemNames),
+		"itemDescriptions":      cadence.NewArray(itemDescriptions),
+		"itemCategories":        cadence.NewArray(itemCategories),
+		"itemThumbnails":        cadence.NewArray(itemThumbnails),
+		"itemThumbnailPaths":    cadence.NewArray(itemThumbnailPaths),
+		"itemQuantities":        cadence.NewArray(itemQuantities),
+		"itemPrices":            cadence.NewArray(itemPrices),
+		"itemArtistNames":       cadence.NewArray(itemArtistNames),
+		"itemSeriesNames":       cadence.NewArray(itemSeriesNames),
+		"itemArtNames":          cadence.NewArray(itemArtNames),
+		"allPaymentsRecipient":  batch.AllPaymentsRecipient,
+		"allRoyaltiesRecipient": batch.AllRoyaltiesRecipient,
 	}
+}
 
-	item_names_cadence := []cadence.Value{}
-	item_descriptions_cadence := []cadence.Value{}
-	item_thumbnails_cadence := []cadence.Value{}
-	item_thumbnail_paths_cadence := []cadence.Value{}
-	item_quantities_cadence := []cadence.Value{}
-	item_prices_cadence := []cadence.Value{}
-	item_artist_names_cadence := []cadence.Value{}
-	item_series_names_cadence := []cadence.Value{}
-	item_art_names_cadence := []cadence.Value{}
-	item_art_categories_cadence := []cadence.Value{}
+// PrepareInventory computes the IPFS CID of the thumbnail of every entry,
+// without the network. Thumbnails are read from the png folder of
+// artRepoPath, and every thumbnail is checked before any is read, as is the
+// category of every entry against the categories.csv of artRepoPath.
+func PrepareInventory(artRepoPath string, entries []manifest.InventoryEntry) ([]Item, error) {
+	taxonomy, err := manifest.LoadTaxonomy(filepath.Join(artRepoPath, manifest.CategoriesFileName))
+	if err != nil {
+		return nil, err
+	}
+	if err := taxonomy.CheckInventory(entries); err != nil {
+		return nil, err
+	}
+	if err := manifest.CheckInventoryFiles(artRepoPath, entries); err != nil {
+		return nil, err
+	}
 
-	for _, line := range csvData {
-		item_name := line[0]
-		item_description := line[1]
-		item_thumbnail_file_name := line[2]
-		item_quantity := line[3]
-		item_quantity_parsed, err := strconv.ParseUint(item_quantity, 10, 64)
-		if err != nil {
-			panic(err)
-		}
-		item_price := line[4]
-		item_price_parsed, err := cadence.ParseUFix64(item_price)
+	items := []Item{}
+	for _, entry := range entries {
+		thumbnail_file_path := filepath.Join(artRepoPath, "png", entry.ThumbnailFileName())
+		thumbnail_cid, err := ipfs_pin.ComputeCID(context.Background(), thumbnail_file_path)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
-		item_artist_name := line[5]
-		item_series_name := line[6]
-		item_art_name := line[7]
-		item_art_category := line[8]
-
-		item_names_cadence = append(item_names_cadence, cadence.String(item_name))
-		item_descriptions_cadence = append(item_descriptions_cadence, cadence.String(item_description))
-		item_quantities_cadence = append(item_quantities_cadence, cadence.UInt64(uint64(item_quantity_parsed)))
-		item_prices_cadence = append(item_prices_cadence, cadence.UFix64(item_price_parsed))
-		item_artist_names_cadence = append(item_artist_names_cadence, cadence.String(item_artist_name))
-		item_series_names_cadence = append(item_series_names_cadence, cadence.String(item_series_name))
-		item_art_names_cadence = append(item_art_names_cadence, cadence.String(item_art_name))
-		item_art_categories_cadence = append(item_art_categories_cadence, cadence.String(item_art_category))
-
-		item_thumbnail_file_path := fmt.Sprintf("%s/png/%s.png", artRepoPath, item_thumbnail_file_name)
-		item_thumbnail_file_name_and_type := fmt.Sprintf("%s.png", item_thumbnail_file_name)
-
-		f, _ := os.Open(item_thumbnail_file_path)
-		cid, _ := c.Put(context.Background(), f)
-
-		item_thumbnails_cadence = append(item_thumbnails_cadence, cadence.String(cid.String()))
-		item_thumbnail_paths_cadence = append(item_thumbnail_paths_cadence, cadence.String(item_thumbnail_file_name_and_type))
-
-		fmt.Printf("Pinned to IPFS: https://%v.ipfs.w3s.link/%v\n", cid, item_thumbnail_file_name_and_type)
-
+		items = append(items, Item{Entry: entry, ThumbnailPath: thumbnail_file_path, ThumbnailCID: thumbnail_cid})
 	}
 
-	item_names := cadence.NewArray(item_names_cadence)
-	item_descriptions := cadence.NewArray(item_descriptions_cadence)
-	item_thumbnails := cadence.NewArray(item_thumbnails_cadence)
-	item_thumbnail_paths := cadence.NewArray(item_thumbnail_paths_cadence)
-	item_quantities := cadence.NewArray(item_quantities_cadence)
-	item_prices := cadence.NewArray(item_prices_cadence)
-	item_artist_names := cadence.NewArray(item_artist_names_cadence)
-	item_series_names := cadence.NewArray(item_series_names_cadence)
-	item_art_names := cadence.NewArray(item_art_names_cadence)
-	item_art_categories := cadence.NewArray(item_art_categories_cadence)
-
-	return item_names, item_descriptions, item_thumbnails, item_thumbnail_paths, item_quantities, item_prices, item_artist_names, item_series_names, item_art_names, item_art_categories
+	return items, nil
+}
 
+// PinThumbnails pins the thumbnail of every prepared item with the backend
+// configured in the environment or the .env next to flow.json, see ipfs_pin,
+// and fails if a thumbnail changed since it was prepared. Thumbnails already
+// in the lockfile at lockPath, shared with art_prep, are not uploaded again.
+func PinThumbnails(items []Item, lockPath string) error {
+	if err := flow_config.LoadEnv(flow_config.DefaultPath); err != nil {
+		return err
+	}
+	files := []ipfs_pin.File{}
+	for _, item := range items {
+		files = append(files, ipfs_pin.File{Path: item.ThumbnailPath, CID: item.ThumbnailCID})
+	}
+	return ipfs_pin.PinFromEnv(context.Background(), lockPath, files)
 }
@@ -0,0 +1,39 @@
+package ipfs_pin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/ipfs/go-cid"
+)
+
+// CARWriter writes every file it is asked to pin to <Dir>/<cid>.car, for
+// work without a network. The CARs can be uploaded later to any backend, for
+// example with ipfs dag import.
+type CARWriter struct {
+	Dir string
+}
+
+// NewCARWriter writes CARs to dir
+func NewCARWriter(dir string) *CARWriter {
+	return &CARWriter{Dir: dir}
+}
+
+func (w *CARWriter) Backend() (string, string) {
+	return BackendCAR, StatusWritten
+}
+
+func (w *CARWriter) Pin(ctx context.Context, path string) (cid.Cid, error) {
+	packed, err := PackFile(ctx, path)
+	if err != nil {
+		return cid.Undef, err
+	}
+	if err := os.MkdirAll(w.Dir, 0755); err != nil {
+		return cid.Undef, err
+	}
+	if err := os.WriteFile(filepath.Join(w.Dir, packed.Root.String()+".car"), packed.Data, 0644); err != nil {
+		return cid.Undef, err
+	}
+	return packed.Root, nil
+}
@@ -0,0 +1,293 @@
+/*
+Pins artwork to IPFS through a backend chosen in the environment, or the .env
+next to flow.json, with IPFS_PINNER:
+
+	w3s              web3.storage, with WEB3_STORAGE_IPFS_API_KEY (the default)
+	kubo             a local IPFS node at KUBO_API_URL
+	pinning-service  a service implementing the IPFS Pinning Service API at
+	                 PINNING_SERVICE_URL, with PINNING_SERVICE_TOKEN
+	car              CAR files written to PIN_CAR_DIR, for offline work
+
+Uploads are attempted up to PIN_ATTEMPTS times with exponential backoff, each
+attempt limited to PIN_TIMEOUT, see Retry, and PIN_CONCURRENCY files are
+uploaded at once.
+
+Every backend packs files the same way web3.storage always has, so a
+thumbnail keeps its CID whichever backend pins it, and the CID can be computed
+offline with ComputeCID before anything is pinned. PinAll then pins the files
+and confirms the backend returned the CIDs computed for them, recording them
+in a lockfile so that unchanged files are not uploaded again, see Lock.
+*/
+
+package ipfs_pin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	bserv "github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-merkledag"
+	"github.com/ipld/go-car"
+	"github.com/web3-storage/go-w3s-client/adder"
+)
+
+// Pinner pins a file to IPFS
+type Pinner interface {
+	// Pin pins the file at path wrapped in a directory, so that it can be
+	// linked to as <cid>/<file name>, and returns the CID of the directory
+	Pin(ctx context.Context, path string) (cid.Cid, error)
+
+	// Backend names the backend in the lockfile, with the status Pin leaves
+	// files in
+	Backend() (name string, status string)
+}
+
+// Backends of IPFS_PINNER
+const (
+	BackendW3S            = "w3s"
+	BackendKubo           = "kubo"
+	BackendPinningService = "pinning-service"
+	BackendCAR            = "car"
+)
+
+// Defaults of the backend settings
+const (
+	DefaultKuboAPIURL  = "http://127.0.0.1:5001"
+	DefaultCARDir      = "ipfs_car"
+	DefaultGateway     = "https://ipfs.io"
+	DefaultConcurrency = 4
+)
+
+// Config chooses and sets up a backend
+type Config struct {
+	Backend             string
+	W3SToken            string
+	KuboAPIURL          string
+	PinningServiceURL   string
+	PinningServiceToken string
+	PinningServiceWait  time.Duration // how long to wait for a pin, 0 to return once it is queued
+	CARDir              string
+	Gateway             string        // gateway printed links go through
+	Attempts            int           // attempts per file, see Retry
+	Timeout             time.Duration // of every attempt, 0 for none
+	Concurrency         int           // files uploaded at once
+}
+
+// ConfigFromEnv reads the config from the environment, see the package doc
+func ConfigFromEnv() (Config, error) {
+	config := Config{
+		Backend:             os.Getenv("IPFS_PINNER"),
+		W3SToken:            os.Getenv("WEB3_STORAGE_IPFS_API_KEY"),
+		KuboAPIURL:          os.Getenv("KUBO_API_URL"),
+		PinningServiceURL:   os.Getenv("PINNING_SERVICE_URL"),
+		PinningServiceToken: os.Getenv("PINNING_SERVICE_TOKEN"),
+		CARDir:              os.Getenv("PIN_CAR_DIR"),
+		Gateway:             os.Getenv("IPFS_GATEWAY"),
+	}
+	for name, d := range map[string]*time.Duration{"PINNING_SERVICE_WAIT": &config.PinningServiceWait, "PIN_TIMEOUT": &config.Timeout} {
+		if value := os.Getenv(name); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				return Config{}, fmt.Errorf("%s: %w", name, err)
+			}
+			*d = parsed
+		} else if name == "PIN_TIMEOUT" {
+			*d = DefaultTimeout
+		}
+	}
+	config.Attempts = DefaultAttempts
+	if attempts := os.Getenv("PIN_ATTEMPTS"); attempts != "" {
+		n, err := strconv.Atoi(attempts)
+		if err != nil || n < 1 {
+			return Config{}, fmt.Errorf("PIN_ATTEMPTS: %q should be a whole number of at least 1", attempts)
+		}
+		config.Attempts = n
+	}
+	config.Concurrency = DefaultConcurrency
+	if concurrency := os.Getenv("PIN_CONCURRENCY"); concurrency != "" {
+		n, err := strconv.Atoi(concurrency)
+		if err != nil || n < 1 {
+			return Config{}, fmt.Errorf("PIN_CONCURRENCY: %q should be a whole number of at least 1", concurrency)
+		}
+		config.Concurrency = n
+	}
+	if config.Backend == "" {
+		config.Backend = BackendW3S
+	}
+	if config.CARDir == "" {
+		config.CARDir = DefaultCARDir
+	}
+	if config.Gateway == "" {
+		config.Gateway = DefaultGateway
+	}
+	return config, nil
+}
+
+// NewPinner sets up the backend of the config
+func NewPinner(config Config) (Pinner, error) {
+	switch config.Backend {
+	case BackendW3S:
+		pinner, err := NewW3S(config.W3SToken)
+		if err != nil {
+			return nil, err
+		}
+		return NewRetry(pinner, config.Attempts, config.Timeout), nil
+	case BackendKubo:
+		apiURL := config.KuboAPIURL
+		if apiURL == "" {
+			apiURL = DefaultKuboAPIURL
+		}
+		return NewRetry(NewKubo(apiURL), config.Attempts, config.Timeout), nil
+	case BackendPinningService:
+		service, err := NewPinningService(config.PinningServiceURL, config.PinningServiceToken)
+		if err != nil {
+			return nil, err
+		}
+		// a service can only pin what it can find, so a Kubo node set up
+		// alongside provides the content
+		if config.KuboAPIURL != "" {
+			service.Provider = NewKubo(config.KuboAPIURL)
+		}
+		service.Wait = config.PinningServiceWait
+		// the timeout of an attempt does not count waiting for the pin
+		timeout := config.Timeout
+		if timeout > 0 {
+			timeout += service.Wait
+		}
+		return NewRetry(service, config.Attempts, timeout), nil
+	case BackendCAR:
+		return NewCARWriter(config.CARDir), nil
+	}
+	return nil, fmt.Errorf("unknown IPFS_PINNER %q, expected %s, %s, %s or %s",
+		config.Backend, BackendW3S, BackendKubo, BackendPinningService, BackendCAR)
+}
+
+// GatewayURL links to a pinned file through the gateway of the config
+func (config Config) GatewayURL(root cid.Cid, fileName string) string {
+	return fmt.Sprintf("%s/ipfs/%s/%s", strings.TrimSuffix(config.Gateway, "/"), root, fileName)
+}
+
+// Car is a file packed into a CAR, wrapped in a directory
+type Car struct {
+	Root cid.Cid
+	Data []byte
+}
+
+// buildDAG chunks the file at path into a UnixFS DAG wrapped in a directory,
+// the way web3.storage does: CIDv1, raw leaves and 1MiB chunks
+func buildDAG(ctx context.Context, path string) (cid.Cid, ipld.DAGService, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return cid.Undef, nil, err
+	}
+	defer f.Close()
+
+	store := dssync.MutexWrap(ds.NewMapDatastore())
+	dag := merkledag.NewDAGService(bserv.New(blockstore.NewBlockstore(store), nil))
+	dagAdder, err := adder.NewAdder(ctx, dag)
+	if err != nil {
+		return cid.Undef, nil, err
+	}
+	root, err := dagAdder.Add(f, "", nil)
+	if err != nil {
+		return cid.Undef, nil, fmt.Errorf("packing %s: %w", path, err)
+	}
+	return root, dag, nil
+}
+
+// ComputeCID computes the CID the file at path is pinned under by every
+// backend, without the network
+func ComputeCID(ctx context.Context, path string) (cid.Cid, error) {
+	root, _, err := buildDAG(ctx, path)
+	return root, err
+}
+
+// PackFile packs the file at path into a CAR, with the chunking and CIDs
+// web3.storage uses
+func PackFile(ctx context.Context, path string) (Car, error) {
+	root, dag, err := buildDAG(ctx, path)
+	if err != nil {
+		return Car{}, err
+	}
+	buf := bytes.Buffer{}
+	if err := car.WriteCar(ctx, dag, []cid.Cid{root}, &buf); err != nil {
+		return Car{}, fmt.Errorf("packing %s: %w", path, err)
+	}
+	return Car{Root: root, Data: buf.Bytes()}, nil
+}
+
+// checkRoot makes sure a backend pinned what was packed
+func checkRoot(backend string, packed cid.Cid, pinned cid.Cid) error {
+	if !packed.Equals(pinned) {
+		return fmt.Errorf("%w: %s pinned %s, expected %s", ErrCIDMismatch, backend, pinned, packed)
+	}
+	return nil
+}
+
+// File is a file to pin, with the CID computed for it offline
+type File struct {
+	Path string
+	CID  cid.Cid
+}
+
+// Name is the name of the file within its pinned directory
+func (file File) Name() string {
+	return filepath.Base(file.Path)
+}
+
+// PinFromEnv pins every file with the backend configured in the environment,
+// skipping the files already in the lockfile at lockPath, and prints a
+// gateway link to each file as it is pinned. It fails if any file could not be
+// pinned, after pinning the others.
+func PinFromEnv(ctx context.Context, lockPath string, files []File) error {
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	pinner, err := NewPinner(config)
+	if err != nil {
+		return err
+	}
+	options := PinOptions{
+		LockPath:    lockPath,
+		Concurrency: config.Concurrency,
+		Progress: func(done int, total int, file File, err error) {
+			if err != nil {
+				fmt.Printf("[%d/%d] Could not pin %s: %v\n", done, total, file.Path, err)
+				return
+			}
+			fmt.Printf("[%d/%d] Pinned to IPFS: %s\n", done, total, config.GatewayURL(file.CID, file.Name()))
+		},
+	}
+	pinned, err := PinAll(ctx, pinner, files, options)
+	var failures PinErrors
+	if err != nil && !errors.As(err, &failures) {
+		return err
+	}
+	printed := map[string]bool{}
+	for _, failure := range failures {
+		printed[failure.File.Path] = true
+	}
+	for _, file := range pinned {
+		printed[file.Path] = true
+	}
+	for _, file := range files {
+		if !printed[file.Path] {
+			fmt.Printf("Already pinned: %s\n", config.GatewayURL(file.CID, file.Name()))
+			printed[file.Path] = true
+		}
+	}
+	return err
+}
@@ -0,0 +1,629 @@
+package ipfs_pin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-car"
+	"github.com/web3-storage/go-w3s-client"
+)
+
+// writeThumbnail writes a file large enough to be chunked
+func writeThumbnail(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "thumbnail.png")
+	data := bytes.Repeat([]byte("floasis items thumbnail "), 100_000)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func packedRoot(t *testing.T, path string) cid.Cid {
+	t.Helper()
+	packed, err := PackFile(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return packed.Root
+}
+
+// carRoot reads a CAR through and returns its root
+func carRoot(r io.Reader) (cid.Cid, error) {
+	reader, err := car.NewCarReader(r)
+	if err != nil {
+		return cid.Undef, err
+	}
+	for {
+		if _, err := reader.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			return cid.Undef, err
+		}
+	}
+	return reader.Header.Roots[0], nil
+}
+
+// fakeKubo serves dag/import like a Kubo node
+func fakeKubo(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v0/dag/import" || r.URL.Query().Get("pin-roots") != "true" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		root, err := carRoot(file)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, `{"Message":%q,"Code":0,"Type":"error"}`, err.Error())
+			return
+		}
+		fmt.Fprintf(w, `{"Root":{"Cid":{"/":%q},"PinErrorMsg":""}}`+"\n", root)
+	}))
+}
+
+func TestPackFileWrapsInDirectory(t *testing.T) {
+	path := writeThumbnail(t)
+	packed, err := PackFile(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := carRoot(bytes.NewReader(packed.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !root.Equals(packed.Root) {
+		t.Fatalf("CAR root %s, expected %s", root, packed.Root)
+	}
+	if packed.Root.Version() != 1 {
+		t.Fatalf("CID version %d, expected 1", packed.Root.Version())
+	}
+	// the root is the directory, not the file
+	fileRoot := packedRoot(t, path)
+	if !fileRoot.Equals(packed.Root) {
+		t.Fatalf("packing is not deterministic: %s and %s", fileRoot, packed.Root)
+	}
+}
+
+func TestCARWriter(t *testing.T) {
+	path := writeThumbnail(t)
+	dir := filepath.Join(t.TempDir(), "car")
+
+	pinned, err := NewCARWriter(dir).Pin(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := packedRoot(t, path); !pinned.Equals(expected) {
+		t.Fatalf("pinned %s, expected %s", pinned, expected)
+	}
+
+	f, err := os.Open(filepath.Join(dir, pinned.String()+".car"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	root, err := carRoot(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !root.Equals(pinned) {
+		t.Fatalf("CAR root %s, expected %s", root, pinned)
+	}
+}
+
+func TestKubo(t *testing.T) {
+	server := fakeKubo(t)
+	defer server.Close()
+	path := writeThumbnail(t)
+
+	pinned, err := NewKubo(server.URL).Pin(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := packedRoot(t, path); !pinned.Equals(expected) {
+		t.Fatalf("pinned %s, expected %s", pinned, expected)
+	}
+}
+
+func TestKuboReportsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"Root":{"Cid":{"/":"bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi"},"PinErrorMsg":"out of disk"}}`)
+	}))
+	defer server.Close()
+
+	_, err := NewKubo(server.URL).Pin(context.Background(), writeThumbnail(t))
+	if err == nil || !strings.Contains(err.Error(), "out of disk") {
+		t.Fatalf("expected the pin error, got %v", err)
+	}
+}
+
+// fakePinningService serves the IPFS Pinning Service API, reporting a pin as
+// pinning the first time it is asked about and then as finalStatus
+type fakePinningService struct {
+	finalStatus string
+	mu          sync.Mutex
+	pins        map[string]string // cid by request id
+	polls       map[string]int
+}
+
+func (s *fakePinningService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":{"reason":"UNAUTHORIZED","details":"bad token"}}`)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/pins":
+		var req pinRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requestID := fmt.Sprintf("r%d", len(s.pins))
+		s.pins[requestID] = req.Cid
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, `{"requestid":%q,"status":"queued","pin":{"cid":%q}}`, requestID, req.Cid)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/pins/"):
+		requestID := strings.TrimPrefix(r.URL.Path, "/pins/")
+		status := "pinning"
+		if s.polls[requestID] > 0 {
+			status = s.finalStatus
+		}
+		s.polls[requestID]++
+		fmt.Fprintf(w, `{"requestid":%q,"status":%q,"pin":{"cid":%q},"info":{"status_details":"not found on the network"}}`, requestID, status, s.pins[requestID])
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newFakePinningService(finalStatus string) *fakePinningService {
+	return &fakePinningService{finalStatus: finalStatus, pins: map[string]string{}, polls: map[string]int{}}
+}
+
+func TestPinningServiceWaitsForPin(t *testing.T) {
+	fake := newFakePinningService(statusPinned)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	kubo := fakeKubo(t)
+	defer kubo.Close()
+	path := writeThumbnail(t)
+
+	service, err := NewPinningService(server.URL, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.Provider = NewKubo(kubo.URL)
+	service.Wait = 5 * time.Second
+	service.PollInterval = time.Millisecond
+
+	pinned, err := service.Pin(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := packedRoot(t, path); !pinned.Equals(expected) {
+		t.Fatalf("pinned %s, expected %s", pinned, expected)
+	}
+	if fake.pins["r0"] != pinned.String() || fake.polls["r0"] != 2 {
+		t.Fatalf("expected a pin request polled twice, got %v %v", fake.pins, fake.polls)
+	}
+}
+
+func TestPinningServiceReturnsOnceQueued(t *testing.T) {
+	fake := newFakePinningService(statusPinned)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	service, err := NewPinningService(server.URL, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Pin(context.Background(), writeThumbnail(t)); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.polls) != 0 {
+		t.Fatalf("expected no polling, got %v", fake.polls)
+	}
+}
+
+func TestPinningServiceReportsFailures(t *testing.T) {
+	server := httptest.NewServer(newFakePinningService(statusFailed))
+	defer server.Close()
+	path := writeThumbnail(t)
+
+	service, err := NewPinningService(server.URL, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.Wait = 5 * time.Second
+	service.PollInterval = time.Millisecond
+	if _, err := service.Pin(context.Background(), path); err == nil || !strings.Contains(err.Error(), "not found on the network") {
+		t.Fatalf("expected the pin to fail, got %v", err)
+	}
+
+	service.Token = "wrong"
+	if _, err := service.Pin(context.Background(), path); err == nil || !strings.Contains(err.Error(), "UNAUTHORIZED") {
+		t.Fatalf("expected an authorization error, got %v", err)
+	}
+}
+
+func TestW3S(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/car" || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		root, err := carRoot(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"cid":%q}`, root)
+	}))
+	defer server.Close()
+	path := writeThumbnail(t)
+
+	pinner, err := NewW3S("secret", w3s.WithEndpoint(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned, err := pinner.Pin(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := packedRoot(t, path); !pinned.Equals(expected) {
+		t.Fatalf("pinned %s, expected %s", pinned, expected)
+	}
+}
+
+func TestNewPinner(t *testing.T) {
+	for _, test := range []struct {
+		config   Config
+		expected interface{}
+	}{
+		{Config{Backend: BackendW3S, W3SToken: "secret"}, &W3S{}},
+		{Config{Backend: BackendKubo}, &Kubo{}},
+		{Config{Backend: BackendPinningService, PinningServiceURL: "http://localhost", PinningServiceToken: "secret"}, &PinningService{}},
+		{Config{Backend: BackendCAR, CARDir: t.TempDir()}, &CARWriter{}},
+	} {
+		pinner, err := NewPinner(test.config)
+		if err != nil {
+			t.Fatalf("%s: %v", test.config.Backend, err)
+		}
+		// network backends are retried, the CAR writer is not
+		if retry, ok := pinner.(*Retry); ok && test.config.Backend != BackendCAR {
+			pinner = retry.Pinner
+		}
+		if fmt.Sprintf("%T", pinner) != fmt.Sprintf("%T", test.expected) {
+			t.Fatalf("%s: got a %T", test.config.Backend, pinner)
+		}
+	}
+
+	if _, err := NewPinner(Config{Backend: "ftp"}); err == nil {
+		t.Fatal("expected an unknown backend to be rejected")
+	}
+	if _, err := NewPinner(Config{Backend: BackendW3S}); err == nil {
+		t.Fatal("expected w3s without a token to be rejected")
+	}
+}
+
+func TestComputeCIDMatchesPinnedCID(t *testing.T) {
+	path := writeThumbnail(t)
+	computed, err := ComputeCID(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned, err := NewCARWriter(t.TempDir()).Pin(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !computed.Equals(pinned) {
+		t.Fatalf("computed %s, pinned %s", computed, pinned)
+	}
+}
+
+func TestPinAllDetectsChangedFiles(t *testing.T) {
+	path := writeThumbnail(t)
+	computed, err := ComputeCID(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinner := NewCARWriter(t.TempDir())
+	files := []File{{Path: path, CID: computed}, {Path: path, CID: computed}}
+	pinned, err := PinAll(context.Background(), pinner, files, PinOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pinned) != 1 {
+		t.Fatalf("expected the file to be pinned once, got %v", pinned)
+	}
+
+	if err := os.WriteFile(path, []byte("redrawn"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PinAll(context.Background(), pinner, files, PinOptions{}); err == nil || !strings.Contains(err.Error(), "was the file changed") {
+		t.Fatalf("expected a CID mismatch, got %v", err)
+	}
+}
+
+// countingPinner counts the files it is asked to pin
+type countingPinner struct {
+	Pinner
+	pins int
+}
+
+func (p *countingPinner) Pin(ctx context.Context, path string) (cid.Cid, error) {
+	p.pins++
+	return p.Pinner.Pin(ctx, path)
+}
+
+func TestPinAllSkipsLockedFiles(t *testing.T) {
+	path := writeThumbnail(t)
+	computed, err := ComputeCID(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []File{{Path: path, CID: computed}}
+	lockPath := filepath.Join(t.TempDir(), LockFileName)
+	kubo := fakeKubo(t)
+	defer kubo.Close()
+
+	pinner := &countingPinner{Pinner: NewKubo(kubo.URL)}
+	for i := 0; i < 2; i++ {
+		if _, err := PinAll(context.Background(), pinner, files, PinOptions{LockPath: lockPath}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pinner.pins != 1 {
+		t.Fatalf("expected the locked file to be skipped, pinned %d times", pinner.pins)
+	}
+
+	lock, err := LoadLock(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lock.Files) != 1 {
+		t.Fatalf("expected a locked file, got %v", lock.Files)
+	}
+	for key, entry := range lock.Files {
+		if !strings.HasSuffix(key, "/thumbnail.png") || entry.CID != computed.String() ||
+			entry.Path != computed.String()+"/thumbnail.png" || entry.Backend != BackendKubo || entry.Status != StatusPinned {
+			t.Fatalf("unexpected lock entry %s: %+v", key, entry)
+		}
+	}
+
+	// the lock is per backend, another backend pins the file again
+	if _, err := PinAll(context.Background(), NewCARWriter(t.TempDir()), files, PinOptions{LockPath: lockPath}); err != nil {
+		t.Fatal(err)
+	}
+	lock, err = LoadLock(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range lock.Files {
+		if entry.Backend != BackendCAR || entry.Status != StatusWritten {
+			t.Fatalf("expected the CAR writer in the lock, got %+v", entry)
+		}
+	}
+}
+
+// flakyKubo fails the first failures dag/import calls with status, then
+// serves them like fakeKubo
+func flakyKubo(t *testing.T, failures int, status int) (*httptest.Server, *int) {
+	kubo := fakeKubo(t)
+	t.Cleanup(kubo.Close)
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= failures {
+			w.WriteHeader(status)
+			fmt.Fprint(w, `{"Message":"try again later","Code":0,"Type":"error"}`)
+			return
+		}
+		kubo.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func newTestRetry(pinner Pinner, attempts int, timeout time.Duration) *Retry {
+	retry := NewRetry(pinner, attempts, timeout)
+	retry.InitialInterval = time.Millisecond
+	return retry
+}
+
+func TestRetryRecoversFromServerErrors(t *testing.T) {
+	server, calls := flakyKubo(t, 2, http.StatusInternalServerError)
+	path := writeThumbnail(t)
+
+	pinned, err := newTestRetry(NewKubo(server.URL), 3, time.Minute).Pin(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := packedRoot(t, path); !pinned.Equals(expected) {
+		t.Fatalf("pinned %s, expected %s", pinned, expected)
+	}
+	if *calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", *calls)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	server, calls := flakyKubo(t, 10, http.StatusBadGateway)
+
+	_, err := newTestRetry(NewKubo(server.URL), 3, time.Minute).Pin(context.Background(), writeThumbnail(t))
+	var attemptsErr *AttemptsError
+	var statusErr *StatusError
+	if !errors.As(err, &attemptsErr) || attemptsErr.Attempts != 3 || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected to give up after 3 attempts with the last status, got %v", err)
+	}
+	if *calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", *calls)
+	}
+}
+
+func TestRetrySkipsPermanentErrors(t *testing.T) {
+	server, calls := flakyKubo(t, 10, http.StatusUnauthorized)
+
+	_, err := newTestRetry(NewKubo(server.URL), 5, time.Minute).Pin(context.Background(), writeThumbnail(t))
+	if err == nil || !strings.Contains(err.Error(), "after 1 attempt") {
+		t.Fatalf("expected a refused upload not to be retried, got %v", err)
+	}
+	if *calls != 1 {
+		t.Fatalf("expected 1 attempt, got %d", *calls)
+	}
+
+	_, err = newTestRetry(NewKubo(server.URL), 5, time.Minute).Pin(context.Background(), filepath.Join(t.TempDir(), "missing.png"))
+	if !errors.Is(err, os.ErrNotExist) || *calls != 1 {
+		t.Fatalf("expected a missing file not to be uploaded, got %v after %d calls", err, *calls)
+	}
+}
+
+func TestRetryTimesOutAttempts(t *testing.T) {
+	kubo := fakeKubo(t)
+	defer kubo.Close()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			// the cancelled request is only noticed once its body is read
+			io.Copy(io.Discard, r.Body)
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		kubo.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "thumbnail.png")
+	if err := os.WriteFile(path, []byte("floasis items thumbnail"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTestRetry(NewKubo(server.URL), 2, time.Second).Pin(context.Background(), path); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected the stalled attempt to be retried, got %d calls", calls)
+	}
+}
+
+func TestPinAllReportsEveryFailure(t *testing.T) {
+	path := writeThumbnail(t)
+	computed, err := ComputeCID(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing := []File{
+		{Path: filepath.Join(t.TempDir(), "a.png"), CID: computed},
+		{Path: filepath.Join(t.TempDir(), "b.png"), CID: computed},
+	}
+	files := append([]File{missing[0], {Path: path, CID: computed}}, missing[1])
+
+	pinned, err := PinAll(context.Background(), NewCARWriter(t.TempDir()), files, PinOptions{Concurrency: 2})
+	var failures PinErrors
+	if !errors.As(err, &failures) || len(failures) != 2 ||
+		failures[0].File.Path != missing[0].Path || failures[1].File.Path != missing[1].Path {
+		t.Fatalf("expected both missing files reported, got %v", err)
+	}
+	if len(pinned) != 1 || pinned[0].Path != path {
+		t.Fatalf("expected the other file pinned, got %v", pinned)
+	}
+}
+
+// slowPinner delays every pin, tracking how many run at once
+type slowPinner struct {
+	Pinner
+	mu      sync.Mutex
+	running int
+	most    int
+}
+
+func (p *slowPinner) Pin(ctx context.Context, path string) (cid.Cid, error) {
+	p.mu.Lock()
+	p.running++
+	if p.running > p.most {
+		p.most = p.running
+	}
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.running--
+		p.mu.Unlock()
+	}()
+	time.Sleep(20 * time.Millisecond)
+	return p.Pinner.Pin(ctx, path)
+}
+
+func TestPinAllBoundsConcurrencyAndKeepsOrder(t *testing.T) {
+	dir := t.TempDir()
+	files := []File{}
+	for i := 0; i < 12; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("thumbnail-%02d.png", i))
+		if err := os.WriteFile(path, []byte(fmt.Sprintf("thumbnail %d", i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		computed, err := ComputeCID(context.Background(), path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, File{Path: path, CID: computed})
+	}
+	lockPath := filepath.Join(t.TempDir(), LockFileName)
+
+	pinner := &slowPinner{Pinner: NewCARWriter(t.TempDir())}
+	progress := []int{}
+	options := PinOptions{
+		LockPath:    lockPath,
+		Concurrency: 3,
+		Progress: func(done int, total int, file File, err error) {
+			if err != nil || total != len(files) {
+				t.Errorf("unexpected progress of %s: %d/%d, %v", file.Path, done, total, err)
+			}
+			progress = append(progress, done)
+		},
+	}
+	pinned, err := PinAll(context.Background(), pinner, files, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pinner.most < 2 || pinner.most > 3 {
+		t.Fatalf("expected up to 3 uploads at once, got %d", pinner.most)
+	}
+	if len(pinned) != len(files) {
+		t.Fatalf("expected every file pinned, got %d", len(pinned))
+	}
+	for i := range files {
+		if pinned[i].Path != files[i].Path || progress[i] != i+1 {
+			t.Fatalf("expected the files in order with progress counted up, got %v and %v", pinned, progress)
+		}
+	}
+	lock, err := LoadLock(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lock.Files) != len(files) {
+		t.Fatalf("expected every pin saved to the lock, got %d", len(lock.Files))
+	}
+}
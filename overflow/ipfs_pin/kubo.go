@@ -0,0 +1,107 @@
+package ipfs_pin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+)
+
+// Kubo pins to an IPFS node through its HTTP RPC API, importing the packed
+// file with dag/import so that the node does not chunk it again its own way
+type Kubo struct {
+	APIURL string
+	Client *http.Client
+}
+
+// NewKubo pins to the node serving its RPC API at apiURL, usually
+// http://127.0.0.1:5001
+func NewKubo(apiURL string) *Kubo {
+	return &Kubo{APIURL: strings.TrimSuffix(apiURL, "/"), Client: http.DefaultClient}
+}
+
+// kuboError is the body of a failed RPC call
+type kuboError struct {
+	Message string
+}
+
+// kuboImport is a line of the dag/import response
+type kuboImport struct {
+	Root *struct {
+		Cid         map[string]string
+		PinErrorMsg string
+	}
+}
+
+func (k *Kubo) Backend() (string, string) {
+	return BackendKubo, StatusPinned
+}
+
+func (k *Kubo) Pin(ctx context.Context, path string) (cid.Cid, error) {
+	packed, err := PackFile(ctx, path)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	body := bytes.Buffer{}
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", packed.Root.String()+".car")
+	if err != nil {
+		return cid.Undef, err
+	}
+	if _, err := part.Write(packed.Data); err != nil {
+		return cid.Undef, err
+	}
+	if err := form.Close(); err != nil {
+		return cid.Undef, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.APIURL+"/api/v0/dag/import?pin-roots=true", &body)
+	if err != nil {
+		return cid.Undef, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	res, err := k.Client.Do(req)
+	if err != nil {
+		return cid.Undef, fmt.Errorf("kubo: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(res.Body)
+		var kuboErr kuboError
+		json.Unmarshal(message, &kuboErr)
+		return cid.Undef, &StatusError{Backend: "kubo", Op: "dag/import", StatusCode: res.StatusCode, Message: kuboErr.Message}
+	}
+
+	// the response streams a line per imported root
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		var line kuboImport
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return cid.Undef, fmt.Errorf("kubo: dag/import: %w", err)
+		}
+		if line.Root == nil {
+			continue
+		}
+		if line.Root.PinErrorMsg != "" {
+			return cid.Undef, fmt.Errorf("kubo: pinning %s: %s", path, line.Root.PinErrorMsg)
+		}
+		pinned, err := cid.Parse(line.Root.Cid["/"])
+		if err != nil {
+			return cid.Undef, fmt.Errorf("kubo: dag/import: %w", err)
+		}
+		return pinned, checkRoot("kubo", packed.Root, pinned)
+	}
+	if err := scanner.Err(); err != nil {
+		return cid.Undef, fmt.Errorf("kubo: dag/import: %w", err)
+	}
+	return cid.Undef, fmt.Errorf("kubo: dag/import did not report pinning %s", packed.Root)
+}
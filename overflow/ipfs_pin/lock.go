@@ -0,0 +1,106 @@
+package ipfs_pin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// LockFileName is the lockfile kept next to the manifests of an art repo.
+// It is committed, so that everyone pinning from the repo reuses the pins.
+const LockFileName = "ipfs_pins.lock.json"
+
+// Pin statuses recorded in the lockfile
+const (
+	StatusPinned  = "pinned"
+	StatusQueued  = "queued"  // a pinning service accepted the pin, without waiting for it
+	StatusWritten = "written" // written to a CAR, to be uploaded
+)
+
+// LockEntry is a file that was pinned
+type LockEntry struct {
+	CID     string `json:"cid"`
+	Path    string `json:"path"` // gateway path, <cid>/<file name>
+	Backend string `json:"backend"`
+	Status  string `json:"status"`
+}
+
+// Lock maps files, by the sha256 of their content and their name, to the
+// CID they were pinned under. The name is part of the key since it is part of
+// the wrapping directory, and so of the CID.
+type Lock struct {
+	Files map[string]LockEntry `json:"files"`
+}
+
+// LoadLock reads the lockfile at path, or returns an empty lock if there is
+// none yet
+func LoadLock(path string) (*Lock, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Lock{Files: map[string]LockEntry{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var lock Lock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("ipfs_pin: %s: %w", path, err)
+	}
+	if lock.Files == nil {
+		lock.Files = map[string]LockEntry{}
+	}
+	return &lock, nil
+}
+
+// Save writes the lock to path, through a temporary file so that an
+// interrupted run never leaves half a lockfile behind. Keys are sorted, so
+// the lockfile diffs cleanly.
+func (lock *Lock) Save(path string) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// lockKey identifies a file by its content and name
+func lockKey(file File) (string, error) {
+	f, err := os.Open(file.Path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)) + "/" + file.Name(), nil
+}
+
+// pinned returns whether the file was pinned by the backend under its CID
+func (lock *Lock) pinned(key string, file File, backend string) bool {
+	entry, ok := lock.Files[key]
+	return ok && entry.Backend == backend && entry.CID == file.CID.String()
+}
+
+// record adds a pinned file to the lock
+func (lock *Lock) record(key string, file File, backend string, status string) {
+	lock.Files[key] = LockEntry{
+		CID:     file.CID.String(),
+		Path:    file.CID.String() + "/" + file.Name(),
+		Backend: backend,
+		Status:  status,
+	}
+}
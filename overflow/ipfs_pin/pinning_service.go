@@ -0,0 +1,178 @@
+package ipfs_pin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-cid"
+)
+
+// Pin statuses of the IPFS Pinning Service API
+const (
+	statusQueued  = "queued"
+	statusPinning = "pinning"
+	statusPinned  = "pinned"
+	statusFailed  = "failed"
+)
+
+// PinningService pins through a service implementing the IPFS Pinning
+// Service API, https://ipfs.github.io/pinning-services-api-spec/. Services
+// pin by CID and fetch the content from the network, so it has to be
+// provided by some node, see Provider.
+type PinningService struct {
+	URL    string
+	Token  string
+	Client *http.Client
+
+	// Provider, if set, pins the file first to make it available to the
+	// service, usually a Kubo node
+	Provider Pinner
+
+	// Wait is how long to wait for the service to pin the file, or 0 to
+	// return as soon as the pin is queued
+	Wait         time.Duration
+	PollInterval time.Duration
+}
+
+// NewPinningService pins through the service at url, authorized by token
+func NewPinningService(url string, token string) (*PinningService, error) {
+	if url == "" {
+		return nil, fmt.Errorf("pinning service: missing PINNING_SERVICE_URL")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("pinning service: missing PINNING_SERVICE_TOKEN")
+	}
+	return &PinningService{
+		URL:          strings.TrimSuffix(url, "/"),
+		Token:        token,
+		Client:       http.DefaultClient,
+		PollInterval: 2 * time.Second,
+	}, nil
+}
+
+// pinRequest is the body of POST /pins
+type pinRequest struct {
+	Cid  string `json:"cid"`
+	Name string `json:"name,omitempty"`
+}
+
+// pinStatus is the body of the POST /pins and GET /pins/{requestid} responses
+type pinStatus struct {
+	RequestID string `json:"requestid"`
+	Status    string `json:"status"`
+	Info      struct {
+		StatusDetails string `json:"status_details"`
+	} `json:"info"`
+}
+
+// serviceError is the body of a failed request
+type serviceError struct {
+	Error struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	} `json:"error"`
+}
+
+func (p *PinningService) do(ctx context.Context, method string, path string, body interface{}) (pinStatus, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return pinStatus{}, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.URL+path, reader)
+	if err != nil {
+		return pinStatus{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.Token)
+	req.Header.Set("Content-Type", "application/json")
+	res, err := p.Client.Do(req)
+	if err != nil {
+		return pinStatus{}, fmt.Errorf("pinning service: %w", err)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return pinStatus{}, fmt.Errorf("pinning service: %w", err)
+	}
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusAccepted {
+		var serviceErr serviceError
+		json.Unmarshal(data, &serviceErr)
+		message := strings.TrimSpace(serviceErr.Error.Reason + " " + serviceErr.Error.Details)
+		return pinStatus{}, &StatusError{Backend: "pinning service", Op: method + " " + path, StatusCode: res.StatusCode, Message: message}
+	}
+	var status pinStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return pinStatus{}, fmt.Errorf("pinning service: %s %s: %w", method, path, err)
+	}
+	return status, nil
+}
+
+func (p *PinningService) Backend() (string, string) {
+	if p.Wait == 0 {
+		return BackendPinningService, StatusQueued
+	}
+	return BackendPinningService, StatusPinned
+}
+
+func (p *PinningService) Pin(ctx context.Context, path string) (cid.Cid, error) {
+	var root cid.Cid
+	if p.Provider != nil {
+		provided, err := p.Provider.Pin(ctx, path)
+		if err != nil {
+			return cid.Undef, err
+		}
+		root = provided
+	} else {
+		packed, err := PackFile(ctx, path)
+		if err != nil {
+			return cid.Undef, err
+		}
+		root = packed.Root
+	}
+
+	status, err := p.do(ctx, http.MethodPost, "/pins", pinRequest{Cid: root.String(), Name: filepath.Base(path)})
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	if p.Wait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Wait)
+		defer cancel()
+	}
+	for {
+		switch status.Status {
+		case statusPinned:
+			return root, nil
+		case statusFailed:
+			return cid.Undef, fmt.Errorf("pinning service: pinning %s failed: %s", root, status.Info.StatusDetails)
+		case statusQueued, statusPinning:
+			if p.Wait == 0 {
+				return root, nil
+			}
+		default:
+			return cid.Undef, fmt.Errorf("pinning service: unknown status %q of %s", status.Status, root)
+		}
+
+		select {
+		case <-ctx.Done():
+			return cid.Undef, fmt.Errorf("pinning service: %s still %s: %w", root, status.Status, ctx.Err())
+		case <-time.After(p.PollInterval):
+		}
+		status, err = p.do(ctx, http.MethodGet, "/pins/"+status.RequestID, nil)
+		if err != nil {
+			return cid.Undef, err
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package ipfs_pin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// PinOptions tune PinAll
+type PinOptions struct {
+	// LockPath is the lockfile of the files pinned so far, or empty for none.
+	// Files it records as pinned by the same backend are skipped, and every
+	// pin is saved to it as soon as it is made.
+	LockPath string
+
+	// Concurrency is how many files are uploaded at once, 1 if not set
+	Concurrency int
+
+	// Progress, if set, is called once every upload is done, with the number
+	// of uploads done so far out of total and err nil if the file was pinned.
+	// Calls never overlap.
+	Progress func(done int, total int, file File, err error)
+}
+
+// PinFailure is a file PinAll could not pin
+type PinFailure struct {
+	File File
+	Err  error
+}
+
+// PinErrors reports every file PinAll could not pin, in the order of the
+// files
+type PinErrors []PinFailure
+
+func (errs PinErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, failure := range errs {
+		messages[i] = fmt.Sprintf("%s: %v", failure.File.Path, failure.Err)
+	}
+	return fmt.Sprintf("%d file(s) could not be pinned:\n  %s", len(errs), strings.Join(messages, "\n  "))
+}
+
+// upload is a file PinAll pins, by its index in the files
+type upload struct {
+	index int
+	key   string
+}
+
+// PinAll pins every file once, uploading up to options.Concurrency files at
+// once, and fails if a backend returns another CID than the one computed
+// offline, which happens when the file changed since. Files that cannot be
+// pinned do not stop the others, and are reported together as PinErrors.
+// PinAll returns the files it pinned, in the order they were given.
+func PinAll(ctx context.Context, pinner Pinner, files []File, options PinOptions) ([]File, error) {
+	lock := &Lock{Files: map[string]LockEntry{}}
+	if options.LockPath != "" {
+		loaded, err := LoadLock(options.LockPath)
+		if err != nil {
+			return nil, err
+		}
+		lock = loaded
+	}
+	backend, status := pinner.Backend()
+
+	errs := make([]error, len(files))
+	uploads := []upload{}
+	queued := map[string]bool{}
+	for i, file := range files {
+		key, err := lockKey(file)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		if queued[key] || lock.pinned(key, file, backend) {
+			continue
+		}
+		queued[key] = true
+		uploads = append(uploads, upload{index: i, key: key})
+	}
+
+	concurrency := options.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > len(uploads) {
+		concurrency = len(uploads)
+	}
+
+	uploaded := make([]bool, len(files))
+	var saveErr error
+	done := 0
+	// mu guards the lock, the results and the progress
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan upload)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range queue {
+				file := files[u.index]
+				err := pinFile(ctx, pinner, file)
+
+				mu.Lock()
+				errs[u.index] = err
+				if err == nil {
+					uploaded[u.index] = true
+					if options.LockPath != "" && saveErr == nil {
+						lock.record(u.key, file, backend, status)
+						saveErr = lock.Save(options.LockPath)
+					}
+				}
+				done++
+				if options.Progress != nil {
+					options.Progress(done, len(uploads), file, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, u := range uploads {
+		queue <- u
+	}
+	close(queue)
+	wg.Wait()
+
+	pinned := []File{}
+	failures := PinErrors{}
+	for i, file := range files {
+		if uploaded[i] {
+			pinned = append(pinned, file)
+		} else if errs[i] != nil {
+			failures = append(failures, PinFailure{File: file, Err: errs[i]})
+		}
+	}
+	if saveErr != nil {
+		return pinned, saveErr
+	}
+	if len(failures) > 0 {
+		return pinned, failures
+	}
+	return pinned, nil
+}
+
+// pinFile pins a file and checks it was pinned under its CID
+func pinFile(ctx context.Context, pinner Pinner, file File) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	root, err := pinner.Pin(ctx, file.Path)
+	if err != nil {
+		return err
+	}
+	if !root.Equals(file.CID) {
+		return fmt.Errorf("%w: pinned as %s, expected %s as prepared, was the file changed?", ErrCIDMismatch, root, file.CID)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package ipfs_pin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/ipfs/go-cid"
+)
+
+// Defaults of PIN_ATTEMPTS and PIN_TIMEOUT
+const (
+	DefaultAttempts = 5
+	DefaultTimeout  = 2 * time.Minute
+)
+
+// ErrCIDMismatch is returned when a file is pinned under another CID than
+// the one computed for it, which retrying does not fix
+var ErrCIDMismatch = errors.New("CID mismatch")
+
+// StatusError is an unexpected HTTP response from a backend
+type StatusError struct {
+	Backend    string
+	Op         string
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s: %s: unexpected response status %d", e.Backend, e.Op, e.StatusCode)
+	}
+	return fmt.Sprintf("%s: %s: %s (status %d)", e.Backend, e.Op, e.Message, e.StatusCode)
+}
+
+// Temporary reports whether the request can succeed when sent again, rather
+// than being refused for good, like with a wrong token
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusRequestTimeout
+}
+
+// AttemptsError is the last error of a file that could not be pinned
+type AttemptsError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *AttemptsError) Error() string {
+	return fmt.Sprintf("gave up after %d attempt(s): %v", e.Attempts, e.Err)
+}
+
+func (e *AttemptsError) Unwrap() error {
+	return e.Err
+}
+
+// Retry pins through another pinner, giving every attempt a timeout and
+// retrying failed attempts with exponential backoff. Missing files, CID
+// mismatches and refused requests are not retried.
+type Retry struct {
+	Pinner
+	Attempts        int           // attempts per file
+	Timeout         time.Duration // of every attempt, 0 for none
+	InitialInterval time.Duration // wait before the first retry, growing after every retry
+}
+
+// NewRetry retries pins through pinner up to attempts times per file, with
+// every attempt limited to timeout
+func NewRetry(pinner Pinner, attempts int, timeout time.Duration) *Retry {
+	return &Retry{
+		Pinner:          pinner,
+		Attempts:        attempts,
+		Timeout:         timeout,
+		InitialInterval: backoff.DefaultInitialInterval,
+	}
+}
+
+// retryable tells failures worth another attempt from those that are not
+func retryable(err error) bool {
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, ErrCIDMismatch) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+	return true
+}
+
+func (r *Retry) Pin(ctx context.Context, path string) (cid.Cid, error) {
+	policy := backoff.NewExponentialBackOff()
+	policy.InitialInterval = r.InitialInterval
+	policy.MaxElapsedTime = 0
+	maxRetries := 0
+	if r.Attempts > 1 {
+		maxRetries = r.Attempts - 1
+	}
+
+	attempts := 0
+	root := cid.Undef
+	attempt := func() error {
+		attempts++
+		attemptCtx := ctx
+		if r.Timeout > 0 {
+			var cancel context.CancelFunc
+			attemptCtx, cancel = context.WithTimeout(ctx, r.Timeout)
+			defer cancel()
+		}
+		pinned, err := r.Pinner.Pin(attemptCtx, path)
+		if err != nil {
+			if ctx.Err() != nil || !retryable(err) {
+				return backoff.Permanent(err)
+			}
+			return err
+		}
+		root = pinned
+		return nil
+	}
+	notify := func(err error, wait time.Duration) {
+		fmt.Printf("Pinning %s failed, retrying in %s: %v\n", path, wait.Round(time.Millisecond), err)
+	}
+
+	err := backoff.RetryNotify(attempt, backoff.WithContext(backoff.WithMaxRetries(policy, uint64(maxRetries)), ctx), notify)
+	if err != nil {
+		return cid.Undef, &AttemptsError{Attempts: attempts, Err: err}
+	}
+	return root, nil
+}
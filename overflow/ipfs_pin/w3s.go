@@ -0,0 +1,49 @@
+package ipfs_pin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/web3-storage/go-w3s-client"
+)
+
+// W3S pins to web3.storage
+type W3S struct {
+	client w3s.Client
+}
+
+// NewW3S pins to web3.storage with an API token. Options can point the client
+// at another endpoint.
+func NewW3S(token string, options ...w3s.Option) (*W3S, error) {
+	if token == "" {
+		return nil, fmt.Errorf("w3s: missing WEB3_STORAGE_IPFS_API_KEY")
+	}
+	client, err := w3s.NewClient(append([]w3s.Option{w3s.WithToken(token)}, options...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &W3S{client: client}, nil
+}
+
+func (p *W3S) Backend() (string, string) {
+	return BackendW3S, StatusPinned
+}
+
+func (p *W3S) Pin(ctx context.Context, path string) (cid.Cid, error) {
+	packed, err := PackFile(ctx, path)
+	if err != nil {
+		return cid.Undef, err
+	}
+	pinned, err := p.client.PutCar(ctx, bytes.NewReader(packed.Data))
+	if err != nil {
+		// the client reports refused uploads only in its message
+		var statusCode int
+		if _, scanErr := fmt.Sscanf(err.Error(), "unexpected response status: %d", &statusCode); scanErr == nil {
+			return cid.Undef, &StatusError{Backend: "w3s", Op: "pinning " + path, StatusCode: statusCode}
+		}
+		return cid.Undef, fmt.Errorf("w3s: pinning %s: %w", path, err)
+	}
+	return pinned, checkRoot("w3s", packed.Root, pinned)
+}
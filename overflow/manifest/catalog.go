@@ -0,0 +1,447 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Keys of the entry lists of YAML, JSON and TOML catalogs
+const (
+	ArtCatalogKey       = "art"
+	InventoryCatalogKey = "items"
+	SeriesCatalogKey    = "series"
+	CategoryCatalogKey  = "categories"
+)
+
+// Manifest formats, picked by file extension
+const (
+	formatCSV  = "csv"
+	formatYAML = "yaml"
+	formatJSON = "json"
+	formatTOML = "toml"
+)
+
+func formatOf(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return formatCSV, nil
+	case ".yaml", ".yml":
+		return formatYAML, nil
+	case ".json":
+		return formatJSON, nil
+	case ".toml":
+		return formatTOML, nil
+	}
+	return "", fmt.Errorf("%s: unknown manifest format, expected .csv, .yaml, .yml, .json or .toml", path)
+}
+
+func isCSV(path string) bool {
+	format, _ := formatOf(path)
+	return format == formatCSV
+}
+
+// readManifest calls parse for every entry of a CSV manifest, or of the list
+// under key in a catalog
+func readManifest(path string, schema Schema, key string, parse func(row *Row)) error {
+	format, err := formatOf(path)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case formatJSON:
+		if err := checkJSON(path); err != nil {
+			return err
+		}
+		// JSON is read as YAML, which it is a subset of, to locate every field
+		return readYAML(path, schema, key, parse)
+	case formatYAML:
+		return readYAML(path, schema, key, parse)
+	case formatTOML:
+		return readTOML(path, schema, key, parse)
+	}
+	return ReadCSV(path, schema, parse)
+}
+
+// loadExisting loads the entries of a manifest, or none if it does not exist yet
+func loadExisting[Entry any](path string, load func(path string) ([]Entry, error)) ([]Entry, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return load(path)
+}
+
+// checkJSON rejects JSON that YAML would let through, like a missing value
+func checkJSON(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	err = json.Unmarshal(data, &value)
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// Offset counts the character the error is at, and is 0 for an
+		// empty file, which is located at its start
+		before := []byte{}
+		if syntaxErr.Offset > 0 {
+			before = data[:syntaxErr.Offset-1]
+		}
+		line := bytes.Count(before, []byte("\n")) + 1
+		column := len(before) - bytes.LastIndexByte(before, '\n')
+		return &LineError{Path: path, Line: line, Column: column, Err: err}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// nodeError locates a problem at a YAML node
+func nodeError(path string, node *yaml.Node, format string, args ...interface{}) *LineError {
+	return &LineError{Path: path, Line: node.Line, Column: node.Column, Err: fmt.Errorf(format, args...)}
+}
+
+// readYAML reads a YAML or JSON catalog. Lines and columns of every field are
+// kept, so problems are located the same way as in CSV manifests.
+func readYAML(path string, schema Schema, key string, parse func(row *Row)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return &LineError{Path: path, Line: 1, Err: fmt.Errorf("missing %q list", key)}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nodeError(path, root, "expected a mapping with an %q list", key)
+	}
+
+	errs := LineErrors{}
+	var list *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		name, value := root.Content[i], root.Content[i+1]
+		if name.Value != key {
+			errs = append(errs, nodeError(path, name, "unknown key %q, expected %q", name.Value, key))
+			continue
+		}
+		list = value
+	}
+	if list == nil {
+		errs = append(errs, nodeError(path, root, "missing %q list", key))
+		return errs
+	}
+	if list.Kind != yaml.SequenceNode {
+		errs = append(errs, nodeError(path, list, "%s: expected a list of entries", key))
+		return errs
+	}
+
+	for i, item := range list.Content {
+		if item.Kind != yaml.MappingNode {
+			errs = append(errs, nodeError(path, item, "expected an entry with the keys %s", strings.Join(schema.Header(), ", ")))
+			continue
+		}
+		row := &Row{
+			source:    Source{Path: path, Line: item.Line, Entry: i + 1},
+			fields:    map[string]string{},
+			positions: map[string]position{},
+			errs:      &errs,
+		}
+		ok := true
+		for j := 0; j+1 < len(item.Content); j += 2 {
+			name, value := item.Content[j], item.Content[j+1]
+			if _, known := schema.column(name.Value); !known {
+				errs = append(errs, nodeError(path, name, "unknown key %q, expected one of %s", name.Value, strings.Join(schema.Header(), ", ")))
+				ok = false
+				continue
+			}
+			if _, repeated := row.fields[name.Value]; repeated {
+				errs = append(errs, nodeError(path, name, "key %q is repeated", name.Value))
+				ok = false
+				continue
+			}
+			if value.Kind != yaml.ScalarNode {
+				errs = append(errs, nodeError(path, value, "%s: should be text or a number", name.Value))
+				ok = false
+				continue
+			}
+			// the text as written, so that prices are not rounded through a float
+			row.fields[name.Value] = value.Value
+			if value.ShortTag() == "!!null" {
+				row.fields[name.Value] = ""
+			}
+			row.positions[name.Value] = newPosition(value.Line, value.Column)
+		}
+		if ok {
+			parse(row)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// tomlField turns a TOML value into the text of a field
+func tomlField(value interface{}) (string, bool) {
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case int64:
+		return strconv.FormatInt(value, 10), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// readTOML reads a TOML catalog. The TOML parser does not tell where values
+// are, so problems with entries are located by their place in the list.
+func readTOML(path string, schema Schema, key string, parse func(row *Row)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	doc := map[string]interface{}{}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			line, column := decodeErr.Position()
+			return &LineError{Path: path, Line: line, Column: column, Err: err}
+		}
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	errs := LineErrors{}
+	names := []string{}
+	for name := range doc {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name != key {
+			errs = append(errs, &LineError{Path: path, Err: fmt.Errorf("unknown key %q, expected %q", name, key)})
+		}
+	}
+	value, ok := doc[key]
+	if !ok {
+		errs = append(errs, &LineError{Path: path, Err: fmt.Errorf("missing %q list", key)})
+		return errs
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		errs = append(errs, &LineError{Path: path, Err: fmt.Errorf("%s: expected a list of entries, like [[%s]] tables", key, key)})
+		return errs
+	}
+
+	for i, item := range list {
+		source := Source{Path: path, Entry: i + 1}
+		table, ok := item.(map[string]interface{})
+		if !ok {
+			errs = append(errs, source.lineError(fmt.Errorf("expected an entry with the keys %s", strings.Join(schema.Header(), ", "))))
+			continue
+		}
+		row := &Row{
+			source: source,
+			fields: map[string]string{},
+			errs:   &errs,
+		}
+		names := []string{}
+		for name := range table {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		ok = true
+		for _, name := range names {
+			if _, known := schema.column(name); !known {
+				errs = append(errs, source.lineError(fmt.Errorf("unknown key %q, expected one of %s", name, strings.Join(schema.Header(), ", "))))
+				ok = false
+				continue
+			}
+			field, isField := tomlField(table[name])
+			if !isField {
+				errs = append(errs, source.lineError(fmt.Errorf("%s: should be text or a number", name)))
+				ok = false
+				continue
+			}
+			row.fields[name] = field
+		}
+		if ok {
+			parse(row)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// writeManifest writes rows to a manifest in the format of its file
+// extension, replacing the file if there is one
+func writeManifest(path string, schema Schema, key string, rows []map[string]string) error {
+	format, err := formatOf(path)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	switch format {
+	case formatYAML:
+		data, err = encodeYAML(schema, key, rows)
+	case formatJSON:
+		data, err = encodeJSON(schema, key, rows)
+	case formatTOML:
+		data, err = encodeTOML(schema, key, rows)
+	default:
+		data, err = encodeCSV(schema, rows)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// fieldsOf lists the fields of a row in schema order, leaving out empty
+// optional fields
+func fieldsOf(schema Schema, row map[string]string) []Column {
+	columns := []Column{}
+	for _, column := range schema {
+		if !column.Required && row[column.Name] == "" {
+			continue
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+func encodeCSV(schema Schema, rows []map[string]string) ([]byte, error) {
+	buf := bytes.Buffer{}
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(schema.Header()); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		record := make([]string, len(schema))
+		for i, column := range schema {
+			record[i] = row[column.Name]
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+func encodeYAML(schema Schema, key string, rows []map[string]string) ([]byte, error) {
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, row := range rows {
+		item := &yaml.Node{Kind: yaml.MappingNode}
+		for _, column := range fieldsOf(schema, row) {
+			value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: row[column.Name]}
+			if column.Number {
+				value.Tag = ""
+			} else if strings.Contains(value.Value, "\n") {
+				value.Style = yaml.LiteralStyle
+			}
+			item.Content = append(item.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: column.Name}, value)
+		}
+		list.Content = append(list.Content, item)
+	}
+	root := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: key}, list}}
+
+	buf := bytes.Buffer{}
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// jsonString encodes text as a JSON string, which is a TOML basic string too
+func jsonString(text string) []byte {
+	buf := bytes.Buffer{}
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(text)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// jsonEntry encodes a row with its keys in schema order
+type jsonEntry struct {
+	schema Schema
+	row    map[string]string
+}
+
+func (entry jsonEntry) MarshalJSON() ([]byte, error) {
+	buf := bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, column := range fieldsOf(entry.schema, entry.row) {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(jsonString(column.Name))
+		buf.WriteByte(':')
+		value := entry.row[column.Name]
+		if column.Number && json.Valid([]byte(value)) {
+			buf.WriteString(value)
+		} else {
+			buf.Write(jsonString(value))
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func encodeJSON(schema Schema, key string, rows []map[string]string) ([]byte, error) {
+	entries := []jsonEntry{}
+	for _, row := range rows {
+		entries = append(entries, jsonEntry{schema: schema, row: row})
+	}
+	buf := bytes.Buffer{}
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(map[string][]jsonEntry{key: entries}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeTOML(schema Schema, key string, rows []map[string]string) ([]byte, error) {
+	buf := bytes.Buffer{}
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(&buf, "[[%s]]\n", key)
+		for _, column := range fieldsOf(schema, row) {
+			value := row[column.Name]
+			// TOML floats are float64, so UFix64 prices are quoted to keep
+			// all of their decimals
+			if _, err := strconv.ParseInt(value, 10, 64); column.Number && err == nil {
+				fmt.Fprintf(&buf, "%s = %s\n", column.Name, value)
+			} else {
+				fmt.Fprintf(&buf, "%s = %s\n", column.Name, jsonString(value))
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
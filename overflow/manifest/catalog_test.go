@@ -0,0 +1,291 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/onflow/cadence"
+)
+
+var testArt = []ArtEntry{
+	{
+		Name:        "Athletian Hat 0",
+		PlanetName:  "Athleticus",
+		BaseArt:     "athletian-hat-base",
+		CardArt:     "athletian-hat-card",
+		Description: "Athletian Hat 0 description\nover more than one line, with \"quotes\"",
+		Thumbnail:   "athletian-hat-thumbnail",
+	},
+	{
+		Name:       "Athletian Hat 1",
+		PlanetName: "Athleticus",
+		BaseArt:    "athletian-hat-base",
+		CardArt:    "athletian-hat-card",
+		Thumbnail:  "athletian-hat-thumbnail",
+	},
+}
+
+var testInventory = []InventoryEntry{
+	{
+		Name:        "Athletian Hat 0",
+		Description: "a hat # not a comment",
+		Thumbnail:   "athletian-hat-thumbnail",
+		Quantity:    3,
+		// more decimals than a float64 keeps next to the whole part
+		Price:      cadence.UFix64(1234567812345678),
+		ArtistName: "hichana",
+		SeriesName: "series0",
+		ArtName:    "Athletian Hat 0",
+		Category:   "hat",
+	},
+	{
+		Name:       "Athletian Hat 1",
+		Thumbnail:  "athletian-hat-thumbnail",
+		Quantity:   0,
+		Price:      cadence.UFix64(50_00000000),
+		ArtistName: "hichana",
+		SeriesName: "series0",
+		ArtName:    "Athletian Hat 1",
+		Category:   "hat",
+	},
+}
+
+// withoutSources clears the sources of entries, which only locate them
+func withoutSources[Entry any](entries []Entry) []Entry {
+	cleared := make([]Entry, len(entries))
+	for i, entry := range entries {
+		reflect.ValueOf(&entry).Elem().FieldByName("Source").Set(reflect.ValueOf(Source{}))
+		cleared[i] = entry
+	}
+	return cleared
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []string{"csv", "yaml", "json", "toml"} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+
+			artPath := filepath.Join(dir, "art_list."+format)
+			if err := WriteArtEntries(artPath, testArt); err != nil {
+				t.Fatal(err)
+			}
+			art, err := LoadArtEntries(artPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if art := withoutSources(art); !reflect.DeepEqual(art, testArt) {
+				t.Errorf("expected %+v, found %+v", testArt, art)
+			}
+
+			inventoryPath := filepath.Join(dir, "store_inventory_list."+format)
+			if err := WriteInventoryEntries(inventoryPath, testInventory); err != nil {
+				t.Fatal(err)
+			}
+			inventory, err := LoadInventoryEntries(inventoryPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if inventory := withoutSources(inventory); !reflect.DeepEqual(inventory, testInventory) {
+				t.Errorf("expected %+v, found %+v", testInventory, inventory)
+			}
+		})
+	}
+}
+
+func TestAppendEntries(t *testing.T) {
+	for _, format := range []string{"csv", "yaml", "json", "toml"} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "art_list."+format)
+			for _, entry := range testArt {
+				if err := AppendArtEntries(path, []ArtEntry{entry}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			art, err := LoadArtEntries(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if art := withoutSources(art); !reflect.DeepEqual(art, testArt) {
+				t.Errorf("expected %+v, found %+v", testArt, art)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		data     string
+		expected []string
+	}{
+		{
+			"missing required column",
+			"art_list.csv",
+			"name,base_art,card_art,thumbnail\n",
+			[]string{`art_list.csv:1: missing required column "planet"`},
+		},
+		{
+			"unknown column",
+			"art_list.csv",
+			"name,planet,base_art,card_art,colour,thumbnail\n",
+			[]string{`art_list.csv:1:31: unknown column "colour", expected a header row like name,planet,base_art,card_art,description,thumbnail`},
+		},
+		{
+			"empty required field after a comment",
+			"art_list.csv",
+			"# art of the first series\nname,planet,base_art,card_art,thumbnail\nHat 0,,hat-base,hat-card,hat-thumbnail\n",
+			[]string{`art_list.csv:3:7: planet: is required`},
+		},
+		{
+			"repeated name",
+			"art_list.csv",
+			"name,planet,base_art,card_art,thumbnail\nHat 0,P,b,c,t\n\nHat 0,P,b,c,t\n",
+			[]string{`art_list.csv:4:1: name: "Hat 0" is already listed at line 2`},
+		},
+		{
+			"yaml fields",
+			"art_list.yaml",
+			"art:\n  - name: Hat 0\n    planet: P\n    base_art: hat-base.svg\n    card_art: c\n    thumbnail: t\n  - name: Hat 1\n    colour: red\n",
+			[]string{
+				`art_list.yaml:4:15: base_art: "hat-base.svg" should be a file name without folder or extension`,
+				`art_list.yaml:8:5: unknown key "colour", expected one of name, planet, base_art, card_art, description, thumbnail`,
+			},
+		},
+		{
+			"json numbers",
+			"store_inventory_list.json",
+			`{
+  "items": [
+    {"name": "Hat 0", "thumbnail": "t", "quantity": "many", "price": 1.5, "artist": "a", "series": "s", "art": "Hat 0", "category": "hat"}
+  ]
+}`,
+			[]string{`store_inventory_list.json:3:53: quantity: "many" is not a whole number`},
+		},
+		{
+			"json syntax",
+			"art_list.json",
+			"{\n  \"art\": [\n    {\"name\": }\n  ]\n}",
+			[]string{`art_list.json:3:14: invalid character '}' looking for beginning of value`},
+		},
+		{
+			"empty json",
+			"art_list.json",
+			"",
+			[]string{`art_list.json:1:1: unexpected end of JSON input`},
+		},
+		{
+			"toml entries",
+			"store_inventory_list.toml",
+			"[[items]]\nname = \"Hat 0\"\nthumbnail = \"t\"\nquantity = 1\nprice = \"1.5\"\nartist = \"a\"\nseries = \"s\"\nart = \"Hat 0\"\ncategory = \"hat\"\n\n[[items]]\nname = \"Hat 1\"\nthumbnail = \"t\"\nquantity = 1\nprice = \"1.5\"\nartist = \"a\"\nseries = \"s\"\nart = \"Hat 1\"\n",
+			[]string{`store_inventory_list.toml: entry 2: category: is required`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, test.file)
+			if err := os.WriteFile(path, []byte(test.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			var err error
+			if strings.HasPrefix(test.file, "art_list") {
+				_, err = LoadArtEntries(path)
+			} else {
+				_, err = LoadInventoryEntries(path)
+			}
+
+			actual := []string{}
+			var lineErrs LineErrors
+			var lineErr *LineError
+			switch {
+			case errors.As(err, &lineErrs):
+				for _, e := range lineErrs {
+					actual = append(actual, e.Error())
+				}
+			case errors.As(err, &lineErr):
+				actual = append(actual, lineErr.Error())
+			default:
+				t.Fatalf("expected located errors, found %v", err)
+			}
+			for i := range actual {
+				actual[i] = strings.TrimPrefix(actual[i], dir+string(filepath.Separator))
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected errors\n  %s\nfound\n  %s", strings.Join(test.expected, "\n  "), strings.Join(actual, "\n  "))
+			}
+		})
+	}
+}
+
+func TestEncodeNumbers(t *testing.T) {
+	rows := []map[string]string{
+		{"name": "Hat 0", "quantity": "3", "price": "12.34567891"},
+		{"name": "Hat 1", "quantity": "many", "price": "50"},
+	}
+
+	toml, err := encodeTOML(InventorySchema, InventoryCatalogKey, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the TOML parser reads decimals as float64, so only whole numbers are
+	// left unquoted
+	expectedTOML := `[[items]]
+name = "Hat 0"
+thumbnail = ""
+quantity = 3
+price = "12.34567891"
+artist = ""
+series = ""
+art = ""
+category = ""
+
+[[items]]
+name = "Hat 1"
+thumbnail = ""
+quantity = "many"
+price = 50
+artist = ""
+series = ""
+art = ""
+category = ""
+`
+	if string(toml) != expectedTOML {
+		t.Errorf("expected TOML\n%s\nfound\n%s", expectedTOML, toml)
+	}
+
+	json, err := encodeJSON(InventorySchema, InventoryCatalogKey, rows[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// JSON is read back as text, so decimals are left unquoted too
+	expectedJSON := `{
+  "items": [
+    {
+      "name": "Hat 0",
+      "thumbnail": "",
+      "quantity": 3,
+      "price": 12.34567891,
+      "artist": "",
+      "series": "",
+      "art": "",
+      "category": ""
+    }
+  ]
+}
+`
+	if string(json) != expectedJSON {
+		t.Errorf("expected JSON\n%s\nfound\n%s", expectedJSON, json)
+	}
+	json, err = encodeJSON(InventorySchema, InventoryCatalogKey, rows[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(json), `"quantity": "many"`) {
+		t.Errorf("expected a quantity that is not a number to be quoted, found\n%s", json)
+	}
+}
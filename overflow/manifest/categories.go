@@ -0,0 +1,141 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// CategoriesFileName is the category taxonomy kept next to the inventory of
+// an art repo
+const CategoriesFileName = "categories.csv"
+
+// Columns of categories.csv
+var (
+	CategoryName      = Column{Name: "name", Required: true}
+	CategoryZOrder    = Column{Name: "z_order", Required: true, Number: true}
+	CategoryExclusive = Column{Name: "exclusive"}
+)
+
+// CategorySchema is the header of categories.csv
+var CategorySchema = Schema{CategoryName, CategoryZOrder, CategoryExclusive}
+
+// CategoryEntry is a line of categories.csv, an inventory category with the
+// place of its layer in composites. Layers are stacked from the lowest
+// ZOrder up, and categories sharing an Exclusive group, like two kinds of
+// hats, cannot be worn together.
+type CategoryEntry struct {
+	Name      string
+	ZOrder    uint64
+	Exclusive string // group of mutually exclusive categories, if any
+	Source    Source
+}
+
+// Fields returns the entry by column name
+func (entry CategoryEntry) Fields() map[string]string {
+	return map[string]string{
+		CategoryName.Name:      entry.Name,
+		CategoryZOrder.Name:    strconv.FormatUint(entry.ZOrder, 10),
+		CategoryExclusive.Name: entry.Exclusive,
+	}
+}
+
+// parseCategoryEntry reads a row of categories.csv
+func parseCategoryEntry(row *Row) CategoryEntry {
+	return CategoryEntry{
+		Name:      row.String(CategoryName),
+		ZOrder:    row.UInt64(CategoryZOrder),
+		Exclusive: row.String(CategoryExclusive),
+		Source:    row.Source(),
+	}
+}
+
+// Validate checks the entry on its own
+func (entry CategoryEntry) Validate() error {
+	return checkRequired(entry.Fields(), CategorySchema)
+}
+
+// Taxonomy is the set of categories inventory items can be in
+type Taxonomy struct {
+	Path       string
+	Categories []CategoryEntry
+	byName     map[string]CategoryEntry
+}
+
+// LoadTaxonomy reads and validates categories.csv, or a catalog with the
+// same columns under a "categories" key. Category names have to be unique.
+func LoadTaxonomy(path string) (Taxonomy, error) {
+	entries, err := loadEntries(path, CategorySchema, CategoryCatalogKey, parseCategoryEntry, func(entry CategoryEntry) string { return entry.Name })
+	if err != nil {
+		return Taxonomy{}, err
+	}
+	taxonomy := Taxonomy{Path: path, Categories: entries, byName: map[string]CategoryEntry{}}
+	for _, entry := range entries {
+		taxonomy.byName[entry.Name] = entry
+	}
+	return taxonomy, nil
+}
+
+// Category looks up a category by name
+func (taxonomy Taxonomy) Category(name string) (CategoryEntry, bool) {
+	entry, ok := taxonomy.byName[name]
+	return entry, ok
+}
+
+func (taxonomy Taxonomy) names() []string {
+	names := make([]string, len(taxonomy.Categories))
+	for i, entry := range taxonomy.Categories {
+		names[i] = entry.Name
+	}
+	return names
+}
+
+// unknown describes a category missing from the taxonomy
+func (taxonomy Taxonomy) unknown(name string) error {
+	return fmt.Errorf("%q is not a category of %s%s", name, taxonomy.Path, suggest(name, taxonomy.names()))
+}
+
+// CheckInventory checks that every entry is in a category of the taxonomy
+func (taxonomy Taxonomy) CheckInventory(entries []InventoryEntry) error {
+	errs := LineErrors{}
+	for _, entry := range entries {
+		if _, ok := taxonomy.Category(entry.Category); !ok {
+			errs = append(errs, entry.Source.lineError(&FieldError{Column: InventoryCategory, Err: taxonomy.unknown(entry.Category)}))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ErrExclusive is returned by OrderLayers for layers that cannot be worn
+// together
+var ErrExclusive = errors.New("mutually exclusive")
+
+// OrderLayers returns the order to stack layers of the given categories in,
+// as indexes into categories from the bottom layer up. Layers of the same
+// z-order keep their order. Layers in categories of the same exclusive group
+// are refused.
+func (taxonomy Taxonomy) OrderLayers(categories []string) ([]int, error) {
+	order := make([]int, len(categories))
+	worn := map[string]string{} // category by exclusive group
+	for i, name := range categories {
+		category, ok := taxonomy.Category(name)
+		if !ok {
+			return nil, taxonomy.unknown(name)
+		}
+		if category.Exclusive != "" {
+			if other, ok := worn[category.Exclusive]; ok {
+				return nil, fmt.Errorf("%q and %q are %w, both being %s", other, name, ErrExclusive, category.Exclusive)
+			}
+			worn[category.Exclusive] = name
+		}
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return taxonomy.byName[categories[order[a]]].ZOrder < taxonomy.byName[categories[order[b]]].ZOrder
+	})
+	return order, nil
+}
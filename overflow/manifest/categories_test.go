@@ -0,0 +1,62 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testCategories = `name,z_order,exclusive
+background,0,
+body,10,
+hat,20,headwear
+helmet,20,headwear
+glasses,30,
+badge,30,
+`
+
+func TestOrderLayers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), CategoriesFileName)
+	if err := os.WriteFile(path, []byte(testCategories), 0644); err != nil {
+		t.Fatal(err)
+	}
+	taxonomy, err := LoadTaxonomy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		categories []string
+		expected   []int
+		exclusive  bool
+		unknown    bool
+	}{
+		{"by z-order", []string{"hat", "background", "body"}, []int{1, 2, 0}, false, false},
+		{"equal z-orders keep their order", []string{"badge", "hat", "glasses"}, []int{1, 0, 2}, false, false},
+		{"equal z-orders the other way around", []string{"glasses", "badge"}, []int{0, 1}, false, false},
+		{"one category of an exclusive group", []string{"helmet", "body"}, []int{1, 0}, false, false},
+		{"two categories of an exclusive group", []string{"hat", "body", "helmet"}, nil, true, false},
+		{"unknown category", []string{"body", "cape"}, nil, false, true},
+		{"no layers", []string{}, []int{}, false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			order, err := taxonomy.OrderLayers(test.categories)
+			if errors.Is(err, ErrExclusive) != test.exclusive {
+				t.Errorf("expected ErrExclusive %v, found %v", test.exclusive, err)
+			}
+			if test.unknown && (err == nil || errors.Is(err, ErrExclusive)) {
+				t.Errorf("expected an unknown category error, found %v", err)
+			}
+			if !test.exclusive && !test.unknown && err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(order, test.expected) {
+				t.Errorf("expected order %v, found %v", test.expected, order)
+			}
+		})
+	}
+}
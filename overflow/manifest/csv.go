@@ -0,0 +1,155 @@
+package manifest
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"floasis-items/flow/overflow/helpers"
+)
+
+// commentChar starts a comment line in a CSV manifest
+const commentChar = '#'
+
+// errNoHeader is returned by readHeader for a file without any rows
+var errNoHeader = errors.New("missing header row")
+
+// readHeader reads the header row, skipping comments and blank lines, and
+// checks it against the schema
+func readHeader(reader *csv.Reader, path string, schema Schema) ([]string, error) {
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errNoHeader
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	errs := LineErrors{}
+	seen := map[string]bool{}
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		header[i] = name
+		line, column := reader.FieldPos(i)
+		if _, ok := schema.column(name); !ok {
+			errs = append(errs, &LineError{Path: path, Line: line, Column: column,
+				Err: fmt.Errorf("unknown column %q, expected a header row like %s", name, strings.Join(schema.Header(), ","))})
+			continue
+		}
+		if seen[name] {
+			errs = append(errs, &LineError{Path: path, Line: line, Column: column, Err: fmt.Errorf("column %q is repeated", name)})
+		}
+		seen[name] = true
+	}
+	line, _ := reader.FieldPos(0)
+	for _, column := range schema {
+		if column.Required && !seen[column.Name] {
+			errs = append(errs, &LineError{Path: path, Line: line, Err: fmt.Errorf("missing required column %q", column.Name)})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return header, nil
+}
+
+// ReadCSV reads a CSV manifest with a header row naming its columns, and
+// calls parse for every other row. Lines starting with # are comments, and
+// blank lines are skipped. Every problem is collected with its
+// file:line:column and returned together as LineErrors.
+func ReadCSV(path string, schema Schema, parse func(row *Row)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = commentChar
+	reader.FieldsPerRecord = -1
+
+	header, err := readHeader(reader, path, schema)
+	if err == errNoHeader {
+		return &LineError{Path: path, Line: 1, Err: fmt.Errorf("missing header row %s", strings.Join(schema.Header(), ","))}
+	}
+	if err != nil {
+		return err
+	}
+
+	errs := LineErrors{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			errs = append(errs, &LineError{Path: path, Line: parseErr.Line, Column: parseErr.Column, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(record) != len(header) {
+			errs = append(errs, &LineError{Path: path, Line: line, Err: fmt.Errorf("expected %d fields like the header row, found %d", len(header), len(record))})
+			continue
+		}
+		row := &Row{
+			source:    Source{Path: path, Line: line},
+			fields:    map[string]string{},
+			positions: map[string]position{},
+			errs:      &errs,
+		}
+		for i, name := range header {
+			row.fields[name] = record[i]
+			row.positions[name] = newPosition(reader.FieldPos(i))
+		}
+		parse(row)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// AppendCSV appends rows to a CSV manifest, in the column order of its header
+// row. A header row is written first if the file is new or empty.
+func AppendCSV(path string, schema Schema, rows []map[string]string) error {
+	header := schema.Header()
+	records := [][]string{}
+
+	file, err := os.Open(path)
+	if err == nil {
+		reader := csv.NewReader(file)
+		reader.Comment = commentChar
+		reader.FieldsPerRecord = -1
+		existing, err := readHeader(reader, path, schema)
+		file.Close()
+		switch err {
+		case nil:
+			header = existing
+		case errNoHeader:
+			records = append(records, header)
+		default:
+			return err
+		}
+	} else if errors.Is(err, os.ErrNotExist) {
+		records = append(records, header)
+	} else {
+		return err
+	}
+
+	for _, row := range rows {
+		record := make([]string, len(header))
+		for i, name := range header {
+			record[i] = row[name]
+		}
+		records = append(records, record)
+	}
+	return helpers.AppendCsvFile(path, records)
+}
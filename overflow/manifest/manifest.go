@@ -0,0 +1,419 @@
+/*
+Typed entries of the art repo manifests: art_list.csv, the art that goes into
+the art library, and store_inventory_list.csv, the items the store sells.
+Entries name their artwork by file name without extension, resolved against
+the svg and png folders of the art repo. series_list.csv names the series of
+the art library and the art list of each, and CheckReferences checks the
+inventory against them before anything is sent. categories.csv declares the
+inventory categories, see Taxonomy.
+
+Manifests start with a header row naming their columns, see ArtSchema and
+InventorySchema, so columns can be in any order and optional columns can be
+left out:
+
+	# art of the first series
+	name,planet,base_art,card_art,description,thumbnail
+	Athletian Hat 0,Athleticus,athletian-hat-base,athletian-hat-card,Athletian Hat 0 description,athletian-hat-thumbnail
+
+The same entries can be kept in a YAML, JSON or TOML catalog instead, picked
+by file extension, with the columns as keys of a list under "art" or "items":
+
+	art:
+	  - name: Athletian Hat 0
+	    planet: Athleticus
+	    base_art: athletian-hat-base
+	    card_art: athletian-hat-card
+	    description: |
+	      Athletian Hat 0 description
+	      over more than one line
+	    thumbnail: athletian-hat-thumbnail
+*/
+
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/onflow/cadence"
+)
+
+// Columns of art_list.csv
+var (
+	ArtName        = Column{Name: "name", Required: true}
+	ArtPlanet      = Column{Name: "planet", Required: true}
+	ArtBaseArt     = Column{Name: "base_art", Required: true}
+	ArtCardArt     = Column{Name: "card_art", Required: true}
+	ArtDescription = Column{Name: "description"}
+	ArtThumbnail   = Column{Name: "thumbnail", Required: true}
+)
+
+// ArtSchema is the header of art_list.csv
+var ArtSchema = Schema{ArtName, ArtPlanet, ArtBaseArt, ArtCardArt, ArtDescription, ArtThumbnail}
+
+// Columns of store_inventory_list.csv
+var (
+	InventoryName        = Column{Name: "name", Required: true}
+	InventoryDescription = Column{Name: "description"}
+	InventoryThumbnail   = Column{Name: "thumbnail", Required: true}
+	InventoryQuantity    = Column{Name: "quantity", Required: true, Number: true}
+	InventoryPrice       = Column{Name: "price", Required: true, Number: true}
+	InventoryArtist      = Column{Name: "artist", Required: true}
+	InventorySeries      = Column{Name: "series", Required: true}
+	InventoryArt         = Column{Name: "art", Required: true}
+	InventoryCategory    = Column{Name: "category", Required: true}
+)
+
+// InventorySchema is the header of store_inventory_list.csv
+var InventorySchema = Schema{
+	InventoryName, InventoryDescription, InventoryThumbnail, InventoryQuantity, InventoryPrice,
+	InventoryArtist, InventorySeries, InventoryArt, InventoryCategory,
+}
+
+// ArtEntry is a line of art_list.csv
+type ArtEntry struct {
+	Name        string
+	PlanetName  string
+	BaseArt     string // svg/<BaseArt>.svg
+	CardArt     string // svg/<CardArt>.svg
+	Description string
+	Thumbnail   string // png/<Thumbnail>.png
+	Source      Source
+}
+
+// InventoryEntry is a line of store_inventory_list.csv
+type InventoryEntry struct {
+	Name        string
+	Description string
+	Thumbnail   string // png/<Thumbnail>.png
+	Quantity    uint64
+	Price       cadence.UFix64
+	ArtistName  string
+	SeriesName  string
+	ArtName     string // the ArtEntry the item mints
+	Category    string
+	Source      Source
+}
+
+// Fields returns the entry by column name, see WriteArtEntries
+func (entry ArtEntry) Fields() map[string]string {
+	return map[string]string{
+		ArtName.Name:        entry.Name,
+		ArtPlanet.Name:      entry.PlanetName,
+		ArtBaseArt.Name:     entry.BaseArt,
+		ArtCardArt.Name:     entry.CardArt,
+		ArtDescription.Name: entry.Description,
+		ArtThumbnail.Name:   entry.Thumbnail,
+	}
+}
+
+// Fields returns the entry by column name, see WriteInventoryEntries
+func (entry InventoryEntry) Fields() map[string]string {
+	return map[string]string{
+		InventoryName.Name:        entry.Name,
+		InventoryDescription.Name: entry.Description,
+		InventoryThumbnail.Name:   entry.Thumbnail,
+		InventoryQuantity.Name:    strconv.FormatUint(entry.Quantity, 10),
+		InventoryPrice.Name:       entry.Price.String(),
+		InventoryArtist.Name:      entry.ArtistName,
+		InventorySeries.Name:      entry.SeriesName,
+		InventoryArt.Name:         entry.ArtName,
+		InventoryCategory.Name:    entry.Category,
+	}
+}
+
+// ThumbnailFileName is the name the thumbnail is pinned under
+func (entry ArtEntry) ThumbnailFileName() string {
+	return entry.Thumbnail + ".png"
+}
+
+// ThumbnailFileName is the name the thumbnail is pinned under
+func (entry InventoryEntry) ThumbnailFileName() string {
+	return entry.Thumbnail + ".png"
+}
+
+// parseArtEntry reads a row of art_list.csv
+func parseArtEntry(row *Row) ArtEntry {
+	return ArtEntry{
+		Name:        row.String(ArtName),
+		PlanetName:  row.String(ArtPlanet),
+		BaseArt:     row.String(ArtBaseArt),
+		CardArt:     row.String(ArtCardArt),
+		Description: row.String(ArtDescription),
+		Thumbnail:   row.String(ArtThumbnail),
+		Source:      row.Source(),
+	}
+}
+
+// parseInventoryEntry reads a row of store_inventory_list.csv
+func parseInventoryEntry(row *Row) InventoryEntry {
+	return InventoryEntry{
+		Name:        row.String(InventoryName),
+		Description: row.String(InventoryDescription),
+		Thumbnail:   row.String(InventoryThumbnail),
+		Quantity:    row.UInt64(InventoryQuantity),
+		Price:       row.UFix64(InventoryPrice),
+		ArtistName:  row.String(InventoryArtist),
+		SeriesName:  row.String(InventorySeries),
+		ArtName:     row.String(InventoryArt),
+		Category:    row.String(InventoryCategory),
+		Source:      row.Source(),
+	}
+}
+
+// FieldError is a problem with a single field of an entry
+type FieldError struct {
+	Column Column
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Column.Name, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// checkRequired reports the first required column that is empty
+func checkRequired(fields map[string]string, schema Schema) error {
+	for _, column := range schema {
+		if column.Required && strings.TrimSpace(fields[column.Name]) == "" {
+			return &FieldError{Column: column, Err: fmt.Errorf("is required")}
+		}
+	}
+	return nil
+}
+
+// checkFileName rejects artwork names that are paths or carry an extension,
+// which would be resolved outside of the svg and png folders
+func checkFileName(column Column, name string) error {
+	if strings.ContainsAny(name, `/\`) || filepath.Ext(name) != "" {
+		return &FieldError{Column: column, Err: fmt.Errorf("%q should be a file name without folder or extension", name)}
+	}
+	return nil
+}
+
+// Validate checks the entry on its own
+func (entry ArtEntry) Validate() error {
+	if err := checkRequired(entry.Fields(), ArtSchema); err != nil {
+		return err
+	}
+	for column, name := range map[Column]string{ArtBaseArt: entry.BaseArt, ArtCardArt: entry.CardArt, ArtThumbnail: entry.Thumbnail} {
+		if err := checkFileName(column, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks the entry on its own
+func (entry InventoryEntry) Validate() error {
+	if err := checkRequired(entry.Fields(), InventorySchema); err != nil {
+		return err
+	}
+	return checkFileName(InventoryThumbnail, entry.Thumbnail)
+}
+
+// checkFiles reports artwork files missing from the art repo
+func checkFiles(artRepoPath string, files ...string) error {
+	missing := []string{}
+	for _, file := range files {
+		if _, err := os.Stat(filepath.Join(artRepoPath, file)); err != nil {
+			missing = append(missing, file)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s not found in %s", strings.Join(missing, ", "), artRepoPath)
+	}
+	return nil
+}
+
+// CheckFiles checks that the artwork of the entry is in the art repo
+func (entry ArtEntry) CheckFiles(artRepoPath string) error {
+	return checkFiles(artRepoPath,
+		filepath.Join("svg", entry.BaseArt+".svg"),
+		filepath.Join("svg", entry.CardArt+".svg"),
+		filepath.Join("png", entry.ThumbnailFileName()))
+}
+
+// CheckFiles checks that the thumbnail of the entry is in the art repo
+func (entry InventoryEntry) CheckFiles(artRepoPath string) error {
+	return checkFiles(artRepoPath, filepath.Join("png", entry.ThumbnailFileName()))
+}
+
+// CheckArtFiles checks the files of every entry before any of them is
+// converted or pinned
+func CheckArtFiles(artRepoPath string, entries []ArtEntry) error {
+	errs := LineErrors{}
+	for _, entry := range entries {
+		if err := entry.CheckFiles(artRepoPath); err != nil {
+			errs = append(errs, entry.Source.lineError(err))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// CheckInventoryFiles checks the thumbnail of every entry before any of them
+// is pinned
+func CheckInventoryFiles(artRepoPath string, entries []InventoryEntry) error {
+	errs := LineErrors{}
+	for _, entry := range entries {
+		if err := entry.CheckFiles(artRepoPath); err != nil {
+			errs = append(errs, entry.Source.lineError(err))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// LineError is a problem with a line of a manifest. Lines and columns count
+// from 1, and the column is 0 if the problem is with the line as a whole.
+// Catalogs in formats that do not tell lines locate the entry instead.
+type LineError struct {
+	Path   string
+	Line   int
+	Column int
+	Entry  int
+	Err    error
+}
+
+func (e *LineError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("%s:%d:%d: %v", e.Path, e.Line, e.Column, e.Err)
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+	case e.Entry > 0:
+		return fmt.Sprintf("%s: entry %d: %v", e.Path, e.Entry, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// LineErrors collects every problem found in a manifest
+type LineErrors []*LineError
+
+func (errs LineErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d problem(s) in the manifest:\n  %s", len(errs), strings.Join(messages, "\n  "))
+}
+
+// loadEntries reads a manifest in any format and validates every entry. Entries whose
+// fields did not parse are not validated, so every problem is reported once.
+func loadEntries[Entry interface{ Validate() error }](
+	path string,
+	schema Schema,
+	key string,
+	parse func(row *Row) Entry,
+	name func(entry Entry) string,
+) ([]Entry, error) {
+	entries := []Entry{}
+	sources := map[string]Source{}
+	err := readManifest(path, schema, key, func(row *Row) {
+		problems := len(*row.errs)
+		entry := parse(row)
+		if len(*row.errs) > problems {
+			return
+		}
+		if err := entry.Validate(); err != nil {
+			if fieldErr, ok := err.(*FieldError); ok {
+				row.fail(fieldErr.Column.Name, "%v", fieldErr.Err)
+			} else {
+				*row.errs = append(*row.errs, row.source.lineError(err))
+			}
+			return
+		}
+		if first, ok := sources[name(entry)]; ok {
+			row.fail(schema[0].Name, "%q is already listed at %s", name(entry), first.at())
+			return
+		}
+		sources[name(entry)] = row.source
+		entries = append(entries, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// LoadArtEntries reads and validates art_list.csv, or an art catalog with
+// the same columns under an "art" key. Art names have to be unique.
+func LoadArtEntries(path string) ([]ArtEntry, error) {
+	return loadEntries(path, ArtSchema, ArtCatalogKey, parseArtEntry, func(entry ArtEntry) string { return entry.Name })
+}
+
+// LoadInventoryEntries reads and validates store_inventory_list.csv, or an
+// inventory catalog with the same columns under an "items" key. Item names
+// have to be unique.
+func LoadInventoryEntries(path string) ([]InventoryEntry, error) {
+	return loadEntries(path, InventorySchema, InventoryCatalogKey, parseInventoryEntry, func(entry InventoryEntry) string { return entry.Name })
+}
+
+func artRows(entries []ArtEntry) []map[string]string {
+	rows := []map[string]string{}
+	for _, entry := range entries {
+		rows = append(rows, entry.Fields())
+	}
+	return rows
+}
+
+func inventoryRows(entries []InventoryEntry) []map[string]string {
+	rows := []map[string]string{}
+	for _, entry := range entries {
+		rows = append(rows, entry.Fields())
+	}
+	return rows
+}
+
+// WriteArtEntries writes entries to a new art manifest in the format of its
+// file extension
+func WriteArtEntries(path string, entries []ArtEntry) error {
+	return writeManifest(path, ArtSchema, ArtCatalogKey, artRows(entries))
+}
+
+// WriteInventoryEntries writes entries to a new inventory manifest in the
+// format of its file extension
+func WriteInventoryEntries(path string, entries []InventoryEntry) error {
+	return writeManifest(path, InventorySchema, InventoryCatalogKey, inventoryRows(entries))
+}
+
+// AppendArtEntries appends entries to art_list.csv. Catalogs are read and
+// written again, without their comments.
+func AppendArtEntries(path string, entries []ArtEntry) error {
+	if isCSV(path) {
+		return AppendCSV(path, ArtSchema, artRows(entries))
+	}
+	existing, err := loadExisting(path, LoadArtEntries)
+	if err != nil {
+		return err
+	}
+	return WriteArtEntries(path, append(existing, entries...))
+}
+
+// AppendInventoryEntries appends entries to store_inventory_list.csv.
+// Catalogs are read and written again, without their comments.
+func AppendInventoryEntries(path string, entries []InventoryEntry) error {
+	if isCSV(path) {
+		return AppendCSV(path, InventorySchema, inventoryRows(entries))
+	}
+	existing, err := loadExisting(path, LoadInventoryEntries)
+	if err != nil {
+		return err
+	}
+	return WriteInventoryEntries(path, append(existing, entries...))
+}
@@ -0,0 +1,162 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SeriesArt is a series with the art of its art list
+type SeriesArt struct {
+	Series SeriesEntry
+	Art    []ArtEntry
+}
+
+// Library is the art library a store is set up with, read from
+// series_list.csv and the art lists it names
+type Library struct {
+	Path   string // of the series list
+	Series []SeriesArt
+}
+
+// LoadLibrary reads the series list at path and the art list of every series,
+// reporting the problems of all of them together
+func LoadLibrary(path string) (Library, error) {
+	series, err := LoadSeriesEntries(path)
+	if err != nil {
+		return Library{}, err
+	}
+
+	library := Library{Path: path}
+	errs := LineErrors{}
+	// series can share an art list, which is only read and reported once
+	loaded := map[string][]ArtEntry{}
+	failed := map[string]bool{}
+	for _, entry := range series {
+		artListPath := entry.ArtListPath()
+		if failed[artListPath] {
+			continue
+		}
+		art, ok := loaded[artListPath]
+		if !ok {
+			art, err = LoadArtEntries(artListPath)
+			var lineErrs LineErrors
+			switch {
+			case errors.As(err, &lineErrs):
+				errs = append(errs, lineErrs...)
+			case err != nil:
+				errs = append(errs, entry.Source.lineError(&FieldError{Column: SeriesArtList, Err: err}))
+			}
+			if err != nil {
+				failed[artListPath] = true
+				continue
+			}
+			loaded[artListPath] = art
+		}
+		library.Series = append(library.Series, SeriesArt{Series: entry, Art: art})
+	}
+	if len(errs) > 0 {
+		return Library{}, errs
+	}
+	return library, nil
+}
+
+// ReferenceReport is what CheckReferences found
+type ReferenceReport struct {
+	// Problems are inventory items naming an artist, series or art missing
+	// from the library, and art listed more than once in a series, which
+	// the store transactions would panic on
+	Problems LineErrors
+
+	// Unused is the art no inventory item mints, which is allowed but
+	// usually forgotten
+	Unused LineErrors
+}
+
+// Err returns the problems, or nil if there are none
+func (report ReferenceReport) Err() error {
+	if len(report.Problems) > 0 {
+		return report.Problems
+	}
+	return nil
+}
+
+// suggest names a known name the unknown one only differs from by case or
+// surrounding spaces, which is the usual typo
+func suggest(unknown string, known []string) string {
+	for _, name := range known {
+		if strings.EqualFold(strings.TrimSpace(unknown), strings.TrimSpace(name)) {
+			return fmt.Sprintf(", did you mean %q?", name)
+		}
+	}
+	return ""
+}
+
+// CheckReferences checks that the artist, series and art of every inventory
+// item are in the library, before anything is sent
+func CheckReferences(library Library, inventory []InventoryEntry) ReferenceReport {
+	report := ReferenceReport{}
+
+	artists := []string{}
+	seriesOf := map[string][]string{}
+	artOf := map[string][]string{}
+	sources := map[string]Source{} // of the art, by series key and art name
+	used := map[string]bool{}
+	for _, series := range library.Series {
+		artist, name := series.Series.ArtistName, series.Series.SeriesName
+		if _, ok := seriesOf[artist]; !ok {
+			artists = append(artists, artist)
+		}
+		seriesOf[artist] = append(seriesOf[artist], name)
+		key := seriesKey(artist, name)
+		for _, art := range series.Art {
+			artKey := key + "/" + art.Name
+			if first, ok := sources[artKey]; ok {
+				err := &FieldError{Column: ArtName, Err: fmt.Errorf("%q is already in series %q of %q at %s", art.Name, name, artist, first)}
+				report.Problems = append(report.Problems, art.Source.lineError(err))
+				continue
+			}
+			sources[artKey] = art.Source
+			artOf[key] = append(artOf[key], art.Name)
+		}
+	}
+
+	for _, item := range inventory {
+		var err *FieldError
+		key := seriesKey(item.ArtistName, item.SeriesName)
+		artKey := key + "/" + item.ArtName
+		switch {
+		case seriesOf[item.ArtistName] == nil:
+			err = &FieldError{Column: InventoryArtist, Err: fmt.Errorf("%q is not in %s%s", item.ArtistName, library.Path, suggest(item.ArtistName, artists))}
+		case !hasSeries(seriesOf[item.ArtistName], item.SeriesName):
+			err = &FieldError{Column: InventorySeries, Err: fmt.Errorf("%q of %q is not in %s%s", item.SeriesName, item.ArtistName, library.Path, suggest(item.SeriesName, seriesOf[item.ArtistName]))}
+		case sources[artKey] == (Source{}):
+			err = &FieldError{Column: InventoryArt, Err: fmt.Errorf("%q is not in series %q of %q%s", item.ArtName, item.SeriesName, item.ArtistName, suggest(item.ArtName, artOf[key]))}
+		}
+		if err != nil {
+			report.Problems = append(report.Problems, item.Source.lineError(err))
+			continue
+		}
+		used[artKey] = true
+	}
+
+	for _, series := range library.Series {
+		key := seriesKey(series.Series.ArtistName, series.Series.SeriesName)
+		for _, art := range series.Art {
+			if !used[key+"/"+art.Name] && sources[key+"/"+art.Name] == art.Source {
+				err := fmt.Errorf("art %q of series %q is not minted by any inventory item", art.Name, series.Series.SeriesName)
+				report.Unused = append(report.Unused, art.Source.lineError(err))
+			}
+		}
+	}
+	return report
+}
+
+func hasSeries(series []string, name string) bool {
+	for _, s := range series {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package manifest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckReferences(t *testing.T) {
+	library := Library{
+		Path: "series_list.csv",
+		Series: []SeriesArt{
+			{
+				Series: SeriesEntry{ArtistName: "hichana", SeriesName: "Series0", Source: Source{Path: "series_list.csv", Line: 2}},
+				Art: []ArtEntry{
+					{Name: "Hat 0", Source: Source{Path: "art_list.csv", Line: 2}},
+					{Name: "Hat 1", Source: Source{Path: "art_list.csv", Line: 3}},
+				},
+			},
+		},
+	}
+	item := func(seriesName string, artName string) InventoryEntry {
+		return InventoryEntry{ArtistName: "hichana", SeriesName: seriesName, ArtName: artName, Source: Source{Path: "store_inventory_list.csv", Line: 2}}
+	}
+
+	tests := []struct {
+		name     string
+		item     InventoryEntry
+		problems []string
+		unused   []string
+	}{
+		{
+			"known series",
+			item("Series0", "Hat 0"),
+			[]string{},
+			[]string{`art_list.csv:3: art "Hat 1" of series "Series0" is not minted by any inventory item`},
+		},
+		{
+			"unknown series with a near match",
+			item("series0 ", "Hat 0"),
+			[]string{`store_inventory_list.csv:2: series: "series0 " of "hichana" is not in series_list.csv, did you mean "Series0"?`},
+			[]string{`art_list.csv:2: art "Hat 0" of series "Series0" is not minted by any inventory item`, `art_list.csv:3: art "Hat 1" of series "Series0" is not minted by any inventory item`},
+		},
+		{
+			"empty series",
+			item("", "Hat 0"),
+			[]string{`store_inventory_list.csv:2: series: "" of "hichana" is not in series_list.csv`},
+			[]string{`art_list.csv:2: art "Hat 0" of series "Series0" is not minted by any inventory item`, `art_list.csv:3: art "Hat 1" of series "Series0" is not minted by any inventory item`},
+		},
+		{
+			"unknown art",
+			item("Series0", "hat 1"),
+			[]string{`store_inventory_list.csv:2: art: "hat 1" is not in series "Series0" of "hichana", did you mean "Hat 1"?`},
+			[]string{`art_list.csv:2: art "Hat 0" of series "Series0" is not minted by any inventory item`, `art_list.csv:3: art "Hat 1" of series "Series0" is not minted by any inventory item`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report := CheckReferences(library, []InventoryEntry{test.item})
+			if problems := messages(report.Problems); !reflect.DeepEqual(problems, test.problems) {
+				t.Errorf("expected problems %q, found %q", test.problems, problems)
+			}
+			if unused := messages(report.Unused); !reflect.DeepEqual(unused, test.unused) {
+				t.Errorf("expected unused art %q, found %q", test.unused, unused)
+			}
+			if (report.Err() == nil) != (len(test.problems) == 0) {
+				t.Errorf("expected Err to report the problems, found %v", report.Err())
+			}
+		})
+	}
+}
+
+// messages returns the message of every error
+func messages(errs LineErrors) []string {
+	m := []string{}
+	for _, err := range errs {
+		m = append(m, err.Error())
+	}
+	return m
+}
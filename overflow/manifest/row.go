@@ -0,0 +1,148 @@
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/onflow/cadence"
+)
+
+// Column is a named column of a manifest, or a key of the entries of a
+// catalog. Number columns are written unquoted to catalogs.
+type Column struct {
+	Name     string
+	Required bool
+	Number   bool
+}
+
+// Schema lists the columns of a manifest in the order new files are written.
+// CSV manifests and YAML, JSON and TOML catalogs share the same schema.
+type Schema []Column
+
+func (schema Schema) column(name string) (Column, bool) {
+	for _, column := range schema {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+// Header returns the column names in order
+func (schema Schema) Header() []string {
+	header := make([]string, len(schema))
+	for i, column := range schema {
+		header[i] = column.Name
+	}
+	return header
+}
+
+// position of a field in its file, counting from 1
+type position struct {
+	line   int
+	column int
+}
+
+func newPosition(line int, column int) position {
+	return position{line: line, column: column}
+}
+
+// Row is an entry of a manifest whose fields are read by column name.
+// Problems are collected rather than returned, so that every problem in a
+// file can be reported at once.
+type Row struct {
+	source    Source
+	fields    map[string]string
+	positions map[string]position // where every field is, when the format tells
+	errs      *LineErrors
+}
+
+// fail records a problem with the field of a column
+func (row *Row) fail(name string, format string, args ...interface{}) {
+	pos, ok := row.positions[name]
+	if !ok {
+		pos = position{line: row.source.Line}
+	}
+	*row.errs = append(*row.errs, &LineError{
+		Path:   row.source.Path,
+		Line:   pos.line,
+		Column: pos.column,
+		Entry:  row.source.Entry,
+		Err:    fmt.Errorf("%s: %s", name, fmt.Sprintf(format, args...)),
+	})
+}
+
+// String returns the trimmed field of a column. Required fields must be
+// there and not empty, optional columns may be left out.
+func (row *Row) String(column Column) string {
+	value := strings.TrimSpace(row.fields[column.Name])
+	if column.Required && value == "" {
+		row.fail(column.Name, "is required")
+	}
+	return value
+}
+
+// UInt64 parses the field of a column as a whole number
+func (row *Row) UInt64(column Column) uint64 {
+	value := row.String(column)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		row.fail(column.Name, "%q is not a whole number", value)
+	}
+	return n
+}
+
+// UFix64 parses the field of a column as a UFix64. Whole numbers are
+// accepted too, since spreadsheets drop the ".0" of a price like 50.0.
+func (row *Row) UFix64(column Column) cadence.UFix64 {
+	value := row.String(column)
+	if value == "" {
+		return 0
+	}
+	if !strings.Contains(value, ".") {
+		value += ".0"
+	}
+	n, err := cadence.ParseUFix64(value)
+	if err != nil {
+		row.fail(column.Name, "%q is not a UFix64: %v", row.fields[column.Name], err)
+	}
+	return cadence.UFix64(n)
+}
+
+// Source is where an entry was read from
+func (row *Row) Source() Source {
+	return row.source
+}
+
+// Source locates an entry in its manifest. Lines count from 1, and are 0 for
+// formats that do not tell them, in which case Entry counts the entries of
+// the catalog from 1.
+type Source struct {
+	Path  string
+	Line  int
+	Entry int
+}
+
+func (source Source) String() string {
+	if source.Line > 0 {
+		return fmt.Sprintf("%s:%d", source.Path, source.Line)
+	}
+	return fmt.Sprintf("%s: entry %d", source.Path, source.Entry)
+}
+
+// at locates the entry within its file
+func (source Source) at() string {
+	if source.Line > 0 {
+		return fmt.Sprintf("line %d", source.Line)
+	}
+	return fmt.Sprintf("entry %d", source.Entry)
+}
+
+// lineError locates a problem with the entry as a whole
+func (source Source) lineError(err error) *LineError {
+	return &LineError{Path: source.Path, Line: source.Line, Entry: source.Entry, Err: err}
+}
@@ -0,0 +1,79 @@
+package manifest
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Columns of series_list.csv
+var (
+	SeriesArtist  = Column{Name: "artist", Required: true}
+	SeriesName    = Column{Name: "series", Required: true}
+	SeriesArtList = Column{Name: "art_list", Required: true}
+)
+
+// SeriesSchema is the header of series_list.csv
+var SeriesSchema = Schema{SeriesArtist, SeriesName, SeriesArtList}
+
+// SeriesEntry is a line of series_list.csv, a series of an artist in the art
+// library and the art manifest of the art added to it
+type SeriesEntry struct {
+	ArtistName string
+	SeriesName string
+	ArtList    string // relative to the series list
+	Source     Source
+}
+
+// Fields returns the entry by column name
+func (entry SeriesEntry) Fields() map[string]string {
+	return map[string]string{
+		SeriesArtist.Name:  entry.ArtistName,
+		SeriesName.Name:    entry.SeriesName,
+		SeriesArtList.Name: entry.ArtList,
+	}
+}
+
+// ArtListPath resolves the art manifest of the series against the series
+// list it was read from
+func (entry SeriesEntry) ArtListPath() string {
+	if filepath.IsAbs(entry.ArtList) {
+		return entry.ArtList
+	}
+	return filepath.Join(filepath.Dir(entry.Source.Path), entry.ArtList)
+}
+
+// parseSeriesEntry reads a row of series_list.csv
+func parseSeriesEntry(row *Row) SeriesEntry {
+	return SeriesEntry{
+		ArtistName: row.String(SeriesArtist),
+		SeriesName: row.String(SeriesName),
+		ArtList:    row.String(SeriesArtList),
+		Source:     row.Source(),
+	}
+}
+
+// Validate checks the entry on its own
+func (entry SeriesEntry) Validate() error {
+	if err := checkRequired(entry.Fields(), SeriesSchema); err != nil {
+		return err
+	}
+	if _, err := formatOf(entry.ArtList); err != nil {
+		return &FieldError{Column: SeriesArtList, Err: err}
+	}
+	return nil
+}
+
+// seriesKey names a series the way the art library does, by artist and
+// series name
+func seriesKey(artistName string, seriesName string) string {
+	return fmt.Sprintf("%s/%s", artistName, seriesName)
+}
+
+// LoadSeriesEntries reads and validates series_list.csv, or a series catalog
+// with the same columns under a "series" key. A series can only be listed
+// once per artist.
+func LoadSeriesEntries(path string) ([]SeriesEntry, error) {
+	return loadEntries(path, SeriesSchema, SeriesCatalogKey, parseSeriesEntry, func(entry SeriesEntry) string {
+		return seriesKey(entry.ArtistName, entry.SeriesName)
+	})
+}
@@ -0,0 +1,85 @@
+package png2svg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BoundingBoxAttribute is the SVG attribute the bounding box of the whole
+// artwork (on the <svg> tag) or of a color group (on a <g> tag) is written to
+const BoundingBoxAttribute = "data-bbox"
+
+// BoundingBox is an axis-aligned box in pixel coordinates
+type BoundingBox struct {
+	X, Y int
+	W, H int
+}
+
+// Empty returns true if the box does not cover any pixels
+func (bb BoundingBox) Empty() bool {
+	return bb.W <= 0 || bb.H <= 0
+}
+
+// Union returns the smallest box that covers both boxes
+func (bb BoundingBox) Union(other BoundingBox) BoundingBox {
+	if bb.Empty() {
+		return other
+	}
+	if other.Empty() {
+		return bb
+	}
+	minX, minY := minInt(bb.X, other.X), minInt(bb.Y, other.Y)
+	maxX, maxY := maxInt(bb.X+bb.W, other.X+other.W), maxInt(bb.Y+bb.H, other.Y+other.H)
+	return BoundingBox{minX, minY, maxX - minX, maxY - minY}
+}
+
+// Overlaps returns true if the two boxes share at least one pixel
+func (bb BoundingBox) Overlaps(other BoundingBox) bool {
+	if bb.Empty() || other.Empty() {
+		return false
+	}
+	return bb.X < other.X+other.W && other.X < bb.X+bb.W &&
+		bb.Y < other.Y+other.H && other.Y < bb.Y+bb.H
+}
+
+// String returns the box on the same "x y width height" form as a viewBox
+func (bb BoundingBox) String() string {
+	return fmt.Sprintf("%d %d %d %d", bb.X, bb.Y, bb.W, bb.H)
+}
+
+// ParseBoundingBox parses a box written by BoundingBox.String
+func ParseBoundingBox(s string) (BoundingBox, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 4 {
+		return BoundingBox{}, fmt.Errorf("bounding box %q must have four numbers", s)
+	}
+	var numbers [4]int
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return BoundingBox{}, fmt.Errorf("bounding box %q: %w", s, err)
+		}
+		numbers[i] = n
+	}
+	return BoundingBox{numbers[0], numbers[1], numbers[2], numbers[3]}, nil
+}
+
+// boxBounds returns the pixels covered by a box
+func boxBounds(bo *Box) BoundingBox {
+	return BoundingBox{bo.x, bo.y, bo.w, bo.h}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,137 @@
+package png2svg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseHexColor parses a color on the "#rrggbb" or "#rgb" form
+func ParseHexColor(s string) (r, g, b int, err error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return 0, 0, 0, fmt.Errorf("color %q is not on the #rrggbb or #rgb form", s)
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("color %q is not on the #rrggbb or #rgb form", s)
+	}
+	return int(n >> 16 & 0xff), int(n >> 8 & 0xff), int(n & 0xff), nil
+}
+
+// CornerColor returns the opaque color found in most of the four corners,
+// which is usually the background color of art exported without transparency.
+// Returns false if no two corners share an opaque color.
+func (pi *PixelImage) CornerColor() (r, g, b int, ok bool) {
+	if pi.w == 0 || pi.h == 0 {
+		return 0, 0, 0, false
+	}
+	corners := []*Pixel{
+		pi.pixels[0],
+		pi.pixels[pi.w-1],
+		pi.pixels[(pi.h-1)*pi.w],
+		pi.pixels[pi.h*pi.w-1],
+	}
+	bestCount := 0
+	for _, corner := range corners {
+		if corner.a == 0 {
+			continue
+		}
+		count := 0
+		for _, other := range corners {
+			if other.a != 0 && other.r == corner.r && other.g == corner.g && other.b == corner.b {
+				count++
+			}
+		}
+		if count > bestCount {
+			bestCount = count
+			r, g, b = corner.r, corner.g, corner.b
+		}
+	}
+	return r, g, b, bestCount >= 2
+}
+
+// matchesKey returns true if the pixel is opaque and every channel is within
+// tolerance of the key color
+func (p *Pixel) matchesKey(r, g, b, tolerance int) bool {
+	if p.a == 0 {
+		return false
+	}
+	return absInt(p.r-r) <= tolerance && absInt(p.g-g) <= tolerance && absInt(p.b-b) <= tolerance
+}
+
+// RemoveColor makes every pixel of the key color transparent, so that it is
+// treated as background and not covered by rectangles.
+// If floodFill is true, only key colored pixels that are connected to an edge
+// of the image are removed, and interior pixels of the same color are kept.
+// Must be called before any rectangles are placed.
+// Returns the number of removed pixels.
+func (pi *PixelImage) RemoveColor(r, g, b, tolerance int, floodFill bool) int {
+	removed := 0
+	remove := func(p *Pixel) {
+		p.a = 0
+		p.covered = true
+		removed++
+	}
+
+	if !floodFill {
+		for _, p := range pi.pixels {
+			if p.matchesKey(r, g, b, tolerance) {
+				remove(p)
+			}
+		}
+		pi.updateContentBounds()
+		return removed
+	}
+
+	// Flood fill from the edges, through key colored and already transparent pixels
+	visited := make([]bool, len(pi.pixels))
+	queue := []int{}
+	push := func(x, y int) {
+		if x < 0 || y < 0 || x >= pi.w || y >= pi.h {
+			return
+		}
+		i := y*pi.w + x
+		if visited[i] {
+			return
+		}
+		p := pi.pixels[i]
+		if p.a != 0 && !p.matchesKey(r, g, b, tolerance) {
+			return
+		}
+		visited[i] = true
+		queue = append(queue, i)
+	}
+	for x := 0; x < pi.w; x++ {
+		push(x, 0)
+		push(x, pi.h-1)
+	}
+	for y := 0; y < pi.h; y++ {
+		push(0, y)
+		push(pi.w-1, y)
+	}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		if p := pi.pixels[i]; p.a != 0 {
+			remove(p)
+		}
+		x, y := i%pi.w, i/pi.w
+		push(x-1, y)
+		push(x+1, y)
+		push(x, y-1)
+		push(x, y+1)
+	}
+	pi.updateContentBounds()
+	return removed
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
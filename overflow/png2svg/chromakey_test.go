@@ -0,0 +1,207 @@
+package png2svg
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testColors are the colors of the test images, by the character that
+// draws them. "." is transparent.
+var testColors = map[rune]color.NRGBA{
+	'w': {R: 255, G: 255, B: 255, A: 255},
+	'n': {R: 250, G: 250, B: 250, A: 255}, // near white
+	'r': {R: 255, A: 255},
+	'g': {G: 255, A: 255},
+}
+
+// newTestImage draws a PixelImage row by row from the characters of testColors
+func newTestImage(rows ...string) *PixelImage {
+	img := image.NewNRGBA(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, pixel := range row {
+			img.SetNRGBA(x, y, testColors[pixel])
+		}
+	}
+	return NewPixelImage(img, false)
+}
+
+// pixelRows draws the image back into characters, with "o" for the pixels
+// of the generated outline
+func pixelRows(pi *PixelImage) []string {
+	rows := []string{}
+	for y := 0; y < pi.h; y++ {
+		var row strings.Builder
+		for x := 0; x < pi.w; x++ {
+			p := pi.pixels[y*pi.w+x]
+			switch {
+			case p.a == 0:
+				row.WriteRune('.')
+			case p.outline:
+				row.WriteRune('o')
+			default:
+				for pixel, c := range testColors {
+					if int(c.R) == p.r && int(c.G) == p.g && int(c.B) == p.b {
+						row.WriteRune(pixel)
+					}
+				}
+			}
+		}
+		rows = append(rows, row.String())
+	}
+	return rows
+}
+
+func TestRemoveColor(t *testing.T) {
+	tests := []struct {
+		name      string
+		image     []string
+		tolerance int
+		floodFill bool
+		expected  []string
+		removed   int
+	}{
+		{
+			"global removal",
+			[]string{"wwwww", "wrrrw", "wrwrw", "wrrrw", "wwwww"},
+			0, false,
+			[]string{".....", ".rrr.", ".r.r.", ".rrr.", "....."},
+			17,
+		},
+		{
+			"flood fill keeps enclosed regions",
+			[]string{"wwwww", "wrrrw", "wrwrw", "wrrrw", "wwwww"},
+			0, true,
+			[]string{".....", ".rrr.", ".rwr.", ".rrr.", "....."},
+			16,
+		},
+		{
+			"flood fill does not cross diagonals",
+			[]string{"wrr", "rwr", "rrr"},
+			0, true,
+			[]string{".rr", "rwr", "rrr"},
+			1,
+		},
+		{
+			"flood fill through transparent pixels",
+			[]string{".rrr", ".wwr", ".rrr"},
+			0, true,
+			[]string{".rrr", "...r", ".rrr"},
+			2,
+		},
+		{
+			"within tolerance",
+			[]string{"nwr"},
+			5, false,
+			[]string{"..r"},
+			2,
+		},
+		{
+			"just outside of tolerance",
+			[]string{"nwr"},
+			4, false,
+			[]string{"n.r"},
+			1,
+		},
+		{
+			"flood fill within tolerance",
+			[]string{"nwr", "rnr"},
+			5, true,
+			[]string{"..r", "r.r"},
+			3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pi := newTestImage(test.image...)
+			removed := pi.RemoveColor(255, 255, 255, test.tolerance, test.floodFill)
+			if removed != test.removed {
+				t.Errorf("expected %d removed pixels, found %d", test.removed, removed)
+			}
+			if actual := pixelRows(pi); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %v, found %v", test.expected, actual)
+			}
+			for i, p := range pi.pixels {
+				if p.a == 0 && !p.covered {
+					t.Errorf("expected removed pixel %d to be covered", i)
+				}
+			}
+		})
+	}
+}
+
+// TestRemoveColorContentBounds checks that the content box shrinks to the
+// pixels that are left
+func TestRemoveColorContentBounds(t *testing.T) {
+	pi := newTestImage("wwwww", "wrrrw", "wrwrw", "wwwww")
+	if expected := (BoundingBox{0, 0, 5, 4}); pi.ContentBounds() != expected {
+		t.Fatalf("expected content bounds %v, found %v", expected, pi.ContentBounds())
+	}
+	pi.RemoveColor(255, 255, 255, 0, true)
+	if expected := (BoundingBox{1, 1, 3, 2}); pi.ContentBounds() != expected {
+		t.Errorf("expected content bounds %v, found %v", expected, pi.ContentBounds())
+	}
+}
+
+func TestCornerColor(t *testing.T) {
+	tests := []struct {
+		name    string
+		image   []string
+		r, g, b int
+		ok      bool
+	}{
+		{"all corners", []string{"wrw", "rrr", "wrw"}, 255, 255, 255, true},
+		{"most corners", []string{"wrg", "rrr", "wrw"}, 255, 255, 255, true},
+		{"two corners", []string{"wrg", "rrr", "wrg"}, 255, 255, 255, true},
+		{"transparent corners do not count", []string{".wg", "www", ".wg"}, 0, 255, 0, true},
+		{"no shared corner", []string{"wrg", "rrr", "nr."}, 0, 0, 0, false},
+		{"transparent", []string{"...", "...", "..."}, 0, 0, 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, g, b, ok := newTestImage(test.image...).CornerColor()
+			if ok != test.ok || (ok && (r != test.r || g != test.g || b != test.b)) {
+				t.Errorf("expected %d,%d,%d %v, found %d,%d,%d %v", test.r, test.g, test.b, test.ok, r, g, b, ok)
+			}
+		})
+	}
+
+	empty := NewPixelImage(image.NewNRGBA(image.Rect(0, 0, 0, 0)), false)
+	if _, _, _, ok := empty.CornerColor(); ok {
+		t.Error("expected an empty image to have no corner color")
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	tests := []struct {
+		s       string
+		r, g, b int
+	}{
+		{"#ff8000", 255, 128, 0},
+		{"ff8000", 255, 128, 0},
+		{"#F80", 255, 136, 0},
+		{" #abc ", 170, 187, 204},
+		{"#000000", 0, 0, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			r, g, b, err := ParseHexColor(test.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r != test.r || g != test.g || b != test.b {
+				t.Errorf("expected %d,%d,%d, found %d,%d,%d", test.r, test.g, test.b, r, g, b)
+			}
+		})
+	}
+
+	for _, s := range []string{"", "#", "#ff80", "#ff80000", "#gg0000", "#ff 800", "#+ff800", "0xff80", "red"} {
+		_, _, _, err := ParseHexColor(s)
+		expected := `color "` + s + `" is not on the #rrggbb or #rgb form`
+		if err == nil || err.Error() != expected {
+			t.Errorf("expected %q, found %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package png2svg
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/xyproto/tinysvg"
+)
+
+// OutlineAttribute marks the <g> tag that holds the generated outline
+const OutlineAttribute = "data-outline"
+
+// outlineGroupKey is the groupBounds key of the outline group, which is kept
+// apart from the artwork groups even if it shares a fill color with one of them
+const outlineGroupKey = "outline"
+
+// opaqueAt returns true if the pixel at the given coordinate is opaque.
+// Coordinates outside of the image are transparent.
+func (pi *PixelImage) opaqueAt(x, y int) bool {
+	if x < 0 || y < 0 || x >= pi.w || y >= pi.h {
+		return false
+	}
+	return pi.pixels[y*pi.w+x].a != 0
+}
+
+// hasNeighbour returns true if one of the 4 (or 8) neighbours of the given
+// coordinate is opaque (or transparent, if opaque is false)
+func (pi *PixelImage) hasNeighbour(x, y int, eightConnected bool, opaque bool) bool {
+	neighbours := [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	if eightConnected {
+		neighbours = append(neighbours, [2]int{-1, -1}, [2]int{1, -1}, [2]int{-1, 1}, [2]int{1, 1})
+	}
+	for _, n := range neighbours {
+		if pi.opaqueAt(x+n[0], y+n[1]) == opaque {
+			return true
+		}
+	}
+	return false
+}
+
+// AddOutline generates a 1px outline of the given color around the opaque regions.
+// If inside is false, the outline is drawn on the transparent pixels around the
+// artwork. If inside is true, the outermost pixels of the artwork are replaced.
+// If eightConnected is true, diagonal neighbours count as touching.
+// The outline is written to its own <g>, so that it can be recolored on its own.
+// Must be called before any rectangles are placed.
+// Returns the number of outline pixels.
+func (pi *PixelImage) AddOutline(r, g, b int, eightConnected bool, inside bool) int {
+	// Find all outline pixels first, so that new outline pixels do not grow the outline
+	outlined := []int{}
+	for i, p := range pi.pixels {
+		x, y := i%pi.w, i/pi.w
+		if inside && p.a != 0 && pi.hasNeighbour(x, y, eightConnected, false) {
+			outlined = append(outlined, i)
+		} else if !inside && p.a == 0 && pi.hasNeighbour(x, y, eightConnected, true) {
+			outlined = append(outlined, i)
+		}
+	}
+
+	for _, i := range outlined {
+		p := pi.pixels[i]
+		p.r, p.g, p.b, p.a = r, g, b, 255
+		p.covered = false
+		p.outline = true
+	}
+	pi.outlineColor = string(lengthenBlack(tinysvg.ColorBytes(r, g, b), pi.colorOptimize))
+	pi.updateContentBounds()
+	return len(outlined)
+}
+
+// coverOutlineBox records an outline rectangle, and marks its pixels as covered
+func (pi *PixelImage) coverOutlineBox(bo *Box) {
+	pi.outlineBoxes = append(pi.outlineBoxes, boxBounds(bo))
+	pi.groupBounds[outlineGroupKey] = pi.groupBounds[outlineGroupKey].Union(boxBounds(bo))
+	for y := bo.y; y < (bo.y + bo.h); y++ {
+		for x := bo.x; x < (bo.x + bo.w); x++ {
+			pi.pixels[y*pi.w+x].covered = true
+		}
+	}
+}
+
+// insertOutlineGroup adds the outline group as the last child of the <svg> tag,
+// so that it is drawn on top of the artwork
+func (pi *PixelImage) insertOutlineGroup(svgDocument []byte) []byte {
+	if len(pi.outlineBoxes) == 0 {
+		return svgDocument
+	}
+	var buf bytes.Buffer
+	buf.WriteString("<g fill=\"" + pi.outlineColor + "\" " + OutlineAttribute + "=\"true\"")
+	if pi.boundingBoxes {
+		buf.WriteString(" " + BoundingBoxAttribute + "=\"" + pi.groupBounds[outlineGroupKey].String() + "\"")
+	}
+	buf.WriteString(">")
+	for _, bb := range pi.outlineBoxes {
+		fmt.Fprintf(&buf, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\"/>", bb.X, bb.Y, bb.W, bb.H)
+	}
+	buf.WriteString("</g>")
+
+	end := bytes.LastIndex(svgDocument, []byte("</svg>"))
+	if end < 0 {
+		return append(svgDocument, buf.Bytes()...)
+	}
+	return append(svgDocument[:end], append(buf.Bytes(), svgDocument[end:]...)...)
+}
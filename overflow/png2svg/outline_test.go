@@ -0,0 +1,165 @@
+package png2svg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAddOutline(t *testing.T) {
+	tests := []struct {
+		name           string
+		image          []string
+		eightConnected bool
+		inside         bool
+		expected       []string
+	}{
+		{
+			"outside, 4-connected",
+			[]string{".....", ".....", "..r..", ".....", "....."},
+			false, false,
+			[]string{".....", "..o..", ".oro.", "..o..", "....."},
+		},
+		{
+			"outside, 8-connected",
+			[]string{".....", ".....", "..r..", ".....", "....."},
+			true, false,
+			[]string{".....", ".ooo.", ".oro.", ".ooo.", "....."},
+		},
+		{
+			// the center only touches transparent pixels diagonally
+			"inside, 4-connected",
+			[]string{".....", "..r..", ".rrr.", "..r..", "....."},
+			false, true,
+			[]string{".....", "..o..", ".oro.", "..o..", "....."},
+		},
+		{
+			"inside, 8-connected",
+			[]string{".....", "..r..", ".rrr.", "..r..", "....."},
+			true, true,
+			[]string{".....", "..o..", ".ooo.", "..o..", "....."},
+		},
+		{
+			"inside of a solid block",
+			[]string{"....", ".rr.", ".rr.", "...."},
+			true, true,
+			[]string{"....", ".oo.", ".oo.", "...."},
+		},
+		{
+			// the outline only grows from the original artwork, not from
+			// the outline pixels it adds
+			"outside of two regions",
+			[]string{"r...r"},
+			false, false,
+			[]string{"ro.or"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pi := newTestImage(test.image...)
+			count := pi.AddOutline(0, 0, 0, test.eightConnected, test.inside)
+			actual := pixelRows(pi)
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %v, found %v", test.expected, actual)
+			}
+			if expected := strings.Count(strings.Join(test.expected, ""), "o"); count != expected {
+				t.Errorf("expected %d outline pixels, found %d", expected, count)
+			}
+			for _, p := range pi.pixels {
+				if p.outline && (p.r != 0 || p.g != 0 || p.b != 0 || p.a != 255 || p.covered) {
+					t.Errorf("expected outline pixel %d,%d to be opaque, black and uncovered, found %+v", p.x, p.y, *p)
+				}
+			}
+		})
+	}
+}
+
+// TestAddOutlineContentBounds checks that an outline outside of the artwork
+// grows the content box, but not beyond the edges of the image
+func TestAddOutlineContentBounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		image    []string
+		inside   bool
+		expected BoundingBox
+	}{
+		{"grows on every side", []string{".....", ".....", "..r..", ".....", "....."}, false, BoundingBox{1, 1, 3, 3}},
+		{"top left corner", []string{"r..", "...", "..."}, false, BoundingBox{0, 0, 2, 2}},
+		{"bottom right corner", []string{"...", "...", "..r"}, false, BoundingBox{1, 1, 2, 2}},
+		{"across the image", []string{"...", "rrr", "..."}, false, BoundingBox{0, 0, 3, 3}},
+		{"filling the image", []string{"rr", "rr"}, false, BoundingBox{0, 0, 2, 2}},
+		{"inside keeps the box", []string{".....", ".rrr.", ".rrr.", "....."}, true, BoundingBox{1, 1, 3, 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pi := newTestImage(test.image...)
+			pi.AddOutline(0, 0, 0, true, test.inside)
+			if pi.ContentBounds() != test.expected {
+				t.Errorf("expected content bounds %v, found %v", test.expected, pi.ContentBounds())
+			}
+		})
+	}
+}
+
+// TestOutlineGroup checks that the outline is written to a group of its own,
+// after the artwork so that it is drawn on top
+func TestOutlineGroup(t *testing.T) {
+	pi := newTestImage("...", ".rg", "...")
+	pi.SetBoundingBoxes(true)
+	pi.AddOutline(0, 0, 0, false, false)
+	pi.CoverAllPixels()
+	document := string(pi.Bytes())
+
+	// tinysvg writes the attributes of the artwork in no particular order
+	expected := `<g fill="#000000" data-outline="true" data-bbox="0 0 3 3">` +
+		`<rect x="1" width="1" height="1"/><rect x="2" width="1" height="1"/><rect y="1" width="1" height="1"/>` +
+		`<rect x="1" y="2" width="1" height="1"/><rect x="2" y="2" width="1" height="1"/></g></svg>`
+	if !strings.HasSuffix(document, expected) {
+		t.Errorf("expected the document to end with\n%s\nfound\n%s", expected, document)
+	}
+	if strings.Count(document, "<rect") != 7 || strings.Count(document, "<g") != 1 {
+		t.Errorf("expected the 2 artwork rects before the outline group, found\n%s", document)
+	}
+	if bounds := pi.GroupBounds()[outlineGroupKey]; bounds != (BoundingBox{0, 0, 3, 3}) {
+		t.Errorf("expected outline bounds 0 0 3 3, found %v", bounds)
+	}
+}
+
+func TestInsertOutlineGroup(t *testing.T) {
+	pi := newTestImage("r")
+	document := []byte(`<svg><g fill="red"><rect/></g></svg>`)
+	if actual := string(pi.insertOutlineGroup(document)); actual != string(document) {
+		t.Errorf("expected the document to be left alone without an outline, found %s", actual)
+	}
+
+	pi.outlineColor = "#000000"
+	pi.outlineBoxes = []BoundingBox{{0, 0, 2, 1}}
+	tests := []struct {
+		name     string
+		document string
+		expected string
+	}{
+		{
+			"before the end of the svg",
+			`<svg><g fill="red"><rect/></g></svg>`,
+			`<svg><g fill="red"><rect/></g><g fill="#000000" data-outline="true"><rect x="0" y="0" width="2" height="1"/></g></svg>`,
+		},
+		{
+			"before the last end of an svg",
+			`<svg><svg></svg></svg>`,
+			`<svg><svg></svg><g fill="#000000" data-outline="true"><rect x="0" y="0" width="2" height="1"/></g></svg>`,
+		},
+		{
+			"appended without an end",
+			`<svg>`,
+			`<svg><g fill="#000000" data-outline="true"><rect x="0" y="0" width="2" height="1"/></g>`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := string(pi.insertOutlineGroup([]byte(test.document))); actual != test.expected {
+				t.Errorf("expected %s, found %s", test.expected, actual)
+			}
+		})
+	}
+}
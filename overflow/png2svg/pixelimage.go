@@ -19,6 +19,7 @@ import (
 // Pixel represents a pixel at position (x,y)
 // with color (r,g,b,a)
 // and a bool for if this pixel has been covered by an SVG shape yet
+// and a bool for if this pixel belongs to the generated outline
 type Pixel struct {
 	x       int
 	y       int
@@ -27,16 +28,19 @@ type Pixel struct {
 	b       int
 	a       int
 	covered bool
+	outline bool
 }
 
 // Box represents a box with the following properties:
 // * position (x, y)
 // * size (w, h)
 // * color (r, g, b, a)
+// * if it covers outline pixels
 type Box struct {
 	x, y       int
 	w, h       int
 	r, g, b, a int
+	outline    bool
 }
 
 // Pixels is a slice of pointers to Pixel
@@ -47,6 +51,11 @@ type Pixels []*Pixel
 // an SVG document, starting with the document and root tag +
 // colorOptimize, for if only 4096 colors should be used
 // (short hex color strings, like #fff).
+// content is the bounding box of all non-transparent pixels, and groupBounds
+// the bounding box of every fill color, as it is written to the SVG.
+// If boundingBoxes is true, both are written to the SVG as data-bbox attributes.
+// outlineBoxes are the rectangles of the generated outline, if any, which is
+// written to a separate group of the outlineColor.
 type PixelImage struct {
 	pixels        Pixels
 	document      *tinysvg.Document
@@ -55,6 +64,11 @@ type PixelImage struct {
 	w             int
 	h             int
 	colorOptimize bool
+	content       BoundingBox
+	groupBounds   map[string]BoundingBox
+	boundingBoxes bool
+	outlineBoxes  []BoundingBox
+	outlineColor  string
 }
 
 // SetColorOptimize can be used to set the colorOptimize flag,
@@ -63,6 +77,35 @@ func (pi *PixelImage) SetColorOptimize(enabled bool) {
 	pi.colorOptimize = enabled
 }
 
+// SetBoundingBoxes can be used to set the boundingBoxes flag,
+// for writing the content and color group bounding boxes to the SVG.
+func (pi *PixelImage) SetBoundingBoxes(enabled bool) {
+	pi.boundingBoxes = enabled
+}
+
+// ContentBounds returns the bounding box of all non-transparent pixels.
+// The box is empty if the image is fully transparent.
+func (pi *PixelImage) ContentBounds() BoundingBox {
+	return pi.content
+}
+
+// GroupBounds returns the bounding box of every fill color covered so far,
+// keyed by the fill color as it is written to the <g> tags.
+// The bounding box of the generated outline, if any, is keyed by "outline".
+func (pi *PixelImage) GroupBounds() map[string]BoundingBox {
+	groupBounds := make(map[string]BoundingBox, len(pi.groupBounds))
+	for k, v := range pi.groupBounds {
+		groupBounds[k] = v
+	}
+	return groupBounds
+}
+
+// addGroupBounds grows the bounding box of the given fill color
+func (pi *PixelImage) addGroupBounds(colorString []byte, bb BoundingBox) {
+	key := string(lengthenBlack(colorString, pi.colorOptimize))
+	pi.groupBounds[key] = pi.groupBounds[key].Union(bb)
+}
+
 // ReadPNG tries to read the given PNG image filename and returns and image.Image
 // and an error. If verbose is true, some basic information is printed to stdout.
 func ReadPNG(filename string, verbose bool) (image.Image, error) {
@@ -123,7 +166,7 @@ func NewPixelImage(img image.Image, verbose bool) *PixelImage {
 			alpha := int(c.A)
 			// Mark transparent pixels as already being "covered"
 			covered := alpha == 0
-			pixels[i] = &Pixel{x, y, int(c.R), int(c.G), int(c.B), alpha, covered}
+			pixels[i] = &Pixel{x, y, int(c.R), int(c.G), int(c.B), alpha, covered, false}
 			i++
 		}
 	}
@@ -136,23 +179,69 @@ func NewPixelImage(img image.Image, verbose bool) *PixelImage {
 		fmt.Println("100%")
 	}
 
-	return &PixelImage{pixels, document, svgTag, verbose, width, height, false}
+	pi := &PixelImage{
+		pixels:        pixels,
+		document:      document,
+		svgTag:        svgTag,
+		verbose:       verbose,
+		w:             width,
+		h:             height,
+		colorOptimize: false,
+		groupBounds:   make(map[string]BoundingBox),
+	}
+	pi.updateContentBounds()
+	return pi
+}
+
+// updateContentBounds sets the content bounding box to cover all non-transparent pixels,
+// in pixel index coordinates
+func (pi *PixelImage) updateContentBounds() {
+	minX, minY, maxX, maxY := pi.w, pi.h, -1, -1
+	for i, p := range pi.pixels {
+		if p.a == 0 {
+			continue
+		}
+		x, y := i%pi.w, i/pi.w
+		minX, minY = minInt(minX, x), minInt(minY, y)
+		maxX, maxY = maxInt(maxX, x), maxInt(maxY, y)
+	}
+	if maxX < 0 {
+		pi.content = BoundingBox{}
+		return
+	}
+	pi.content = BoundingBox{minX, minY, maxX - minX + 1, maxY - minY + 1}
 }
 
 // Done checks if all pixels are covered, in terms of being represented by an SVG element
 // searches from the given x and y coordinate
 func (pi *PixelImage) Done(startx, starty int) bool {
-	for y := starty; y < pi.h; y++ {
-		for x := startx; x < pi.w; x++ {
+	_, _, found := pi.nextUncovered(startx, starty)
+	return !found
+}
+
+// nextUncovered searches row-wise, downwards, from (startx,starty) for a pixel that
+// is not covered yet. Transparent pixels are covered from the start, so only the
+// content bounding box needs to be searched.
+func (pi *PixelImage) nextUncovered(startx, starty int) (int, int, bool) {
+	left, right := pi.content.X, pi.content.X+pi.content.W
+	bottom := pi.content.Y + pi.content.H
+	if starty < pi.content.Y {
+		startx, starty = left, pi.content.Y
+	}
+	for y := starty; y < bottom; y++ {
+		if startx < left {
+			startx = left
+		}
+		for x := startx; x < right; x++ {
 			i := y*pi.w + x
 			if !pi.pixels[i].covered {
-				return false
+				return x, y, true
 			}
 		}
 		// Start at the beginning of the line when searching the rest of the lines
-		startx = 0
+		startx = left
 	}
-	return true
+	return 0, 0, false
 }
 
 // At returns the RGB color at the given coordinate
@@ -186,9 +275,13 @@ func (pi *PixelImage) Covered(x, y int) bool {
 // , by creating a rectangle per pixel.
 func (pi *PixelImage) CoverAllPixels() {
 	coverCount := 0
-	for _, p := range pi.pixels {
-		if !(*p).covered {
+	for i, p := range pi.pixels {
+		if !(*p).covered && (*p).outline {
+			pi.coverOutlineBox(&Box{i % pi.w, i / pi.w, 1, 1, (*p).r, (*p).g, (*p).b, (*p).a, true})
+			coverCount++
+		} else if !(*p).covered {
 			pi.svgTag.Pixel((*p).x, (*p).y, (*p).r, (*p).g, (*p).b)
+			pi.addGroupBounds(tinysvg.ColorBytes((*p).r, (*p).g, (*p).b), BoundingBox{i % pi.w, i / pi.w, 1, 1})
 			(*p).covered = true
 			coverCount++
 		}
@@ -201,18 +294,12 @@ func (pi *PixelImage) CoverAllPixels() {
 // FirstUncovered will find the first pixel that is not covered by an SVG element,
 // starting from (startx,starty), searching row-wise, downwards.
 func (pi *PixelImage) FirstUncovered(startx, starty int) (int, int) {
-	for y := starty; y < pi.h; y++ {
-		for x := startx; x < pi.w; x++ {
-			i := y*pi.w + x
-			if !pi.pixels[i].covered {
-				return x, y
-			}
-		}
-		// Start at the beginning of the line when searching the rest of the lines
-		startx = 0
+	x, y, found := pi.nextUncovered(startx, starty)
+	if !found {
+		// This should never happen, except when debugging
+		panic("All pixels are covered")
 	}
-	// This should never happen, except when debugging
-	panic("All pixels are covered")
+	return x, y
 }
 
 // TODO: investigate reason behind original package shortening colors, and why
@@ -250,7 +337,8 @@ func colorFromLine(line []byte, colorOptimize bool) ([]byte, []byte, bool) {
 // This is not the prettiest function, but it works.
 // TODO: Rewrite, to make it prettier
 // TODO: Benchmark
-func groupLinesByFillColor(lines [][]byte, colorOptimize bool) [][]byte {
+// If groupBounds is not nil, the bounding box of each group is added to its <g> tag.
+func groupLinesByFillColor(lines [][]byte, colorOptimize bool, groupBounds map[string]BoundingBox) [][]byte {
 	// Group lines by fill color
 	var (
 		groupedLines                  = make(map[string][][]byte)
@@ -290,7 +378,11 @@ func groupLinesByFillColor(lines [][]byte, colorOptimize bool) [][]byte {
 			buf.Write([]byte("<g fill=\""))
 			//fmt.Printf("WRITING KEY %s\n", key)
 			buf.WriteString(key)
-			buf.Write([]byte("\">"))
+			buf.Write([]byte("\""))
+			if bb, ok := groupBounds[key]; ok {
+				buf.WriteString(" " + BoundingBoxAttribute + "=\"" + bb.String() + "\"")
+			}
+			buf.Write([]byte(">"))
 			for _, line := range lines {
 				from = append([]byte(" fill=\""), key...)
 				buf.Write(bytes.Replace(line, append(from, '"'), []byte{}, 1))
@@ -317,6 +409,13 @@ func (pi *PixelImage) Bytes() []byte {
 		fmt.Print("Rendering SVG...")
 	}
 
+	// Record the content bounding box on the <svg> tag
+	var groupBounds map[string]BoundingBox
+	if pi.boundingBoxes {
+		pi.svgTag.AddAttrib(BoundingBoxAttribute, []byte(pi.content.String()))
+		groupBounds = pi.groupBounds
+	}
+
 	// Render the SVG document
 	// TODO: pi.document.WriteTo also exists, and might be faster
 	svgDocument := pi.document.Bytes()
@@ -330,7 +429,7 @@ func (pi *PixelImage) Bytes() []byte {
 
 	// Group lines by fill color, insert <g> tags
 	lines := bytes.Split(svgDocument, []byte(">"))
-	lines = groupLinesByFillColor(lines, pi.colorOptimize)
+	lines = groupLinesByFillColor(lines, pi.colorOptimize, groupBounds)
 
 	for i, line := range lines {
 		if len(line) > 0 && !bytes.HasSuffix(line, []byte(">")) {
@@ -340,6 +439,9 @@ func (pi *PixelImage) Bytes() []byte {
 	// Use the line contents as the new svgDocument
 	svgDocument = bytes.Join(lines, []byte{})
 
+	// Add the generated outline, if any, as a group of its own
+	svgDocument = pi.insertOutlineGroup(svgDocument)
+
 	if pi.verbose {
 		fmt.Println("ok")
 		fmt.Print("Additional optimizations...")
@@ -466,7 +568,7 @@ func (pi *PixelImage) CreateRandomBox(checkIfPossible bool) *Box {
 	}
 	// Create a box at that placement, with width 1 and height 1
 	// Return the box
-	return &Box{x, y, w, h, r, g, b, a}
+	return &Box{x, y, w, h, r, g, b, a, false}
 }
 
 // CreateBox creates a 1x1 box at the given location, if it's not already covered
@@ -476,9 +578,17 @@ func (pi *PixelImage) CreateBox(x, y int) *Box {
 	}
 	w, h := 1, 1
 	r, g, b, a := pi.At2(x, y)
+	outline := pi.pixels[y*pi.w+x].outline
 	// Create a box at that placement, with width 1 and height 1
 	// Return the box
-	return &Box{x, y, w, h, r, g, b, a}
+	return &Box{x, y, w, h, r, g, b, a, outline}
+}
+
+// matchesBox returns true if the pixel at the given coordinate has the same color as the box,
+// and belongs to the same layer, so that the box can be expanded to cover it
+func (pi *PixelImage) matchesBox(bo *Box, x, y int) bool {
+	p := pi.pixels[y*pi.w+x]
+	return p.r == bo.r && p.g == bo.g && p.b == bo.b && p.a == bo.a && p.outline == bo.outline
 }
 
 // ExpandLeft will expand a box 1 pixel to the left,
@@ -490,8 +600,7 @@ func (pi *PixelImage) ExpandLeft(bo *Box) bool {
 		return false
 	}
 	for y := bo.y; y < (bo.y + bo.h); y++ {
-		r, g, b, a := pi.At2(x, y)
-		if (r != bo.r) || (g != bo.g) || (b != bo.b) || (a != bo.a) {
+		if !pi.matchesBox(bo, x, y) {
 			return false
 		}
 	}
@@ -510,8 +619,7 @@ func (pi *PixelImage) ExpandUp(bo *Box) bool {
 		return false
 	}
 	for x := bo.x; x < (bo.x + bo.w); x++ {
-		r, g, b, a := pi.At2(x, y)
-		if (r != bo.r) || (g != bo.g) || (b != bo.b) || (a != bo.a) {
+		if !pi.matchesBox(bo, x, y) {
 			return false
 		}
 	}
@@ -530,8 +638,7 @@ func (pi *PixelImage) ExpandRight(bo *Box) bool {
 		return false
 	}
 	for y := bo.y; y < (bo.y + bo.h); y++ {
-		r, g, b, a := pi.At2(x, y)
-		if (r != bo.r) || (g != bo.g) || (b != bo.b) || (a != bo.a) {
+		if !pi.matchesBox(bo, x, y) {
 			return false
 		}
 	}
@@ -549,8 +656,7 @@ func (pi *PixelImage) ExpandDown(bo *Box) bool {
 		return false
 	}
 	for x := bo.x; x < (bo.x + bo.w); x++ {
-		r, g, b, a := pi.At2(x, y)
-		if (r != bo.r) || (g != bo.g) || (b != bo.b) || (a != bo.a) {
+		if !pi.matchesBox(bo, x, y) {
 			return false
 		}
 	}
@@ -598,6 +704,12 @@ func shortColorString(r, g, b int) string {
 // if pink is true, the rectangles will be pink
 // if optimizeColors is true, the color strings will be shortened (and quantized)
 func (pi *PixelImage) CoverBox(bo *Box, pink bool, optimizeColors bool) {
+	// Outline rectangles are kept apart, and written to their own group
+	if bo.outline {
+		pi.coverOutlineBox(bo)
+		return
+	}
+
 	// Draw the rectangle
 	rect := pi.svgTag.AddRect(bo.x, bo.y, bo.w, bo.h)
 
@@ -617,6 +729,7 @@ func (pi *PixelImage) CoverBox(bo *Box, pink bool, optimizeColors bool) {
 
 	// Set the fill color
 	rect.Fill(colorString)
+	pi.addGroupBounds([]byte(colorString), boxBounds(bo))
 
 	// Mark all covered pixels in the PixelImage
 	for y := bo.y; y < (bo.y + bo.h); y++ {
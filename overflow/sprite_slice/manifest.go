@@ -0,0 +1,208 @@
+package sprite_slice
+
+import (
+	"errors"
+	"floasis-items/flow/overflow/convert"
+	"floasis-items/flow/overflow/manifest"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/onflow/cadence"
+)
+
+// Placeholders are the values written into the manifest rows of sliced
+// cells. Everything but the names is expected to be edited by hand before
+// the series is uploaded.
+type Placeholders struct {
+	PlanetName  string
+	Description string
+	Quantity    uint64
+	Price       cadence.UFix64
+	ArtistName  string
+	SeriesName  string
+	Category    string
+}
+
+// DefaultPlaceholders returns placeholders that still pass manifest
+// validation and the categories of the accessories art repo, so that a
+// sliced series can be dry-run as is
+func DefaultPlaceholders() Placeholders {
+	return Placeholders{
+		PlanetName:  "TODO planet",
+		Description: "TODO description",
+		Quantity:    0,
+		Price:       0,
+		ArtistName:  "floasis-items-official",
+		SeriesName:  "series0",
+		Category:    "hats",
+	}
+}
+
+// artListEntry is the art_list.csv entry of a sliced cell.
+// A single cell is used as the base, card and thumbnail art.
+func artListEntry(name string, placeholders Placeholders) manifest.ArtEntry {
+	return manifest.ArtEntry{
+		Name:        name,
+		PlanetName:  placeholders.PlanetName,
+		BaseArt:     name,
+		CardArt:     name,
+		Description: placeholders.Description,
+		Thumbnail:   name,
+	}
+}
+
+// inventoryListEntry is the store_inventory_list.csv entry of a sliced cell
+func inventoryListEntry(name string, placeholders Placeholders) manifest.InventoryEntry {
+	return manifest.InventoryEntry{
+		Name:        name,
+		Description: placeholders.Description,
+		Thumbnail:   name,
+		Quantity:    placeholders.Quantity,
+		Price:       placeholders.Price,
+		ArtistName:  placeholders.ArtistName,
+		SeriesName:  placeholders.SeriesName,
+		ArtName:     name,
+		Category:    placeholders.Category,
+	}
+}
+
+// checkCategory checks that the category placeholder is in the taxonomy of
+// the art repo, if it has a categories.csv
+func checkCategory(artRepoPath string, category string) error {
+	path := filepath.Join(artRepoPath, manifest.CategoriesFileName)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	taxonomy, err := manifest.LoadTaxonomy(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := taxonomy.Category(category); !ok {
+		return fmt.Errorf("category placeholder %q is not a category of %s", category, path)
+	}
+	return nil
+}
+
+// unlisted returns the names the manifest at path does not list yet, so that
+// slicing a sheet again does not append its cells twice
+func unlisted[Entry any](path string, names []string, load func(path string) ([]Entry, error), name func(entry Entry) string) ([]string, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return names, nil
+	}
+	entries, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	listed := map[string]bool{}
+	for _, entry := range entries {
+		listed[name(entry)] = true
+	}
+	missing := []string{}
+	for _, n := range names {
+		if listed[n] {
+			fmt.Printf("%s already lists %s, it is not appended again\n", path, n)
+			continue
+		}
+		missing = append(missing, n)
+	}
+	return missing, nil
+}
+
+// Options controls what SliceToArtRepo does with the sliced cells
+type Options struct {
+	ConvertToSVG bool
+	// Conversion holds the options cells are converted with, the defaults if nil
+	Conversion        *convert.Config
+	AppendArtList     bool
+	AppendInventory   bool
+	ArtListFileName   string
+	InventoryFileName string
+	Placeholders      Placeholders
+}
+
+// DefaultOptions converts every cell and appends it to both manifests
+func DefaultOptions() Options {
+	return Options{
+		ConvertToSVG:      true,
+		AppendArtList:     true,
+		AppendInventory:   true,
+		ArtListFileName:   "art_list.csv",
+		InventoryFileName: "store_inventory_list.csv",
+		Placeholders:      DefaultPlaceholders(),
+	}
+}
+
+// SliceToArtRepo slices the sheet into artRepoPath/png, optionally pushes
+// each cell through png2svg into artRepoPath/svg and appends a placeholder
+// row per cell to the art and inventory manifests, in the column order of
+// their header rows. Cells the manifests already list are not appended again.
+func SliceToArtRepo(sheet_path string, cells []Cell, artRepoPath string, options Options) ([]string, error) {
+	if options.AppendInventory {
+		if err := checkCategory(artRepoPath, options.Placeholders.Category); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := ReadSheet(sheet_path)
+	if err != nil {
+		return nil, err
+	}
+
+	png_dir_path := filepath.Join(artRepoPath, "png")
+	svg_dir_path := filepath.Join(artRepoPath, "svg")
+
+	names, err := SliceSheet(sheet, cells, png_dir_path)
+	if err != nil {
+		return names, err
+	}
+
+	if options.ConvertToSVG {
+		conversion := options.Conversion
+		if conversion == nil {
+			conversion = convert.DefaultConfig()
+		}
+		if err := os.MkdirAll(svg_dir_path, 0755); err != nil {
+			return names, err
+		}
+		for _, name := range names {
+			png_file_path := filepath.Join(png_dir_path, name+".png")
+			svg_file_path := filepath.Join(svg_dir_path, name+".svg")
+			if err := convert.ConvertPNGtoSVGWithConfig(png_file_path, svg_file_path, conversion); err != nil {
+				return names, fmt.Errorf("converting %s: %w", png_file_path, err)
+			}
+		}
+	}
+
+	if options.AppendArtList {
+		art_list_path := filepath.Join(artRepoPath, options.ArtListFileName)
+		missing, err := unlisted(art_list_path, names, manifest.LoadArtEntries, func(entry manifest.ArtEntry) string { return entry.Name })
+		if err != nil {
+			return names, err
+		}
+		entries := []manifest.ArtEntry{}
+		for _, name := range missing {
+			entries = append(entries, artListEntry(name, options.Placeholders))
+		}
+		if err := manifest.AppendArtEntries(art_list_path, entries); err != nil {
+			return names, err
+		}
+	}
+
+	if options.AppendInventory {
+		inventory_path := filepath.Join(artRepoPath, options.InventoryFileName)
+		missing, err := unlisted(inventory_path, names, manifest.LoadInventoryEntries, func(entry manifest.InventoryEntry) string { return entry.Name })
+		if err != nil {
+			return names, err
+		}
+		entries := []manifest.InventoryEntry{}
+		for _, name := range missing {
+			entries = append(entries, inventoryListEntry(name, options.Placeholders))
+		}
+		if err := manifest.AppendInventoryEntries(inventory_path, entries); err != nil {
+			return names, err
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,89 @@
+package sprite_slice
+
+import (
+	"floasis-items/flow/overflow/manifest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestRepo writes the test sheet and a categories.csv into a new art repo
+func newTestRepo(t *testing.T) (string, string) {
+	t.Helper()
+	artRepoPath := t.TempDir()
+	sheetPath := filepath.Join(artRepoPath, "hats.png")
+	if err := WritePNG(newTestSheet(), sheetPath); err != nil {
+		t.Fatal(err)
+	}
+	categories := "name,z_order,exclusive\ntorso + base,10,torso\nhats,20,headwear\n"
+	if err := os.WriteFile(filepath.Join(artRepoPath, manifest.CategoriesFileName), []byte(categories), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return artRepoPath, sheetPath
+}
+
+func testOptions() Options {
+	options := DefaultOptions()
+	options.ConvertToSVG = false
+	return options
+}
+
+func TestSliceToArtRepoAppendsOnce(t *testing.T) {
+	artRepoPath, sheetPath := newTestRepo(t)
+	cells := []Cell{{Name: "hat-a", X: 0, Y: 0, W: 4, H: 4}}
+	if _, err := SliceToArtRepo(sheetPath, cells, artRepoPath, testOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	// slicing again with a new cell appends the new cell only
+	cells = append(cells, Cell{Name: "hat-b", X: 4, Y: 0, W: 2, H: 2})
+	if _, err := SliceToArtRepo(sheetPath, cells, artRepoPath, testOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	art, err := manifest.LoadArtEntries(filepath.Join(artRepoPath, "art_list.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inventory, err := manifest.LoadInventoryEntries(filepath.Join(artRepoPath, "store_inventory_list.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	artNames := []string{}
+	for _, entry := range art {
+		artNames = append(artNames, entry.Name)
+	}
+	inventoryNames := []string{}
+	for _, entry := range inventory {
+		inventoryNames = append(inventoryNames, entry.Name)
+	}
+	expected := []string{"hat-a", "hat-b"}
+	if !reflect.DeepEqual(artNames, expected) || !reflect.DeepEqual(inventoryNames, expected) {
+		t.Errorf("expected %v in both manifests, found %v and %v", expected, artNames, inventoryNames)
+	}
+
+	// the default placeholders pass the taxonomy of the art repo
+	taxonomy, err := manifest.LoadTaxonomy(filepath.Join(artRepoPath, manifest.CategoriesFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := taxonomy.CheckInventory(inventory); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSliceToArtRepoUnknownCategory(t *testing.T) {
+	artRepoPath, sheetPath := newTestRepo(t)
+	options := testOptions()
+	options.Placeholders.Category = "TODO category"
+	cells := []Cell{{Name: "hat-a", X: 0, Y: 0, W: 4, H: 4}}
+	_, err := SliceToArtRepo(sheetPath, cells, artRepoPath, options)
+	if err == nil || !strings.Contains(err.Error(), `category placeholder "TODO category" is not a category of`) {
+		t.Errorf("expected an unknown category error, found %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(artRepoPath, "png")); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be sliced, found %v", err)
+	}
+}
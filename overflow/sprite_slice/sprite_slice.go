@@ -0,0 +1,307 @@
+/*
+Slices a sprite sheet into individual accessory PNGs, so that an artist can
+deliver a whole series on a single sheet. Cells are described either by a
+regular grid or by a JSON atlas in the TexturePacker/Aseprite style.
+*/
+
+package sprite_slice
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Cell is a named rectangle on a sprite sheet.
+// Atlases may trim the transparent border off a frame, in which case the
+// cell is drawn at (OffsetX, OffsetY) on an image of SourceW x SourceH, the
+// size of the frame before trimming. SourceW and SourceH are zero for cells
+// that were not trimmed.
+type Cell struct {
+	Name    string
+	X       int
+	Y       int
+	W       int
+	H       int
+	OffsetX int
+	OffsetY int
+	SourceW int
+	SourceH int
+}
+
+// Rectangle returns the cell as an image.Rectangle
+func (cell Cell) Rectangle() image.Rectangle {
+	return image.Rect(cell.X, cell.Y, cell.X+cell.W, cell.Y+cell.H)
+}
+
+// Trimmed reports whether the cell was trimmed out of a larger frame
+func (cell Cell) Trimmed() bool {
+	return cell.SourceW > 0 && cell.SourceH > 0
+}
+
+// GridSpec describes a sheet made of equally sized cells.
+// Margin is the space around the whole grid and Spacing the space between
+// neighbouring cells, both in pixels.
+// Cells are numbered row-wise from the top left. When Names is set, the
+// n-th cell takes the n-th name and cells without a name are skipped;
+// otherwise cells are named NamePrefix-<n>.
+type GridSpec struct {
+	CellWidth  int
+	CellHeight int
+	Margin     int
+	Spacing    int
+	Names      []string
+	NamePrefix string
+}
+
+// Cells lays the grid out over a sheet of the given size
+func (spec GridSpec) Cells(sheetWidth int, sheetHeight int) ([]Cell, error) {
+	if spec.CellWidth <= 0 || spec.CellHeight <= 0 {
+		return nil, errors.New("grid cell width and height must be positive")
+	}
+
+	columns := (sheetWidth - 2*spec.Margin + spec.Spacing) / (spec.CellWidth + spec.Spacing)
+	rows := (sheetHeight - 2*spec.Margin + spec.Spacing) / (spec.CellHeight + spec.Spacing)
+	if columns <= 0 || rows <= 0 {
+		return nil, fmt.Errorf("a %dx%d grid does not fit on a %dx%d sheet", spec.CellWidth, spec.CellHeight, sheetWidth, sheetHeight)
+	}
+
+	prefix := spec.NamePrefix
+	if prefix == "" {
+		prefix = "cell"
+	}
+
+	cells := []Cell{}
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			index := row*columns + column
+			var name string
+			if spec.Names != nil {
+				if index >= len(spec.Names) || spec.Names[index] == "" {
+					continue
+				}
+				name = spec.Names[index]
+			} else {
+				name = fmt.Sprintf("%s-%d", prefix, index)
+			}
+			cells = append(cells, Cell{
+				Name: name,
+				X:    spec.Margin + column*(spec.CellWidth+spec.Spacing),
+				Y:    spec.Margin + row*(spec.CellHeight+spec.Spacing),
+				W:    spec.CellWidth,
+				H:    spec.CellHeight,
+			})
+		}
+	}
+	return cells, nil
+}
+
+// atlasFrame is a single frame entry in a TexturePacker/Aseprite JSON atlas
+type atlasFrame struct {
+	Filename string `json:"filename"`
+	Frame    struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+		W int `json:"w"`
+		H int `json:"h"`
+	} `json:"frame"`
+	Rotated          bool `json:"rotated"`
+	Trimmed          bool `json:"trimmed"`
+	SpriteSourceSize struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	} `json:"spriteSourceSize"`
+	SourceSize struct {
+		W int `json:"w"`
+		H int `json:"h"`
+	} `json:"sourceSize"`
+}
+
+// ReadAtlas reads the cells from a JSON atlas. Both the "hash" layout
+// (frames keyed by file name) and the "array" layout (a list of frames with
+// a filename field) are supported. File extensions are dropped from the cell
+// names, since the slicer adds its own. Trimmed frames keep their offset
+// into the untrimmed frame, from spriteSourceSize and sourceSize.
+func ReadAtlas(atlasPath string) ([]Cell, error) {
+	atlasData, err := os.ReadFile(atlasPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var atlas struct {
+		Frames json.RawMessage `json:"frames"`
+	}
+	if err := json.Unmarshal(atlasData, &atlas); err != nil {
+		return nil, fmt.Errorf("%s: %w", atlasPath, err)
+	}
+
+	frames := []atlasFrame{}
+	trimmed := strings.TrimSpace(string(atlas.Frames))
+	switch {
+	case strings.HasPrefix(trimmed, "["):
+		if err := json.Unmarshal(atlas.Frames, &frames); err != nil {
+			return nil, fmt.Errorf("%s: %w", atlasPath, err)
+		}
+	case strings.HasPrefix(trimmed, "{"):
+		framesByName := map[string]atlasFrame{}
+		if err := json.Unmarshal(atlas.Frames, &framesByName); err != nil {
+			return nil, fmt.Errorf("%s: %w", atlasPath, err)
+		}
+		names := make([]string, 0, len(framesByName))
+		for name := range framesByName {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			frame := framesByName[name]
+			frame.Filename = name
+			frames = append(frames, frame)
+		}
+	default:
+		return nil, fmt.Errorf("%s: no frames found", atlasPath)
+	}
+
+	cells := make([]Cell, 0, len(frames))
+	for _, frame := range frames {
+		if frame.Rotated {
+			return nil, fmt.Errorf("%s: frame %q is rotated, export the atlas without rotation", atlasPath, frame.Filename)
+		}
+		name := strings.TrimSuffix(frame.Filename, filepath.Ext(frame.Filename))
+		if name == "" {
+			return nil, fmt.Errorf("%s: frame without a name", atlasPath)
+		}
+		if err := checkName(name); err != nil {
+			return nil, fmt.Errorf("%s: frame %q: %w", atlasPath, frame.Filename, err)
+		}
+		cell := Cell{
+			Name: name,
+			X:    frame.Frame.X,
+			Y:    frame.Frame.Y,
+			W:    frame.Frame.W,
+			H:    frame.Frame.H,
+		}
+		if frame.Trimmed {
+			cell.OffsetX = frame.SpriteSourceSize.X
+			cell.OffsetY = frame.SpriteSourceSize.Y
+			cell.SourceW = frame.SourceSize.W
+			cell.SourceH = frame.SourceSize.H
+			offset := image.Rect(cell.OffsetX, cell.OffsetY, cell.OffsetX+cell.W, cell.OffsetY+cell.H)
+			if !cell.Trimmed() || !offset.In(image.Rect(0, 0, cell.SourceW, cell.SourceH)) {
+				return nil, fmt.Errorf("%s: trimmed frame %q does not fit in its source size", atlasPath, frame.Filename)
+			}
+		}
+		cells = append(cells, cell)
+	}
+	return cells, nil
+}
+
+// checkName refuses cell names that are not a plain file name, since the
+// cells are written to <name>.png and <name>.svg in the art repo
+func checkName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("cell name %q must be a file name without path separators", name)
+	}
+	return nil
+}
+
+// ReadSheet decodes the sprite sheet PNG at sheetPath
+func ReadSheet(sheetPath string) (image.Image, error) {
+	f, err := os.Open(sheetPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+// isTransparent reports whether every pixel of the image is fully transparent
+func isTransparent(img image.Image) bool {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// CutCell copies a single cell out of the sheet into a new image whose
+// bounds start at (0, 0). Trimmed cells are restored to their source size,
+// with the trimmed border left transparent.
+func CutCell(sheet image.Image, cell Cell) (*image.NRGBA, error) {
+	rect := cell.Rectangle()
+	if rect.Empty() || !rect.In(sheet.Bounds()) {
+		return nil, fmt.Errorf("cell %q %v is outside of the sheet %v", cell.Name, rect, sheet.Bounds())
+	}
+	if !cell.Trimmed() {
+		cellImage := image.NewNRGBA(image.Rect(0, 0, cell.W, cell.H))
+		draw.Draw(cellImage, cellImage.Bounds(), sheet, rect.Min, draw.Src)
+		return cellImage, nil
+	}
+	cellImage := image.NewNRGBA(image.Rect(0, 0, cell.SourceW, cell.SourceH))
+	offset := image.Rect(cell.OffsetX, cell.OffsetY, cell.OffsetX+cell.W, cell.OffsetY+cell.H)
+	draw.Draw(cellImage, offset, sheet, rect.Min, draw.Src)
+	return cellImage, nil
+}
+
+// WritePNG encodes the image to a PNG file at path
+func WritePNG(img image.Image, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// SliceSheet writes each cell of the sheet to pngDirPath/<name>.png and
+// returns the names of the cells that were written. pngDirPath is created if
+// needed. Fully transparent cells, such as the unused tail of a grid, are
+// skipped.
+func SliceSheet(sheet image.Image, cells []Cell, pngDirPath string) ([]string, error) {
+	seen := map[string]bool{}
+	for _, cell := range cells {
+		if err := checkName(cell.Name); err != nil {
+			return nil, err
+		}
+		if seen[cell.Name] {
+			return nil, fmt.Errorf("cell name %q is used more than once", cell.Name)
+		}
+		seen[cell.Name] = true
+	}
+
+	if err := os.MkdirAll(pngDirPath, 0755); err != nil {
+		return nil, err
+	}
+
+	written := []string{}
+	for _, cell := range cells {
+		cellImage, err := CutCell(sheet, cell)
+		if err != nil {
+			return written, err
+		}
+		if isTransparent(cellImage) {
+			fmt.Println("cell is empty and will not be written:", cell.Name)
+			continue
+		}
+		png_file_path := filepath.Join(pngDirPath, cell.Name+".png")
+		if err := WritePNG(cellImage, png_file_path); err != nil {
+			return written, err
+		}
+		fmt.Println("cell written to png path:", png_file_path)
+		written = append(written, cell.Name)
+	}
+	return written, nil
+}
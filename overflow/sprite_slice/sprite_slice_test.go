@@ -0,0 +1,148 @@
+package sprite_slice
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var (
+	red  = color.NRGBA{R: 255, A: 255}
+	blue = color.NRGBA{B: 255, A: 255}
+)
+
+// newTestSheet returns an 8x4 sheet with a 4x4 red square on the left and a
+// 2x2 blue square, trimmed out of a 4x4 frame, at (4, 0)
+func newTestSheet() *image.NRGBA {
+	sheet := image.NewNRGBA(image.Rect(0, 0, 8, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			sheet.SetNRGBA(x, y, red)
+		}
+	}
+	for y := 0; y < 2; y++ {
+		for x := 4; x < 6; x++ {
+			sheet.SetNRGBA(x, y, blue)
+		}
+	}
+	return sheet
+}
+
+const testAtlas = `{
+	"frames": {
+		"hat-a.png": {
+			"frame": {"x": 0, "y": 0, "w": 4, "h": 4},
+			"rotated": false,
+			"trimmed": false,
+			"spriteSourceSize": {"x": 0, "y": 0, "w": 4, "h": 4},
+			"sourceSize": {"w": 4, "h": 4}
+		},
+		"hat-b.png": {
+			"frame": {"x": 4, "y": 0, "w": 2, "h": 2},
+			"rotated": false,
+			"trimmed": true,
+			"spriteSourceSize": {"x": 1, "y": 2, "w": 2, "h": 2},
+			"sourceSize": {"w": 4, "h": 4}
+		}
+	}
+}`
+
+func TestReadAtlas(t *testing.T) {
+	atlasPath := filepath.Join(t.TempDir(), "hats.json")
+	if err := os.WriteFile(atlasPath, []byte(testAtlas), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cells, err := ReadAtlas(atlasPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Cell{
+		{Name: "hat-a", X: 0, Y: 0, W: 4, H: 4},
+		{Name: "hat-b", X: 4, Y: 0, W: 2, H: 2, OffsetX: 1, OffsetY: 2, SourceW: 4, SourceH: 4},
+	}
+	if !reflect.DeepEqual(cells, expected) {
+		t.Errorf("expected cells %+v, found %+v", expected, cells)
+	}
+}
+
+func TestReadAtlasRefusesPaths(t *testing.T) {
+	for _, filename := range []string{"hats/hat-a.png", `hats\hat-a.png`, "../hat-a.png", "...png"} {
+		t.Run(filename, func(t *testing.T) {
+			atlasPath := filepath.Join(t.TempDir(), "hats.json")
+			atlas := `{"frames": [{"filename": ` + strconv.Quote(filename) + `, "frame": {"x": 0, "y": 0, "w": 4, "h": 4}}]}`
+			if err := os.WriteFile(atlasPath, []byte(atlas), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := ReadAtlas(atlasPath)
+			if err == nil || !strings.Contains(err.Error(), "must be a file name without path separators") {
+				t.Errorf("expected frame %q to be refused, found %v", filename, err)
+			}
+		})
+	}
+
+	// grid names are checked before anything is written
+	pngDirPath := filepath.Join(t.TempDir(), "png")
+	if _, err := SliceSheet(newTestSheet(), []Cell{{Name: "../hat-a", W: 4, H: 4}}, pngDirPath); err == nil {
+		t.Error("expected cell ../hat-a to be refused")
+	}
+	if _, err := os.Stat(pngDirPath); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be written, found %v", err)
+	}
+}
+
+func TestSliceSheet(t *testing.T) {
+	cells := []Cell{
+		{Name: "hat-a", X: 0, Y: 0, W: 4, H: 4},
+		{Name: "hat-b", X: 4, Y: 0, W: 2, H: 2, OffsetX: 1, OffsetY: 2, SourceW: 4, SourceH: 4},
+		{Name: "empty", X: 6, Y: 0, W: 2, H: 4},
+	}
+	// the png folder of a new art repo does not exist yet
+	pngDirPath := filepath.Join(t.TempDir(), "art", "png")
+	names, err := SliceSheet(newTestSheet(), cells, pngDirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"hat-a", "hat-b"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v to be written, found %v", expected, names)
+	}
+
+	tests := []struct {
+		name string
+		// the color of each pixel, row-wise, "r" for red, "b" for blue and
+		// "." for transparent
+		pixels []string
+	}{
+		{"hat-a", []string{"rrrr", "rrrr", "rrrr", "rrrr"}},
+		{"hat-b", []string{"....", "....", ".bb.", ".bb."}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img, err := ReadSheet(filepath.Join(pngDirPath, test.name+".png"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size := img.Bounds().Size(); size != image.Pt(4, 4) {
+				t.Fatalf("expected a 4x4 image, found %v", size)
+			}
+			for y, row := range test.pixels {
+				for x, pixel := range row {
+					expected := color.NRGBA{}
+					switch pixel {
+					case 'r':
+						expected = red
+					case 'b':
+						expected = blue
+					}
+					if actual := color.NRGBAModel.Convert(img.At(x, y)); actual != expected {
+						t.Errorf("pixel (%d, %d): expected %v, found %v", x, y, expected, actual)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,567 @@
+package svg_normalize
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// curveSegments is the number of line segments a curve or a full ellipse is
+// flattened into before it is rasterized
+const curveSegments = 64
+
+// epsilon absorbs floating point noise when comparing coordinates
+const epsilon = 1e-6
+
+type point struct {
+	x, y float64
+}
+
+// matrix is an SVG transform matrix(a b c d e f)
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// multiply returns m followed by n, as in transform="m n"
+func (m matrix) multiply(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+func (m matrix) apply(p point) point {
+	return point{m[0]*p.x + m[2]*p.y + m[4], m[1]*p.x + m[3]*p.y + m[5]}
+}
+
+func (m matrix) isIdentity() bool {
+	return m == identity
+}
+
+var (
+	numberPattern    = regexp.MustCompile(`[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?`)
+	transformPattern = regexp.MustCompile(`(matrix|translate|scale|rotate|skewX|skewY)\s*\(([^)]*)\)`)
+)
+
+func parseNumbers(s string) ([]float64, error) {
+	numbers := []float64{}
+	for _, match := range numberPattern.FindAllString(s, -1) {
+		n, err := strconv.ParseFloat(match, 64)
+		if err != nil {
+			return nil, err
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
+
+// parseTransform parses the value of a transform attribute
+func parseTransform(value string) (matrix, error) {
+	m := identity
+	rest := strings.TrimSpace(transformPattern.ReplaceAllString(value, ""))
+	if strings.Trim(rest, " ,\t\n") != "" {
+		return m, fmt.Errorf("transform %q is not supported", value)
+	}
+	for _, match := range transformPattern.FindAllStringSubmatch(value, -1) {
+		args, err := parseNumbers(match[2])
+		if err != nil {
+			return m, fmt.Errorf("transform %q: %w", value, err)
+		}
+		arg := func(i int, fallback float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return fallback
+		}
+
+		var t matrix
+		switch match[1] {
+		case "matrix":
+			if len(args) != 6 {
+				return m, fmt.Errorf("transform %q: matrix needs 6 numbers", value)
+			}
+			copy(t[:], args)
+		case "translate":
+			t = matrix{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			t = matrix{arg(0, 1), 0, 0, arg(1, arg(0, 1)), 0, 0}
+		case "rotate":
+			angle := arg(0, 0) * math.Pi / 180
+			cx, cy := arg(1, 0), arg(2, 0)
+			sin, cos := math.Sincos(angle)
+			t = matrix{1, 0, 0, 1, cx, cy}.
+				multiply(matrix{cos, sin, -sin, cos, 0, 0}).
+				multiply(matrix{1, 0, 0, 1, -cx, -cy})
+		case "skewX":
+			t = matrix{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			t = matrix{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		}
+		m = m.multiply(t)
+	}
+	return m, nil
+}
+
+// unitFactors convert absolute CSS units to user units, at 96 pixels per inch
+var unitFactors = map[string]float64{
+	"":   1,
+	"px": 1,
+	"pt": 96.0 / 72,
+	"pc": 16,
+	"mm": 96 / 25.4,
+	"cm": 96 / 2.54,
+	"in": 96,
+}
+
+// parseLength parses a coordinate or length with an optional unit suffix.
+// Percentages are relative to reference, the viewBox width or height.
+func parseLength(value string, reference float64) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	number := numberPattern.FindString(value)
+	if number == "" || !strings.HasPrefix(value, number) {
+		return 0, fmt.Errorf("length %q is not a number", value)
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("length %q: %w", value, err)
+	}
+	unit := strings.TrimSpace(value[len(number):])
+	if unit == "%" {
+		return n * reference / 100, nil
+	}
+	factor, ok := unitFactors[strings.ToLower(unit)]
+	if !ok {
+		return 0, fmt.Errorf("length %q has an unsupported unit %q", value, unit)
+	}
+	return n * factor, nil
+}
+
+// subpath is a closed ring of points. Curves have already been flattened.
+type subpath []point
+
+// pathData is the parsed d attribute of a path
+type pathData struct {
+	subpaths []subpath
+	curved   bool // true if any curve or arc was flattened
+}
+
+// pathScanner reads commands, numbers and arc flags from path data
+type pathScanner struct {
+	d   string
+	pos int
+}
+
+func (s *pathScanner) skipSeparators() {
+	for s.pos < len(s.d) && strings.ContainsRune(" \t\r\n,", rune(s.d[s.pos])) {
+		s.pos++
+	}
+}
+
+// command returns the next command letter, if the next token is one
+func (s *pathScanner) command() (byte, bool) {
+	s.skipSeparators()
+	if s.pos < len(s.d) && strings.ContainsRune("MmLlHhVvZzCcSsQqTtAa", rune(s.d[s.pos])) {
+		s.pos++
+		return s.d[s.pos-1], true
+	}
+	return 0, false
+}
+
+// hasNumber returns true if a number follows
+func (s *pathScanner) hasNumber() bool {
+	s.skipSeparators()
+	return s.pos < len(s.d) && strings.ContainsRune("+-.0123456789", rune(s.d[s.pos]))
+}
+
+func (s *pathScanner) number() (float64, error) {
+	s.skipSeparators()
+	match := numberPattern.FindStringIndex(s.d[s.pos:])
+	if match == nil || match[0] != 0 {
+		return 0, fmt.Errorf("expected a number at offset %d of path data", s.pos)
+	}
+	n, err := strconv.ParseFloat(s.d[s.pos:s.pos+match[1]], 64)
+	s.pos += match[1]
+	return n, err
+}
+
+// flag reads an arc flag, which may be written without a separator, like "a1 1 0 01 2 2"
+func (s *pathScanner) flag() (bool, error) {
+	s.skipSeparators()
+	if s.pos < len(s.d) && (s.d[s.pos] == '0' || s.d[s.pos] == '1') {
+		s.pos++
+		return s.d[s.pos-1] == '1', nil
+	}
+	return false, fmt.Errorf("expected an arc flag at offset %d of path data", s.pos)
+}
+
+func (s *pathScanner) numbers(n int) ([]float64, error) {
+	numbers := make([]float64, n)
+	for i := range numbers {
+		number, err := s.number()
+		if err != nil {
+			return nil, err
+		}
+		numbers[i] = number
+	}
+	return numbers, nil
+}
+
+func cubicPoints(p0, p1, p2, p3 point) []point {
+	points := make([]point, 0, curveSegments)
+	for i := 1; i <= curveSegments; i++ {
+		t := float64(i) / curveSegments
+		u := 1 - t
+		points = append(points, point{
+			u*u*u*p0.x + 3*u*u*t*p1.x + 3*u*t*t*p2.x + t*t*t*p3.x,
+			u*u*u*p0.y + 3*u*u*t*p1.y + 3*u*t*t*p2.y + t*t*t*p3.y,
+		})
+	}
+	return points
+}
+
+func quadraticPoints(p0, p1, p2 point) []point {
+	points := make([]point, 0, curveSegments)
+	for i := 1; i <= curveSegments; i++ {
+		t := float64(i) / curveSegments
+		u := 1 - t
+		points = append(points, point{
+			u*u*p0.x + 2*u*t*p1.x + t*t*p2.x,
+			u*u*p0.y + 2*u*t*p1.y + t*t*p2.y,
+		})
+	}
+	return points
+}
+
+// arcPoints flattens an elliptical arc, converting the endpoint form of the
+// path data into the center form, see the SVG implementation notes
+func arcPoints(p0 point, rx, ry, rotation float64, largeArc, sweep bool, p1 point) []point {
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx < epsilon || ry < epsilon {
+		return []point{p1}
+	}
+	sinPhi, cosPhi := math.Sincos(rotation * math.Pi / 180)
+	dx, dy := (p0.x-p1.x)/2, (p0.y-p1.y)/2
+	x1 := cosPhi*dx + sinPhi*dy
+	y1 := -sinPhi*dx + cosPhi*dy
+
+	// scale radii that are too small to reach the end point
+	if lambda := x1*x1/(rx*rx) + y1*y1/(ry*ry); lambda > 1 {
+		rx, ry = rx*math.Sqrt(lambda), ry*math.Sqrt(lambda)
+	}
+
+	numerator := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	factor := math.Sqrt(math.Max(0, numerator/(rx*rx*y1*y1+ry*ry*x1*x1)))
+	if largeArc == sweep {
+		factor = -factor
+	}
+	cx1, cy1 := factor*rx*y1/ry, -factor*ry*x1/rx
+	cx := cosPhi*cx1 - sinPhi*cy1 + (p0.x+p1.x)/2
+	cy := sinPhi*cx1 + cosPhi*cy1 + (p0.y+p1.y)/2
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := angle(1, 0, (x1-cx1)/rx, (y1-cy1)/ry)
+	delta := angle((x1-cx1)/rx, (y1-cy1)/ry, (-x1-cx1)/rx, (-y1-cy1)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	segments := int(math.Ceil(math.Abs(delta) / (2 * math.Pi) * curveSegments))
+	if segments < 1 {
+		segments = 1
+	}
+	points := make([]point, 0, segments)
+	for i := 1; i <= segments; i++ {
+		sin, cos := math.Sincos(theta + delta*float64(i)/float64(segments))
+		points = append(points, point{
+			cx + rx*cos*cosPhi - ry*sin*sinPhi,
+			cy + rx*cos*sinPhi + ry*sin*cosPhi,
+		})
+	}
+	points[len(points)-1] = p1
+	return points
+}
+
+// parsePath parses the d attribute of a path into closed rings
+func parsePath(d string) (pathData, error) {
+	data := pathData{}
+	s := &pathScanner{d: d}
+	var (
+		current, start, lastControl point
+		ring                        subpath
+		command, previous           byte
+	)
+	closeRing := func() {
+		if len(ring) > 1 {
+			data.subpaths = append(data.subpaths, ring)
+		}
+		ring = nil
+	}
+
+	for {
+		if c, ok := s.command(); ok {
+			command = c
+		} else if s.pos >= len(s.d) {
+			break
+		} else if command == 0 || !s.hasNumber() {
+			return data, fmt.Errorf("unexpected %q at offset %d of path data", s.d[s.pos], s.pos)
+		}
+
+		relative := command >= 'a'
+		offset := func(p point) point {
+			if relative {
+				return point{current.x + p.x, current.y + p.y}
+			}
+			return p
+		}
+		// reflect the last control point for smooth curves that follow a curve of the same kind
+		reflected := func(kinds string) point {
+			if strings.ContainsRune(kinds, rune(previous|0x20)) {
+				return point{2*current.x - lastControl.x, 2*current.y - lastControl.y}
+			}
+			return current
+		}
+
+		switch command | 0x20 {
+		case 'm':
+			n, err := s.numbers(2)
+			if err != nil {
+				return data, err
+			}
+			closeRing()
+			current = offset(point{n[0], n[1]})
+			start = current
+			ring = subpath{current}
+			// further coordinate pairs are implicit lineto commands
+			if relative {
+				command = 'l'
+			} else {
+				command = 'L'
+			}
+		case 'z':
+			closeRing()
+			current = start
+		case 'l':
+			n, err := s.numbers(2)
+			if err != nil {
+				return data, err
+			}
+			current = offset(point{n[0], n[1]})
+			ring = append(ring, current)
+		case 'h':
+			n, err := s.number()
+			if err != nil {
+				return data, err
+			}
+			if relative {
+				n += current.x
+			}
+			current = point{n, current.y}
+			ring = append(ring, current)
+		case 'v':
+			n, err := s.number()
+			if err != nil {
+				return data, err
+			}
+			if relative {
+				n += current.y
+			}
+			current = point{current.x, n}
+			ring = append(ring, current)
+		case 'c', 's':
+			var p1 point
+			if command|0x20 == 'c' {
+				n, err := s.numbers(2)
+				if err != nil {
+					return data, err
+				}
+				p1 = offset(point{n[0], n[1]})
+			} else {
+				p1 = reflected("cs")
+			}
+			n, err := s.numbers(4)
+			if err != nil {
+				return data, err
+			}
+			p2, p3 := offset(point{n[0], n[1]}), offset(point{n[2], n[3]})
+			ring = append(ring, cubicPoints(current, p1, p2, p3)...)
+			current, lastControl = p3, p2
+			data.curved = true
+		case 'q', 't':
+			var p1 point
+			if command|0x20 == 'q' {
+				n, err := s.numbers(2)
+				if err != nil {
+					return data, err
+				}
+				p1 = offset(point{n[0], n[1]})
+			} else {
+				p1 = reflected("qt")
+			}
+			n, err := s.numbers(2)
+			if err != nil {
+				return data, err
+			}
+			p2 := offset(point{n[0], n[1]})
+			ring = append(ring, quadraticPoints(current, p1, p2)...)
+			current, lastControl = p2, p1
+			data.curved = true
+		case 'a':
+			n, err := s.numbers(3)
+			if err != nil {
+				return data, err
+			}
+			largeArc, err := s.flag()
+			if err != nil {
+				return data, err
+			}
+			sweep, err := s.flag()
+			if err != nil {
+				return data, err
+			}
+			end, err := s.numbers(2)
+			if err != nil {
+				return data, err
+			}
+			p1 := offset(point{end[0], end[1]})
+			ring = append(ring, arcPoints(current, n[0], n[1], n[2], largeArc, sweep, p1)...)
+			current = p1
+			data.curved = true
+		}
+		previous = command
+
+		// a closepath takes no numbers, so it cannot repeat implicitly
+		if command|0x20 == 'z' {
+			command = 0
+		}
+	}
+	closeRing()
+	return data, nil
+}
+
+// ellipsePoints approximates an ellipse with a ring
+func ellipsePoints(cx, cy, rx, ry float64) subpath {
+	ring := make(subpath, 0, curveSegments)
+	for i := 0; i < curveSegments; i++ {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / curveSegments)
+		ring = append(ring, point{cx + rx*cos, cy + ry*sin})
+	}
+	return ring
+}
+
+// roundedRectPoints approximates a rect with rounded corners with a ring
+func roundedRectPoints(x, y, w, h, rx, ry float64) subpath {
+	rx, ry = math.Min(rx, w/2), math.Min(ry, h/2)
+	corners := []struct{ cx, cy, from float64 }{
+		{x + w - rx, y + ry, -math.Pi / 2},
+		{x + w - rx, y + h - ry, 0},
+		{x + rx, y + h - ry, math.Pi / 2},
+		{x + rx, y + ry, math.Pi},
+	}
+	ring := subpath{}
+	steps := curveSegments / 4
+	for _, corner := range corners {
+		for i := 0; i <= steps; i++ {
+			sin, cos := math.Sincos(corner.from + math.Pi/2*float64(i)/float64(steps))
+			ring = append(ring, point{corner.cx + rx*cos, corner.cy + ry*sin})
+		}
+	}
+	return ring
+}
+
+func (ring subpath) transform(m matrix) subpath {
+	transformed := make(subpath, len(ring))
+	for i, p := range ring {
+		transformed[i] = m.apply(p)
+	}
+	return transformed
+}
+
+// rect is an axis aligned rectangle in user units
+type rect struct {
+	x, y, w, h float64
+}
+
+func (r rect) overlaps(other rect) bool {
+	return r.x+epsilon < other.x+other.w && other.x+epsilon < r.x+r.w &&
+		r.y+epsilon < other.y+other.h && other.y+epsilon < r.y+r.h
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < epsilon
+}
+
+// axisAlignedRect returns the rectangle a ring outlines, if it is an axis
+// aligned rectangle. Repeated and collinear points are ignored.
+func (ring subpath) axisAlignedRect() (rect, bool) {
+	points := []point{}
+	for _, p := range ring {
+		if len(points) > 0 && near(p.x, points[len(points)-1].x) && near(p.y, points[len(points)-1].y) {
+			continue
+		}
+		points = append(points, p)
+	}
+	if len(points) > 1 && near(points[0].x, points[len(points)-1].x) && near(points[0].y, points[len(points)-1].y) {
+		points = points[:len(points)-1]
+	}
+
+	// drop points in the middle of a straight edge
+	corners := []point{}
+	for i, p := range points {
+		prev := points[(i+len(points)-1)%len(points)]
+		next := points[(i+1)%len(points)]
+		if (near(prev.x, p.x) && near(p.x, next.x)) || (near(prev.y, p.y) && near(p.y, next.y)) {
+			continue
+		}
+		corners = append(corners, p)
+	}
+	if len(corners) != 4 {
+		return rect{}, false
+	}
+
+	for i, p := range corners {
+		next := corners[(i+1)%4]
+		if !near(p.x, next.x) && !near(p.y, next.y) {
+			return rect{}, false
+		}
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range corners {
+		minX, minY = math.Min(minX, p.x), math.Min(minY, p.y)
+		maxX, maxY = math.Max(maxX, p.x), math.Max(maxY, p.y)
+	}
+	return rect{minX, minY, maxX - minX, maxY - minY}, maxX-minX > epsilon && maxY-minY > epsilon
+}
+
+// rects returns the rectangles a set of rings outlines, if every ring is an
+// axis aligned rectangle and none of them overlap, so that the fill rule
+// does not matter
+func rects(rings []subpath) ([]rect, bool) {
+	found := []rect{}
+	for _, ring := range rings {
+		r, ok := ring.axisAlignedRect()
+		if !ok {
+			return nil, false
+		}
+		for _, other := range found {
+			if r.overlaps(other) {
+				return nil, false
+			}
+		}
+		found = append(found, r)
+	}
+	return found, true
+}
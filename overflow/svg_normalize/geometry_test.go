@@ -0,0 +1,234 @@
+package svg_normalize
+
+import "testing"
+
+func nearMatrix(a, b matrix) bool {
+	for i := range a {
+		if !near(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParseTransform(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected matrix
+	}{
+		{"", identity},
+		{"translate(10)", matrix{1, 0, 0, 1, 10, 0}},
+		{"translate(10, 20)", matrix{1, 0, 0, 1, 10, 20}},
+		{"scale(2)", matrix{2, 0, 0, 2, 0, 0}},
+		{"scale(2 3)", matrix{2, 0, 0, 3, 0, 0}},
+		{"matrix(1,2,3,4,5,6)", matrix{1, 2, 3, 4, 5, 6}},
+		// the translation is scaled, since the list applies right to left
+		{"scale(2) translate(10,20)", matrix{2, 0, 0, 2, 20, 40}},
+		{"translate(10,20) scale(2)", matrix{2, 0, 0, 2, 10, 20}},
+		{"translate(1 1), matrix(2 0 0 2 3 4)", matrix{2, 0, 0, 2, 4, 5}},
+		{"rotate(90)", matrix{0, 1, -1, 0, 0, 0}},
+		{"rotate(90 5 5)", matrix{0, 1, -1, 0, 10, 0}},
+		{"skewX(45)", matrix{1, 0, 1, 1, 0, 0}},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			m, err := parseTransform(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !nearMatrix(m, test.expected) {
+				t.Errorf("expected %v, found %v", test.expected, m)
+			}
+		})
+	}
+
+	for _, value := range []string{"translate(10", "perspective(2)", "matrix(1 2 3)"} {
+		if _, err := parseTransform(value); err == nil {
+			t.Errorf("expected transform %q to be refused", value)
+		}
+	}
+}
+
+// TestNestedTransforms checks that the transform of a parent applies after
+// the transform of its child
+func TestNestedTransforms(t *testing.T) {
+	parent, _ := parseTransform("translate(10 0)")
+	child, _ := parseTransform("scale(2)")
+	p := parent.multiply(child).apply(point{1, 1})
+	if !near(p.x, 12) || !near(p.y, 2) {
+		t.Errorf("expected 12,2, found %v,%v", p.x, p.y)
+	}
+}
+
+func TestParseLength(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected float64
+	}{
+		{"", 0},
+		{"12", 12},
+		{"-1.5", -1.5},
+		{"12px", 12},
+		{"1in", 96},
+		{"2.54cm", 96},
+		{"25.4mm", 96},
+		{"72pt", 96},
+		{"1pc", 16},
+		{"1E1PX", 10},
+		{"50%", 16},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			n, err := parseLength(test.value, 32)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !near(n, test.expected) {
+				t.Errorf("expected %v, found %v", test.expected, n)
+			}
+		})
+	}
+
+	for _, value := range []string{"px", "12em", "12ex", "twelve"} {
+		if _, err := parseLength(value, 32); err == nil {
+			t.Errorf("expected length %q to be refused", value)
+		}
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"red", "#ff0000"},
+		{" CornflowerBlue ", "#6495ed"},
+		{"#abc", "#aabbcc"},
+		{"#ABCDEF", "#abcdef"},
+		{"rgb(255, 0, 10)", "#ff000a"},
+		{"rgb(100%,50%,0%)", "#ff8000"},
+		{"rgb(300 -5 0)", "#ff0000"},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			hex, err := parseColor(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hex != test.expected {
+				t.Errorf("expected %s, found %s", test.expected, hex)
+			}
+		})
+	}
+
+	for _, value := range []string{"#abcd", "#ggg", "reddish", "hsl(0, 100%, 50%)", "rgb(a, b, c)"} {
+		if _, err := parseColor(value); err == nil {
+			t.Errorf("expected color %q to be refused", value)
+		}
+	}
+}
+
+func TestPathRects(t *testing.T) {
+	tests := []struct {
+		name   string
+		d      string
+		rects  []rect
+		exact  bool
+		curved bool
+	}{
+		{"absolute", "M1 2 L5 2 L5 6 L1 6 Z", []rect{{1, 2, 4, 4}}, true, false},
+		{"relative with h and v", "m1 2h4v4h-4z", []rect{{1, 2, 4, 4}}, true, false},
+		{"counter-clockwise", "M1 2 V6 H5 V2 Z", []rect{{1, 2, 4, 4}}, true, false},
+		{"collinear and repeated points", "M0 0 L2 0 L4 0 L4 0 L4 3 L0 3 Z", []rect{{0, 0, 4, 3}}, true, false},
+		{"separate subpaths", "M0 0h1v1h-1z M2 0h1v1h-1z", []rect{{0, 0, 1, 1}, {2, 0, 1, 1}}, true, false},
+		{"overlapping subpaths", "M0 0h2v2h-2z M1 1h2v2h-2z", nil, false, false},
+		{"triangle", "M0 0 L4 0 L0 4 Z", nil, false, false},
+		{"L-shape", "M0 0 H2 V1 H1 V2 H0 Z", nil, false, false},
+		{"curve", "M0 0 C1 0 1 1 0 1 Z", nil, false, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := parsePath(test.d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data.curved != test.curved {
+				t.Errorf("expected curved %v, found %v", test.curved, data.curved)
+			}
+			found, exact := rects(data.subpaths)
+			if exact != test.exact || len(found) != len(test.rects) {
+				t.Fatalf("expected rects %v, found %v (exact %v)", test.rects, found, exact)
+			}
+			for i, r := range found {
+				e := test.rects[i]
+				if !near(r.x, e.x) || !near(r.y, e.y) || !near(r.w, e.w) || !near(r.h, e.h) {
+					t.Errorf("expected rect %v, found %v", e, r)
+				}
+			}
+		})
+	}
+}
+
+// TestRotatedRect checks that a rect is only kept as a rect while its
+// transform keeps it axis aligned
+func TestRotatedRect(t *testing.T) {
+	ring := subpath{{0, 0}, {4, 0}, {4, 2}, {0, 2}}
+	tests := []struct {
+		transform string
+		exact     bool
+	}{
+		{"rotate(90)", true},
+		{"rotate(45)", false},
+		{"skewX(10)", false},
+		{"scale(-1 1)", true},
+	}
+	for _, test := range tests {
+		t.Run(test.transform, func(t *testing.T) {
+			m, err := parseTransform(test.transform)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, exact := ring.transform(m).axisAlignedRect()
+			if exact != test.exact {
+				t.Errorf("expected exact %v, found %v", test.exact, exact)
+			}
+			if exact && !near(r.w*r.h, 8) {
+				t.Errorf("expected an area of 8, found %v", r.w*r.h)
+			}
+		})
+	}
+}
+
+func TestRasterize(t *testing.T) {
+	// a diamond covering the centers of the middle row and column of a 3x3 grid
+	diamond := shape{fill: "#ff0000", rings: []subpath{{{1.5, 0}, {3, 1.5}, {1.5, 3}, {0, 1.5}}}}
+	img := rasterize([]shape{diamond}, 3, 3)
+	expected := []string{
+		".x.",
+		"xxx",
+		".x.",
+	}
+	for y, row := range expected {
+		for x, pixel := range row {
+			_, _, _, a := img.At(x, y).RGBA()
+			if (a != 0) != (pixel == 'x') {
+				t.Errorf("pixel %d,%d: expected %c, found alpha %d", x, y, pixel, a)
+			}
+		}
+	}
+
+	// a ring inside another is a hole with evenodd, and filled with nonzero
+	outer := subpath{{0, 0}, {3, 0}, {3, 3}, {0, 3}}
+	inner := subpath{{1, 1}, {2, 1}, {2, 2}, {1, 2}}
+	for _, evenOdd := range []bool{false, true} {
+		s := shape{fill: "#0000ff", evenOdd: evenOdd, rings: []subpath{outer, inner}}
+		_, _, _, a := rasterize([]shape{s}, 3, 3).At(1, 1).RGBA()
+		if (a == 0) != evenOdd {
+			t.Errorf("evenodd %v: unexpected alpha %d at the center", evenOdd, a)
+		}
+	}
+
+	if len(ellipsePoints(0, 0, 1, 1)) != curveSegments {
+		t.Errorf("expected ellipses to be flattened into %d segments", curveSegments)
+	}
+}
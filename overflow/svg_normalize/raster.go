@@ -0,0 +1,83 @@
+package svg_normalize
+
+import (
+	"image"
+	"math"
+)
+
+// shape is a filled element, already transformed into user units relative
+// to the viewBox origin
+type shape struct {
+	path    string // where the element was found, like "svg > g[0] > path[3]"
+	reason  string // why the shape is not exact
+	fill    string // #rrggbb
+	evenOdd bool   // fill-rule="evenodd"
+	rings   []subpath
+	rects   []rect // set if the shape is exactly a set of axis aligned rectangles
+	exact   bool
+}
+
+// winding returns the winding number of the rings around a point
+func winding(rings []subpath, p point) (nonZero int, crossings int) {
+	for _, ring := range rings {
+		for i := range ring {
+			a, b := ring[i], ring[(i+1)%len(ring)]
+			if (a.y <= p.y) == (b.y <= p.y) {
+				continue
+			}
+			// x of the edge at the height of the point
+			x := a.x + (p.y-a.y)*(b.x-a.x)/(b.y-a.y)
+			if x <= p.x {
+				continue
+			}
+			crossings++
+			if b.y > a.y {
+				nonZero++
+			} else {
+				nonZero--
+			}
+		}
+	}
+	return nonZero, crossings
+}
+
+func (s shape) contains(p point) bool {
+	nonZero, crossings := winding(s.rings, p)
+	if s.evenOdd {
+		return crossings%2 == 1
+	}
+	return nonZero != 0
+}
+
+// bounds returns the pixels the shape may touch, clipped to the image
+func (s shape) bounds(w, h int) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, ring := range s.rings {
+		for _, p := range ring {
+			minX, minY = math.Min(minX, p.x), math.Min(minY, p.y)
+			maxX, maxY = math.Max(maxX, p.x), math.Max(maxY, p.y)
+		}
+	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY))).
+		Intersect(image.Rect(0, 0, w, h))
+}
+
+// rasterize paints the shapes in order onto a transparent image, one pixel per
+// user unit. A pixel takes the color of the last shape covering its center,
+// so there is no anti-aliasing.
+func rasterize(shapes []shape, w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for _, s := range shapes {
+		color := rgba(s.fill)
+		area := s.bounds(w, h)
+		for y := area.Min.Y; y < area.Max.Y; y++ {
+			for x := area.Min.X; x < area.Max.X; x++ {
+				if s.contains(point{float64(x) + 0.5, float64(y) + 0.5}) {
+					img.SetNRGBA(x, y, color)
+				}
+			}
+		}
+	}
+	return img
+}
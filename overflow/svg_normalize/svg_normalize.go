@@ -0,0 +1,579 @@
+/*
+Normalizes SVGs exported by drawing tools such as Inkscape or Figma into the
+rect-only form of the IaNFTAnalogs schema: an svg root with g elements of
+rects below it, see svg_prep.ValidateSvg.
+
+Groups and transforms are flattened, fills are read from attributes, style
+declarations and inherited values, lengths with unit suffixes are converted
+to user units, and paths, polygons and rects that outline axis aligned
+rectangles are written as rects. Shapes that cannot be written as rects,
+such as circles, curves or rotated rectangles, are rasterized at one pixel
+per user unit and reconverted through png2svg. Everything that was changed
+in a way that can be seen is listed in the Report.
+*/
+
+package svg_normalize
+
+import (
+	"bytes"
+	"floasis-items/flow/overflow/convert"
+	"floasis-items/flow/overflow/svg_prep"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/JoshVarga/svgparser"
+)
+
+// Options control how far Normalize may go to fit an SVG into the schema
+type Options struct {
+	Rasterize     bool // rasterize when a shape is not made of axis aligned rectangles, instead of dropping it
+	MaxRasterSize int  // largest viewBox width or height that is rasterized
+}
+
+// DefaultOptions rasterizes shapes that are not rectangles, for SVGs of up to 1024x1024 units
+func DefaultOptions() Options {
+	return Options{Rasterize: true, MaxRasterSize: 1024}
+}
+
+// Approximation is a visible change made while normalizing. Path locates the
+// element in the source SVG, like "svg > g[0] > path[3]".
+type Approximation struct {
+	Path    string
+	Message string
+}
+
+func (approximation Approximation) String() string {
+	return approximation.Path + ": " + approximation.Message
+}
+
+// Report lists what Normalize approximated
+type Report struct {
+	Rasterized     bool // true if the output was rasterized and reconverted through png2svg
+	Groups         int
+	Rects          int
+	Approximations []Approximation
+}
+
+// Exact returns true if the normalized SVG renders exactly like the source
+func (report *Report) Exact() bool {
+	return len(report.Approximations) == 0
+}
+
+// WriteText writes the report for people, one approximation per line
+func (report *Report) WriteText(w io.Writer) error {
+	mode := "rects kept"
+	if report.Rasterized {
+		mode = "rasterized"
+	}
+	if _, err := fmt.Fprintf(w, "%d g, %d rect, %s, %d approximation(s)\n", report.Groups, report.Rects, mode, len(report.Approximations)); err != nil {
+		return err
+	}
+	for _, approximation := range report.Approximations {
+		if _, err := fmt.Fprintf(w, "  %s\n", approximation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inherited holds the properties that pass from an element to its children
+type inherited struct {
+	transform   matrix
+	fill        string
+	fillRule    string
+	color       string
+	opacity     float64
+	stroke      string
+	strokeWidth string
+	hidden      bool
+}
+
+// normalizer walks the source SVG and collects the shapes it paints
+type normalizer struct {
+	options        Options
+	width, height  float64 // of the viewBox
+	shapes         []shape
+	approximations []Approximation
+}
+
+func (n *normalizer) approximate(path string, format string, args ...interface{}) {
+	n.approximations = append(n.approximations, Approximation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// childPaths names every child by its position among the siblings of the same name
+func childPaths(parentPath string, children []*svgparser.Element) []string {
+	counts := map[string]int{}
+	paths := make([]string, len(children))
+	for i, child := range children {
+		paths[i] = fmt.Sprintf("%s > %s[%d]", parentPath, child.Name, counts[child.Name])
+		counts[child.Name]++
+	}
+	return paths
+}
+
+// properties returns the presentation attributes of an element, with
+// declarations of its style attribute taking precedence
+func properties(element *svgparser.Element) map[string]string {
+	props := map[string]string{}
+	for name, value := range element.Attributes {
+		props[name] = strings.TrimSpace(value)
+	}
+	for _, declaration := range strings.Split(element.Attributes["style"], ";") {
+		name, value, ok := strings.Cut(declaration, ":")
+		if ok {
+			props[strings.TrimSpace(name)] = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+		}
+	}
+	return props
+}
+
+// parseOpacity parses an opacity, which may be a percentage
+func parseOpacity(value string) float64 {
+	percent := strings.HasSuffix(value, "%")
+	n, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil {
+		return 1
+	}
+	if percent {
+		n /= 100
+	}
+	return math.Max(0, math.Min(1, n))
+}
+
+// inherit applies the properties of an element to the inherited state
+func (n *normalizer) inherit(path string, element *svgparser.Element, state inherited) (inherited, error) {
+	props := properties(element)
+	if value, ok := props["transform"]; ok {
+		m, err := parseTransform(value)
+		if err != nil {
+			return state, fmt.Errorf("%s: %w", path, err)
+		}
+		state.transform = state.transform.multiply(m)
+	}
+	for name, target := range map[string]*string{
+		"fill":         &state.fill,
+		"fill-rule":    &state.fillRule,
+		"color":        &state.color,
+		"stroke":       &state.stroke,
+		"stroke-width": &state.strokeWidth,
+	} {
+		if value, ok := props[name]; ok && value != "inherit" {
+			*target = value
+		}
+	}
+	if value, ok := props["opacity"]; ok {
+		state.opacity *= parseOpacity(value)
+	}
+	if value, ok := props["fill-opacity"]; ok {
+		state.opacity *= parseOpacity(value)
+	}
+	if value, ok := props["visibility"]; ok {
+		state.hidden = value == "hidden" || value == "collapse"
+	}
+	for _, name := range []string{"clip-path", "mask", "filter"} {
+		if value, ok := props[name]; ok && value != "none" {
+			n.approximate(path, "%s is not supported and was ignored", name)
+		}
+	}
+	return state, nil
+}
+
+// skippedElements hold definitions or metadata, which are not painted
+var skippedElements = map[string]bool{
+	"defs": true, "title": true, "desc": true, "metadata": true, "namedview": true,
+	"clipPath": true, "mask": true, "symbol": true, "marker": true, "pattern": true,
+	"linearGradient": true, "radialGradient": true, "filter": true, "script": true,
+}
+
+func (n *normalizer) walk(path string, element *svgparser.Element, state inherited) error {
+	for i, childPath := range childPaths(path, element.Children) {
+		child := element.Children[i]
+		if skippedElements[child.Name] || properties(child)["display"] == "none" {
+			continue
+		}
+		childState, err := n.inherit(childPath, child, state)
+		if err != nil {
+			return err
+		}
+		switch child.Name {
+		case "g", "a", "switch":
+			if err := n.walk(childPath, child, childState); err != nil {
+				return err
+			}
+		case "svg":
+			x, err := parseLength(child.Attributes["x"], n.width)
+			if err != nil {
+				return fmt.Errorf("%s: %w", childPath, err)
+			}
+			y, err := parseLength(child.Attributes["y"], n.height)
+			if err != nil {
+				return fmt.Errorf("%s: %w", childPath, err)
+			}
+			if _, ok := child.Attributes["viewBox"]; ok {
+				n.approximate(childPath, "the viewBox of a nested svg is ignored, only its position is kept")
+			}
+			childState.transform = childState.transform.multiply(matrix{1, 0, 0, 1, x, y})
+			if err := n.walk(childPath, child, childState); err != nil {
+				return err
+			}
+		case "style":
+			n.approximate(childPath, "CSS rules of <style> elements are not applied")
+		case "rect", "circle", "ellipse", "polygon", "polyline", "path":
+			if err := n.addShape(childPath, child, childState); err != nil {
+				return err
+			}
+		case "line":
+			n.approximate(childPath, "<line> has no fill, only a stroke, and was dropped")
+		default:
+			n.approximate(childPath, "<%s> is not supported and was dropped", child.Name)
+		}
+	}
+	return nil
+}
+
+// geometry reads the outline of a shape element in its own coordinates.
+// curved is true if the outline had to be flattened.
+func (n *normalizer) geometry(element *svgparser.Element) (rings []subpath, curved bool, err error) {
+	attributes := element.Attributes
+	length := func(name string, reference float64) float64 {
+		if err != nil {
+			return 0
+		}
+		var value float64
+		value, err = parseLength(attributes[name], reference)
+		return value
+	}
+	diagonal := math.Sqrt((n.width*n.width + n.height*n.height) / 2)
+
+	switch element.Name {
+	case "rect":
+		x, y := length("x", n.width), length("y", n.height)
+		w, h := length("width", n.width), length("height", n.height)
+		rx, ry := length("rx", n.width), length("ry", n.height)
+		if err != nil || w <= 0 || h <= 0 {
+			return nil, false, err
+		}
+		if _, ok := attributes["rx"]; !ok {
+			rx = ry
+		}
+		if _, ok := attributes["ry"]; !ok {
+			ry = rx
+		}
+		if rx > 0 && ry > 0 {
+			return []subpath{roundedRectPoints(x, y, w, h, rx, ry)}, true, nil
+		}
+		return []subpath{{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}}, false, nil
+	case "circle":
+		cx, cy, r := length("cx", n.width), length("cy", n.height), length("r", diagonal)
+		if err != nil || r <= 0 {
+			return nil, false, err
+		}
+		return []subpath{ellipsePoints(cx, cy, r, r)}, true, nil
+	case "ellipse":
+		cx, cy := length("cx", n.width), length("cy", n.height)
+		rx, ry := length("rx", n.width), length("ry", n.height)
+		if err != nil || rx <= 0 || ry <= 0 {
+			return nil, false, err
+		}
+		return []subpath{ellipsePoints(cx, cy, rx, ry)}, true, nil
+	case "polygon", "polyline":
+		numbers, err := parseNumbers(attributes["points"])
+		if err != nil {
+			return nil, false, err
+		}
+		ring := subpath{}
+		for i := 0; i+1 < len(numbers); i += 2 {
+			ring = append(ring, point{numbers[i], numbers[i+1]})
+		}
+		if len(ring) < 3 {
+			return nil, false, nil
+		}
+		return []subpath{ring}, false, nil
+	case "path":
+		data, err := parsePath(attributes["d"])
+		if err != nil {
+			return nil, false, err
+		}
+		return data.subpaths, data.curved, nil
+	}
+	return nil, false, nil
+}
+
+// paint resolves the fill of a shape to #rrggbb. ok is false if the shape is not painted.
+func (n *normalizer) paint(path string, state inherited) (fill string, ok bool) {
+	if state.hidden || state.opacity == 0 {
+		return "", false
+	}
+	value := state.fill
+	switch {
+	case value == "":
+		value = "black"
+	case value == "none" || value == "transparent":
+		return "", false
+	case value == "currentColor" || value == "currentcolor":
+		value = state.color
+		if value == "" {
+			value = "black"
+		}
+	case strings.HasPrefix(value, "url("):
+		n.approximate(path, "fill %s refers to a gradient or pattern, which is not supported, and was dropped", value)
+		return "", false
+	}
+	fill, err := parseColor(value)
+	if err != nil {
+		n.approximate(path, "%s and was dropped", err)
+		return "", false
+	}
+	if state.opacity < 1 {
+		n.approximate(path, "opacity %v is not supported, the fill is painted opaque", state.opacity)
+	}
+	return fill, true
+}
+
+func (n *normalizer) addShape(path string, element *svgparser.Element, state inherited) error {
+	if state.stroke != "" && state.stroke != "none" {
+		strokeWidth := 1.0
+		if state.strokeWidth != "" {
+			strokeWidth, _ = parseLength(state.strokeWidth, n.width)
+		}
+		if strokeWidth != 0 {
+			n.approximate(path, "stroke %s is not supported, only the fill is kept", state.stroke)
+		}
+	}
+
+	fill, ok := n.paint(path, state)
+	if !ok {
+		return nil
+	}
+	rings, curved, err := n.geometry(element)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if len(rings) == 0 {
+		return nil
+	}
+
+	s := shape{path: path, fill: fill, evenOdd: state.fillRule == "evenodd"}
+	for _, ring := range rings {
+		s.rings = append(s.rings, ring.transform(state.transform))
+	}
+	if !curved {
+		s.rects, s.exact = rects(s.rings)
+	}
+	if !s.exact {
+		s.reason = "is not made of axis aligned rectangles"
+		switch {
+		case curved:
+			s.reason = "has a curved outline"
+		case !state.transform.isIdentity():
+			s.reason = "is rotated or skewed"
+		}
+	}
+	n.shapes = append(n.shapes, s)
+	return nil
+}
+
+// viewBoxSize reads the viewBox of the root, or its width and height
+func viewBoxSize(root *svgparser.Element) (minX, minY, width, height float64, err error) {
+	if value, ok := root.Attributes["viewBox"]; ok {
+		numbers, err := parseNumbers(value)
+		if err != nil || len(numbers) != 4 || numbers[2] <= 0 || numbers[3] <= 0 {
+			return 0, 0, 0, 0, fmt.Errorf("svg: viewBox %q must have four numbers and a positive size", value)
+		}
+		return numbers[0], numbers[1], numbers[2], numbers[3], nil
+	}
+	width, err = parseLength(root.Attributes["width"], 0)
+	if err != nil {
+		return 0, 0, 0, 0, fmt.Errorf("svg: %w", err)
+	}
+	height, err = parseLength(root.Attributes["height"], 0)
+	if err != nil {
+		return 0, 0, 0, 0, fmt.Errorf("svg: %w", err)
+	}
+	if width <= 0 || height <= 0 {
+		return 0, 0, 0, 0, fmt.Errorf("svg: needs a viewBox, or a width and a height")
+	}
+	return 0, 0, width, height, nil
+}
+
+// formatNumber writes a coordinate without floating point noise
+func formatNumber(n float64) string {
+	n = math.Round(n/epsilon) * epsilon
+	if n == 0 {
+		n = 0 // no negative zero
+	}
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// clip returns the part of a rect inside the viewBox
+func (n *normalizer) clip(r rect) (rect, bool) {
+	x0, y0 := math.Max(r.x, 0), math.Max(r.y, 0)
+	x1, y1 := math.Min(r.x+r.w, n.width), math.Min(r.y+r.h, n.height)
+	return rect{x0, y0, x1 - x0, y1 - y0}, x1-x0 > epsilon && y1-y0 > epsilon
+}
+
+// writeRects renders exact shapes. Consecutive rects of the same fill share a
+// g, so that the paint order of overlapping shapes is kept.
+func (n *normalizer) writeRects(report *Report) []byte {
+	var buf bytes.Buffer
+	size := formatNumber(n.width) + " " + formatNumber(n.height)
+	fmt.Fprintf(&buf, `<?xml version="1.0" encoding="UTF-8"?><svg width="%spx" height="%spx" xmlns="http://www.w3.org/2000/svg" version="1.2" baseProfile="tiny" viewBox="0 0 %s">`,
+		formatNumber(n.width), formatNumber(n.height), size)
+
+	fill := ""
+	for _, s := range n.shapes {
+		for _, r := range s.rects {
+			r, ok := n.clip(r)
+			if !ok {
+				continue
+			}
+			if s.fill != fill {
+				if fill != "" {
+					buf.WriteString("</g>")
+				}
+				fill = s.fill
+				fmt.Fprintf(&buf, `<g fill="%s">`, fill)
+				report.Groups++
+			}
+			fmt.Fprintf(&buf, `<rect x="%s" y="%s" width="%s" height="%s"/>`,
+				formatNumber(r.x), formatNumber(r.y), formatNumber(r.w), formatNumber(r.h))
+			report.Rects++
+		}
+	}
+	if fill != "" {
+		buf.WriteString("</g>")
+	}
+	buf.WriteString("</svg>")
+	return buf.Bytes()
+}
+
+// isInteger returns true if a coordinate lies on the pixel grid
+func isInteger(n float64) bool {
+	return near(n, math.Round(n))
+}
+
+// rasterizeShapes paints every shape onto a pixel grid and reconverts it through png2svg
+func (n *normalizer) rasterizeShapes(report *Report) ([]byte, error) {
+	if n.width > float64(n.options.MaxRasterSize) || n.height > float64(n.options.MaxRasterSize) {
+		return nil, fmt.Errorf("svg: viewBox of %vx%v is too large to rasterize, the limit is %d",
+			n.width, n.height, n.options.MaxRasterSize)
+	}
+	if !isInteger(n.width) || !isInteger(n.height) {
+		n.approximate("svg", "viewBox size %vx%v is rounded up to whole pixels", n.width, n.height)
+	}
+	for _, s := range n.shapes {
+		if !s.exact {
+			continue
+		}
+		for _, r := range s.rects {
+			if !isInteger(r.x) || !isInteger(r.y) || !isInteger(r.w) || !isInteger(r.h) {
+				n.approximate(s.path, "rect at %v,%v of size %vx%v is snapped to the pixel grid", r.x, r.y, r.w, r.h)
+				break
+			}
+		}
+	}
+
+	img := rasterize(n.shapes, int(math.Ceil(n.width-epsilon)), int(math.Ceil(n.height-epsilon)))
+	document, err := convert.ConvertImage(img)
+	if err != nil {
+		return nil, err
+	}
+	report.Rasterized = true
+	root, err := svgparser.Parse(bytes.NewReader(document), false)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range root.Children {
+		report.Groups++
+		report.Rects += len(g.Children)
+	}
+	return document, nil
+}
+
+// Normalize converts an SVG into the rect-only form of the IaNFTAnalogs schema
+func Normalize(svgString string, options Options) ([]byte, *Report, error) {
+	root, err := svgparser.Parse(strings.NewReader(svgString), false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("svg_normalize: parsing svg: %w", err)
+	}
+	if root.Name != "svg" {
+		return nil, nil, fmt.Errorf("svg_normalize: root element must be <svg>, found <%s>", root.Name)
+	}
+
+	minX, minY, width, height, err := viewBoxSize(root)
+	if err != nil {
+		return nil, nil, fmt.Errorf("svg_normalize: %w", err)
+	}
+	n := &normalizer{options: options, width: width, height: height}
+
+	state, err := n.inherit("svg", root, inherited{transform: identity, opacity: 1})
+	if err != nil {
+		return nil, nil, fmt.Errorf("svg_normalize: %w", err)
+	}
+	// move the viewBox origin to 0,0
+	state.transform = matrix{1, 0, 0, 1, -minX, -minY}.multiply(state.transform)
+	if err := n.walk("svg", root, state); err != nil {
+		return nil, nil, fmt.Errorf("svg_normalize: %w", err)
+	}
+
+	exact := true
+	for _, s := range n.shapes {
+		exact = exact && s.exact
+	}
+
+	report := &Report{}
+	var document []byte
+	if exact || !options.Rasterize {
+		kept := n.shapes[:0]
+		for _, s := range n.shapes {
+			if s.exact {
+				kept = append(kept, s)
+			} else {
+				n.approximate(s.path, "%s and was dropped, since rasterizing is disabled", s.reason)
+			}
+		}
+		n.shapes = kept
+		document = n.writeRects(report)
+	} else {
+		for _, s := range n.shapes {
+			if !s.exact {
+				n.approximate(s.path, "%s and was rasterized at one pixel per unit", s.reason)
+			}
+		}
+		document, err = n.rasterizeShapes(report)
+		if err != nil {
+			return nil, nil, fmt.Errorf("svg_normalize: %w", err)
+		}
+	}
+	report.Approximations = n.approximations
+
+	// the output has to pass the same checks as every other SVG that goes on chain
+	normalized, err := svgparser.Parse(bytes.NewReader(document), false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("svg_normalize: parsing normalized svg: %w", err)
+	}
+	if err := svg_prep.ValidateSvg(normalized); err != nil {
+		return nil, nil, fmt.Errorf("svg_normalize: normalized svg is invalid: %w", err)
+	}
+	return document, report, nil
+}
+
+// NormalizeFile normalizes the SVG at inPath and writes the result to outPath
+func NormalizeFile(inPath string, outPath string, options Options) (*Report, error) {
+	data, err := os.ReadFile(inPath)
+	if err != nil {
+		return nil, err
+	}
+	document, report, err := Normalize(string(data), options)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", inPath, err)
+	}
+	if err := os.WriteFile(outPath, document, 0644); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
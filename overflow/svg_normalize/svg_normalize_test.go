@@ -0,0 +1,248 @@
+package svg_normalize
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const header = `<?xml version="1.0" encoding="UTF-8"?><svg width="8px" height="8px" xmlns="http://www.w3.org/2000/svg" version="1.2" baseProfile="tiny" viewBox="0 0 8 8">`
+
+// source wraps elements into an 8x8 svg
+func source(elements string) string {
+	return `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 8 8">` + elements + `</svg>`
+}
+
+func TestNormalizeRects(t *testing.T) {
+	tests := []struct {
+		name           string
+		svg            string
+		expected       string // the rects and groups of the output
+		groups, rects  int
+		approximations []string
+	}{
+		{
+			"nested transforms",
+			source(`<g transform="translate(2 0)"><g transform="scale(2)"><rect x="0" y="1" width="1" height="1" fill="red"/></g></g>`),
+			`<g fill="#ff0000"><rect x="2" y="2" width="2" height="2"/></g>`,
+			1, 1, nil,
+		},
+		{
+			"matrix inside a translate",
+			source(`<g transform="translate(1,1)"><rect transform="matrix(1 0 0 1 2 3)" width="1" height="1" fill="#00f"/></g>`),
+			`<g fill="#0000ff"><rect x="3" y="4" width="1" height="1"/></g>`,
+			1, 1, nil,
+		},
+		{
+			"viewBox origin",
+			`<svg xmlns="http://www.w3.org/2000/svg" viewBox="10 10 8 8"><rect x="11" y="12" width="1" height="1"/></svg>`,
+			`<g fill="#000000"><rect x="1" y="2" width="1" height="1"/></g>`,
+			1, 1, nil,
+		},
+		{
+			"style over attribute fill",
+			source(`<rect width="1" height="1" fill="red" style="fill: #0F0 !important; stroke-width: 0"/>`),
+			`<g fill="#00ff00"><rect x="0" y="0" width="1" height="1"/></g>`,
+			1, 1, nil,
+		},
+		{
+			"inherited named fill",
+			source(`<g style="fill:navy"><rect width="1" height="1"/><rect x="2" width="1" height="1" fill="inherit"/></g>`),
+			`<g fill="#000080"><rect x="0" y="0" width="1" height="1"/><rect x="2" y="0" width="1" height="1"/></g>`,
+			1, 2, nil,
+		},
+		{
+			"currentColor",
+			source(`<g color="#abc"><rect width="1" height="1" fill="currentColor"/></g>`),
+			`<g fill="#aabbcc"><rect x="0" y="0" width="1" height="1"/></g>`,
+			1, 1, nil,
+		},
+		{
+			"axis aligned paths and polygons",
+			source(`<path d="M0 0h2v1H0z m3 0h1v1h-1z" fill="red"/><polygon points="0,2 1,2 1,3 0,3" fill="blue"/><path d="M0 4 L1 4 L1 5 L0 5" fill="blue"/>`),
+			`<g fill="#ff0000"><rect x="0" y="0" width="2" height="1"/><rect x="3" y="0" width="1" height="1"/></g>` +
+				`<g fill="#0000ff"><rect x="0" y="2" width="1" height="1"/><rect x="0" y="4" width="1" height="1"/></g>`,
+			2, 4, nil,
+		},
+		{
+			"paint order",
+			source(`<rect width="1" height="1" fill="red"/><rect x="1" width="1" height="1" fill="blue"/><rect x="2" width="1" height="1" fill="red"/>`),
+			`<g fill="#ff0000"><rect x="0" y="0" width="1" height="1"/></g><g fill="#0000ff"><rect x="1" y="0" width="1" height="1"/></g><g fill="#ff0000"><rect x="2" y="0" width="1" height="1"/></g>`,
+			3, 3, nil,
+		},
+		{
+			"clipped to the viewBox",
+			source(`<rect x="-2" y="6" width="4" height="4"/><rect x="9" width="1" height="1"/>`),
+			`<g fill="#000000"><rect x="0" y="6" width="2" height="2"/></g>`,
+			1, 1, nil,
+		},
+		{
+			"unpainted and unsupported",
+			source(`<defs><rect width="8" height="8"/></defs><rect width="8" height="8" fill="none"/><rect width="8" height="8" display="none"/>` +
+				`<rect width="1" height="1" fill="url(#gradient)"/><rect width="1" height="1" fill="hsl(0,0%,0%)"/><text>hat</text>` +
+				`<g opacity="0.5"><rect width="1" height="1" stroke="red"/></g>`),
+			`<g fill="#000000"><rect x="0" y="0" width="1" height="1"/></g>`,
+			1, 1,
+			[]string{
+				"svg > rect[2]: fill url(#gradient) refers to a gradient or pattern, which is not supported, and was dropped",
+				`svg > rect[3]: color "hsl(0,0%,0%)" is not supported and was dropped`,
+				"svg > text[0]: <text> is not supported and was dropped",
+				"svg > g[0] > rect[0]: stroke red is not supported, only the fill is kept",
+				"svg > g[0] > rect[0]: opacity 0.5 is not supported, the fill is painted opaque",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			document, report, err := Normalize(test.svg, DefaultOptions())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := header + test.expected + "</svg>"
+			if string(document) != expected {
+				t.Errorf("expected\n%s\nfound\n%s", expected, document)
+			}
+			if report.Rasterized || report.Groups != test.groups || report.Rects != test.rects {
+				t.Errorf("expected %d g and %d rect without rasterizing, found %+v", test.groups, test.rects, report)
+			}
+			approximations := []string{}
+			for _, approximation := range report.Approximations {
+				approximations = append(approximations, approximation.String())
+			}
+			if test.approximations == nil {
+				test.approximations = []string{}
+			}
+			if !reflect.DeepEqual(approximations, test.approximations) {
+				t.Errorf("expected approximations\n  %s\nfound\n  %s", strings.Join(test.approximations, "\n  "), strings.Join(approximations, "\n  "))
+			}
+			if report.Exact() != (len(test.approximations) == 0) {
+				t.Errorf("expected Exact to follow the approximations")
+			}
+		})
+	}
+}
+
+// TestNormalizeUnits sizes an svg without a viewBox by its width and height
+func TestNormalizeUnits(t *testing.T) {
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" width="1in" height="72pt"><rect x="1px" y="0.75pt" width="0.25in" height="50%"/></svg>`
+	document, report, err := Normalize(svg, DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?><svg width="96px" height="96px" xmlns="http://www.w3.org/2000/svg" version="1.2" baseProfile="tiny" viewBox="0 0 96 96">` +
+		`<g fill="#000000"><rect x="1" y="1" width="24" height="48"/></g></svg>`
+	if string(document) != expected {
+		t.Errorf("expected\n%s\nfound\n%s", expected, document)
+	}
+	if report.Groups != 1 || report.Rects != 1 || !report.Exact() {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func TestNormalizeRasterizes(t *testing.T) {
+	tests := []struct {
+		name          string
+		svg           string
+		approximation string
+	}{
+		{
+			"rotated rect",
+			source(`<rect x="2" y="2" width="4" height="4" transform="rotate(45 4 4)" fill="red"/>`),
+			"svg > rect[0]: is rotated or skewed and was rasterized at one pixel per unit",
+		},
+		{
+			"circle",
+			source(`<circle cx="4" cy="4" r="3" fill="red"/>`),
+			"svg > circle[0]: has a curved outline and was rasterized at one pixel per unit",
+		},
+		{
+			"curved path",
+			source(`<path d="M1 1 Q7 1 7 7 L1 7 Z" fill="red"/>`),
+			"svg > path[0]: has a curved outline and was rasterized at one pixel per unit",
+		},
+		{
+			"triangle",
+			source(`<polygon points="0,0 8,0 0,8" fill="red"/>`),
+			"svg > polygon[0]: is not made of axis aligned rectangles and was rasterized at one pixel per unit",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			document, report, err := Normalize(test.svg, DefaultOptions())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !report.Rasterized || report.Groups != 1 || report.Rects == 0 {
+				t.Errorf("expected a single rasterized fill, found %+v", report)
+			}
+			if strings.Count(string(document), "<rect") != report.Rects || strings.Count(string(document), "<g") != report.Groups {
+				t.Errorf("the report does not count the output\n%s\n%+v", document, report)
+			}
+			if len(report.Approximations) != 1 || report.Approximations[0].String() != test.approximation {
+				t.Errorf("expected the approximation %q, found %v", test.approximation, report.Approximations)
+			}
+
+			// without rasterizing, the shape is dropped
+			options := DefaultOptions()
+			options.Rasterize = false
+			document, report, err = Normalize(test.svg, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Rasterized || report.Groups != 0 || report.Rects != 0 || strings.Contains(string(document), "<rect") {
+				t.Errorf("expected the shape to be dropped, found %+v\n%s", report, document)
+			}
+			if len(report.Approximations) != 1 || !strings.HasSuffix(report.Approximations[0].Message, "was dropped, since rasterizing is disabled") {
+				t.Errorf("expected the shape to be reported as dropped, found %v", report.Approximations)
+			}
+		})
+	}
+}
+
+func TestNormalizeRasterizeLimits(t *testing.T) {
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 7.5 8"><circle cx="4" cy="4" r="3"/><rect x="0.5" width="1" height="1"/></svg>`
+	_, report, err := Normalize(svg, DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"svg > circle[0]: has a curved outline and was rasterized at one pixel per unit",
+		"svg: viewBox size 7.5x8 is rounded up to whole pixels",
+		"svg > rect[0]: rect at 0.5,0 of size 1x1 is snapped to the pixel grid",
+	}
+	actual := []string{}
+	for _, approximation := range report.Approximations {
+		actual = append(actual, approximation.String())
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected approximations\n  %s\nfound\n  %s", strings.Join(expected, "\n  "), strings.Join(actual, "\n  "))
+	}
+
+	options := DefaultOptions()
+	options.MaxRasterSize = 4
+	if _, _, err := Normalize(source(`<circle cx="4" cy="4" r="3"/>`), options); err == nil || !strings.Contains(err.Error(), "too large to rasterize") {
+		t.Errorf("expected the viewBox to be too large to rasterize, found %v", err)
+	}
+}
+
+func TestNormalizeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		svg      string
+		expected string
+	}{
+		{"not an svg", `<g/>`, "root element must be <svg>, found <g>"},
+		{"no size", `<svg xmlns="http://www.w3.org/2000/svg"/>`, "needs a viewBox, or a width and a height"},
+		{"bad viewBox", `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 8"/>`, `viewBox "0 0 8" must have four numbers`},
+		{"bad transform", source(`<g transform="perspective(2)"><rect width="1" height="1"/></g>`), `svg > g[0]: transform "perspective(2)" is not supported`},
+		{"bad unit", source(`<rect width="1em" height="1"/>`), `svg > rect[0]: length "1em" has an unsupported unit "em"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := Normalize(test.svg, DefaultOptions())
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected an error containing %q, found %v", test.expected, err)
+			}
+		})
+	}
+}
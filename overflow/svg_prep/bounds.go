@@ -0,0 +1,80 @@
+package svg_prep
+
+import (
+	"floasis-items/flow/overflow/png2svg"
+	"strconv"
+)
+
+// MetadataName is the name of the GElem that carries the metadata of the art
+// instead of artwork. It is kept after the groups of the artwork, so that
+// their indexes are the same with and without it, and renders as an SVG
+// <metadata> element, which draws nothing.
+const MetadataName = "metadata"
+
+// BoundingBoxType is the type of a Rect of the metadata whose attributes are
+// the bounding box of all the content of the art, see ContentBoundingBox
+const BoundingBoxType = "bounding-box"
+
+// IsMetadata returns true if the group carries metadata instead of artwork
+func (g *GElem) IsMetadata() bool {
+	return g.Name == MetadataName
+}
+
+// newMetadata returns the metadata of art with the given content bounding box
+func newMetadata(content png2svg.BoundingBox) GElem {
+	return GElem{
+		Name:       MetadataName,
+		Type:       "element",
+		Value:      "",
+		Attributes: GElemAttributes{},
+		Children: []Rect{{
+			Name:  "rect",
+			Type:  BoundingBoxType,
+			Value: "",
+			Attributes: RectAttributes{
+				X:      strconv.Itoa(content.X),
+				Y:      strconv.Itoa(content.Y),
+				Width:  strconv.Itoa(content.W),
+				Height: strconv.Itoa(content.H),
+			},
+		}},
+	}
+}
+
+// rectBounds returns the box a rect covers. Empty coordinates are read as 0.
+func rectBounds(rect Rect) png2svg.BoundingBox {
+	// rects fit the IaNFTAnalogs schema, which png2svg writes integers for
+	x, _ := strconv.Atoi(rect.Attributes.X)
+	y, _ := strconv.Atoi(rect.Attributes.Y)
+	w, _ := strconv.Atoi(rect.Attributes.Width)
+	h, _ := strconv.Atoi(rect.Attributes.Height)
+	return png2svg.BoundingBox{X: x, Y: y, W: w, H: h}
+}
+
+// ContentBoundingBox returns the bounding box of all the content of the art,
+// which png2svg records when converting with bounding boxes. It is false for
+// art converted without them.
+func (svg *Svg) ContentBoundingBox() (png2svg.BoundingBox, bool) {
+	for _, g := range svg.Children {
+		if !g.IsMetadata() {
+			continue
+		}
+		for _, rect := range g.Children {
+			if rect.Type == BoundingBoxType {
+				return rectBounds(rect), true
+			}
+		}
+	}
+	return png2svg.BoundingBox{}, false
+}
+
+// BoundingBox returns the bounding box of the rects of the group, which is
+// computed instead of stored, since the rects are on chain anyway. Packed
+// groups have to be unpacked first.
+func (g *GElem) BoundingBox() png2svg.BoundingBox {
+	bb := png2svg.BoundingBox{}
+	for _, rect := range g.Children {
+		bb = bb.Union(rectBounds(rect))
+	}
+	return bb
+}
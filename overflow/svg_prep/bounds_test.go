@@ -0,0 +1,69 @@
+package svg_prep
+
+import (
+	"floasis-items/flow/overflow/png2svg"
+	"strings"
+	"testing"
+)
+
+// boundedSvg is png2svg output converted with bounding boxes
+const boundedSvg = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 8 8" data-bbox="1 2 5 4">
+<g fill="#ff0000" data-bbox="1 2 2 1"><rect x="1" y="2" width="2" height="1"/></g>
+<g data-bbox="3 3 3 3"><rect x="3" y="3" width="3" height="3"/></g>
+</svg>`
+
+// TestContentBoundingBox checks that the content bounding box is kept in the
+// metadata after the groups, and written back when rendering
+func TestContentBoundingBox(t *testing.T) {
+	svg, err := ParseSvg(boundedSvg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(svg.Children) != 3 || !svg.Children[2].IsMetadata() {
+		t.Fatalf("expected two groups and the metadata, found %+v", svg.Children)
+	}
+	for i, g := range svg.Children[:2] {
+		if g.Value != "" {
+			t.Errorf("svg > g[%d]: value %q should be empty", i, g.Value)
+		}
+	}
+
+	content, ok := svg.ContentBoundingBox()
+	if !ok || content != (png2svg.BoundingBox{X: 1, Y: 2, W: 5, H: 4}) {
+		t.Errorf("content bounding box is %v, %v", content, ok)
+	}
+	if bb := svg.Children[0].BoundingBox(); bb != (png2svg.BoundingBox{X: 1, Y: 2, W: 2, H: 1}) {
+		t.Errorf("svg > g[0] bounding box is %v", bb)
+	}
+
+	packed := svg.Pack()
+	if !packed.Children[2].IsMetadata() || packed.Children[2].IsPacked() {
+		t.Errorf("the metadata should not be packed")
+	}
+	if bb, ok := packed.ContentBoundingBox(); !ok || bb != content {
+		t.Errorf("packing lost the content bounding box")
+	}
+
+	rendered := string(packed.Bytes())
+	for _, expected := range []string{`style="shape-rendering:crispEdges" data-bbox="1 2 5 4">`, `<g fill="#ff0000" data-bbox="1 2 2 1">`, `<g fill="#000000" data-bbox="3 3 3 3">`} {
+		if !strings.Contains(rendered, expected) {
+			t.Errorf("rendered svg is missing %s:\n%s", expected, rendered)
+		}
+	}
+	if strings.Contains(rendered, MetadataName) {
+		t.Errorf("the metadata should not be rendered as a group:\n%s", rendered)
+	}
+}
+
+func TestNoBoundingBoxWithoutMetadata(t *testing.T) {
+	svg, err := ParseSvg(strings.ReplaceAll(boundedSvg, ` data-bbox="1 2 5 4"`, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := svg.ContentBoundingBox(); ok || len(svg.Children) != 2 {
+		t.Errorf("art converted without bounding boxes should have no metadata")
+	}
+	if strings.Contains(string(svg.Bytes()), png2svg.BoundingBoxAttribute) {
+		t.Errorf("art converted without bounding boxes should render without them")
+	}
+}
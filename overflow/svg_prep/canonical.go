@@ -0,0 +1,67 @@
+package svg_prep
+
+import "fmt"
+
+// DefaultFill is the fill SVG renders when a g or rect has none, which is how
+// png2svg and scour write black
+const DefaultFill = "#000000"
+
+// DefaultCoordinate is the value of a rect coordinate or size that is left out.
+// png2svg strips x="0", y="0", width="0" and height="0".
+const DefaultCoordinate = "0"
+
+// StrippedDefault is an attribute stored as an empty string, which stands for
+// its default value. Path locates the element, like "svg > g[0] > rect[3]".
+type StrippedDefault struct {
+	Path      string
+	Attribute string
+	Default   string
+}
+
+func (stripped StrippedDefault) String() string {
+	return fmt.Sprintf("%s: %s is empty, which stands for %s", stripped.Path, stripped.Attribute, stripped.Default)
+}
+
+// visitDefaults calls visit for every attribute of the artwork that has a
+// default value
+func (svg *Svg) visitDefaults(visit func(path string, attribute string, defaultValue string, value *string)) {
+	for i := range svg.Children {
+		g := &svg.Children[i]
+		if g.IsMetadata() {
+			continue
+		}
+		gPath := fmt.Sprintf("svg > g[%d]", i)
+		visit(gPath, "fill", DefaultFill, &g.Attributes.Fill)
+		for j := range g.Children {
+			attributes := &g.Children[j].Attributes
+			rectPath := fmt.Sprintf("%s > rect[%d]", gPath, j)
+			visit(rectPath, "x", DefaultCoordinate, &attributes.X)
+			visit(rectPath, "y", DefaultCoordinate, &attributes.Y)
+			visit(rectPath, "width", DefaultCoordinate, &attributes.Width)
+			visit(rectPath, "height", DefaultCoordinate, &attributes.Height)
+		}
+	}
+}
+
+// Canonicalize writes explicit values for the attributes png2svg strips, so
+// that consumers of the on-chain structs never have to know that an empty
+// string means 0 or black
+func (svg *Svg) Canonicalize() {
+	svg.visitDefaults(func(path string, attribute string, defaultValue string, value *string) {
+		if *value == "" {
+			*value = defaultValue
+		}
+	})
+}
+
+// StrippedDefaults lists the attributes Canonicalize would fill in, such as
+// those of art that went on chain before canonicalization
+func (svg *Svg) StrippedDefaults() []StrippedDefault {
+	stripped := []StrippedDefault{}
+	svg.visitDefaults(func(path string, attribute string, defaultValue string, value *string) {
+		if *value == "" {
+			stripped = append(stripped, StrippedDefault{Path: path, Attribute: attribute, Default: defaultValue})
+		}
+	})
+	return stripped
+}
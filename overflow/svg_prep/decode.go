@@ -0,0 +1,102 @@
+package svg_prep
+
+import (
+	"floasis-items/flow/overflow/cadence_codec"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onflow/cadence"
+)
+
+// unwrapOptional returns the value inside a (possibly nested) optional
+func unwrapOptional(value cadence.Value) cadence.Value {
+	for {
+		optional, ok := value.(cadence.Optional)
+		if !ok {
+			return value
+		}
+		value = optional.Value
+	}
+}
+
+// DecodeSvg converts an IaNFTAnalogs.Svg value, as returned by a script,
+// back into the Go model
+func DecodeSvg(value cadence.Value) (*Svg, error) {
+	svg := &Svg{}
+	if err := cadence_codec.Unmarshal(value, svg); err != nil {
+		return nil, err
+	}
+	return svg, nil
+}
+
+// FoundSvg is an IaNFTAnalogs.Svg found inside a larger value. Path locates it
+// from the root of that value, like "[0].base" or "[\"main\"].group[\"hat\"]".
+type FoundSvg struct {
+	Path string
+	Svg  *Svg
+}
+
+// isSvgStruct returns true if the value is an IaNFTAnalogs.Svg struct. Structs
+// built by the codec carry the address in the identifier, like
+// "A.0000000000000001.IaNFTAnalogs.Svg", while script results decoded from
+// JSON-Cadence keep it in the location and only have "IaNFTAnalogs.Svg".
+func isSvgStruct(value cadence.Value) bool {
+	s, ok := value.(cadence.Struct)
+	if !ok || s.StructType == nil {
+		return false
+	}
+	identifier := s.StructType.QualifiedIdentifier
+	return identifier == "IaNFTAnalogs.Svg" || strings.HasSuffix(identifier, ".IaNFTAnalogs.Svg")
+}
+
+// FindSvgs collects every IaNFTAnalogs.Svg inside a script result, such as the
+// NFTData array of get_collection_data.cdc or the composite groups of
+// get_nft_composites.cdc. Dictionaries are walked in key order.
+func FindSvgs(value cadence.Value) ([]FoundSvg, error) {
+	found := []FoundSvg{}
+	var walk func(path string, value cadence.Value) error
+	walk = func(path string, value cadence.Value) error {
+		value = unwrapOptional(value)
+		if isSvgStruct(value) {
+			svg, err := DecodeSvg(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			found = append(found, FoundSvg{Path: path, Svg: svg})
+			return nil
+		}
+		switch v := value.(type) {
+		case cadence.Array:
+			for i, element := range v.Values {
+				if err := walk(fmt.Sprintf("%s[%d]", path, i), element); err != nil {
+					return err
+				}
+			}
+		case cadence.Dictionary:
+			pairs := append([]cadence.KeyValuePair{}, v.Pairs...)
+			sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key.String() < pairs[j].Key.String() })
+			for _, pair := range pairs {
+				if err := walk(fmt.Sprintf("%s[%s]", path, pair.Key.String()), pair.Value); err != nil {
+					return err
+				}
+			}
+		case cadence.Struct:
+			if v.StructType == nil {
+				return nil
+			}
+			for i, field := range v.StructType.Fields {
+				if i < len(v.Fields) {
+					if err := walk(path+"."+field.Identifier, v.Fields[i]); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk("", value); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
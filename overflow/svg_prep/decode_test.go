@@ -0,0 +1,128 @@
+package svg_prep
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/onflow/cadence"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime/common"
+)
+
+// testSvgs are parsed into the Svg values FindSvgs is expected to return
+var testSvgs = []string{
+	`<svg viewBox="0 0 8 8"><g fill="#ff0000"><rect width="1" height="1"/></g></svg>`,
+	`<svg viewBox="0 0 8 8"><g fill="#00ff00"><rect x="1" y="2" width="3" height="4"/><rect x="5" width="1" height="1"/></g></svg>`,
+	`<svg viewBox="0 0 8 8"><rect x="2" y="2" width="4" height="4" fill="#0000ff"/></svg>`,
+	`<svg viewBox="0 0 8 8" data-bbox="1 1 2 2"><g fill="#000000"><rect x="1" y="1" width="2" height="2"/></g></svg>`,
+}
+
+// newNFTData nests the svgs in optionals, arrays and dictionaries of a
+// struct, like the results of get_collection_data.cdc and
+// get_nft_composites.cdc
+func newNFTData(svgs []cadence.Value) cadence.Struct {
+	dictionary := func(pairs ...cadence.KeyValuePair) cadence.Dictionary {
+		return cadence.NewDictionary(pairs)
+	}
+	return cadence.NewStruct([]cadence.Value{
+		cadence.UInt64(1),
+		cadence.NewOptional(cadence.NewOptional(svgs[0])),
+		cadence.NewOptional(nil),
+		cadence.NewArray([]cadence.Value{svgs[1], cadence.NewOptional(nil)}),
+		dictionary(
+			cadence.KeyValuePair{Key: cadence.String("main"), Value: dictionary(
+				cadence.KeyValuePair{Key: cadence.String("hat"), Value: svgs[2]},
+			)},
+			cadence.KeyValuePair{Key: cadence.String("body"), Value: cadence.NewArray([]cadence.Value{svgs[3]})},
+		),
+	}).WithType(&cadence.StructType{
+		Location:            common.AddressLocation{Address: common.MustBytesToAddress([]byte{2}), Name: "FLOASISNFT"},
+		QualifiedIdentifier: "FLOASISNFT.NFTData",
+		Fields: []cadence.Field{
+			{Identifier: "id", Type: cadence.UInt64Type{}},
+			{Identifier: "base", Type: cadence.AnyStructType{}},
+			{Identifier: "card", Type: cadence.AnyStructType{}},
+			{Identifier: "layers", Type: cadence.AnyStructType{}},
+			{Identifier: "groups", Type: cadence.AnyStructType{}},
+		},
+	})
+}
+
+func TestFindSvgs(t *testing.T) {
+	converter := NewConverter("0x0000000000000001")
+	expected := []*Svg{}
+	values := []cadence.Value{}
+	for _, svgString := range testSvgs {
+		svg, err := ParseSvg(svgString)
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, err := converter.SvgStruct(svg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected = append(expected, svg)
+		values = append(values, value)
+	}
+	paths := []string{".base", ".layers[0]", `.groups["body"][0]`, `.groups["main"]["hat"]`}
+	order := []int{0, 1, 3, 2} // dictionaries are walked in key order
+
+	data := newNFTData(values)
+	encoded, err := jsoncdc.Encode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := jsoncdc.Decode(nil, encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		value cadence.Value
+		// prefix is the path of the NFTData in the value
+		prefix string
+	}{
+		{"built by the converter", data, ""},
+		{"decoded like a script result", decoded, ""},
+		{"inside an optional array", cadence.NewOptional(cadence.NewArray([]cadence.Value{data})), "[0]"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			found, err := FindSvgs(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(found) != len(paths) {
+				t.Fatalf("expected %d svgs, found %d", len(paths), len(found))
+			}
+			for i, f := range found {
+				if f.Path != test.prefix+paths[i] {
+					t.Errorf("expected path %s, found %s", test.prefix+paths[i], f.Path)
+				}
+				if !reflect.DeepEqual(f.Svg, expected[order[i]]) {
+					t.Errorf("%s: expected %+v, found %+v", f.Path, expected[order[i]], f.Svg)
+				}
+			}
+		})
+	}
+}
+
+func TestFindSvgsErrors(t *testing.T) {
+	// an Svg without its other fields cannot be decoded
+	broken := cadence.NewStruct([]cadence.Value{cadence.String("svg")}).WithType(&cadence.StructType{
+		QualifiedIdentifier: "IaNFTAnalogs.Svg",
+		Fields:              []cadence.Field{{Identifier: "name", Type: cadence.StringType{}}},
+	})
+	_, err := FindSvgs(cadence.NewArray([]cadence.Value{cadence.String("hat"), cadence.NewOptional(broken)}))
+	if err == nil || !strings.HasPrefix(err.Error(), "[1]: ") {
+		t.Errorf("expected an error at [1], found %v", err)
+	}
+
+	// values without svgs, and structs without a type, have nothing to find
+	found, err := FindSvgs(cadence.NewArray([]cadence.Value{cadence.String("hat"), cadence.NewStruct(nil)}))
+	if err != nil || len(found) != 0 {
+		t.Errorf("expected no svgs, found %v %v", found, err)
+	}
+}
@@ -0,0 +1,164 @@
+package svg_prep
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+// PackedRectsType is the type of a GElem whose rects are packed, and of the
+// single Rect child that carries them in its value. The group keeps its own
+// value, and packing needs no change to the IaNFTAnalogs structs.
+// IaNFTAnalogsPacked.unpack expands packed groups back on read.
+//
+// The packed value is the base64 encoding, with the standard alphabet and
+// without padding, of unsigned LEB128 varints, four per rect: x, y, width,
+// height. Coordinates below 128 take a single byte, so a rect takes about 5
+// characters.
+const PackedRectsType = "packed-rects"
+
+// unpackedRect returns a Rect the way GetSvgStruct writes it
+func unpackedRect(x, y, width, height uint64) Rect {
+	return Rect{
+		Name:  "rect",
+		Type:  "type",
+		Value: "value",
+		Attributes: RectAttributes{
+			X:      strconv.FormatUint(x, 10),
+			Y:      strconv.FormatUint(y, 10),
+			Width:  strconv.FormatUint(width, 10),
+			Height: strconv.FormatUint(height, 10),
+		},
+	}
+}
+
+// parseCoordinate reads a rect coordinate, which has to be a non-negative
+// integer to be packed. An empty coordinate stands for 0.
+func parseCoordinate(value string) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// PackRects encodes rects into the value of a packed Rect, see PackedRectsType.
+// Rects with coordinates that are not non-negative integers cannot be packed.
+func PackRects(rects []Rect) (string, error) {
+	buf := make([]byte, 0, len(rects)*4)
+	for i, rect := range rects {
+		for _, value := range []string{rect.Attributes.X, rect.Attributes.Y, rect.Attributes.Width, rect.Attributes.Height} {
+			n, err := parseCoordinate(value)
+			if err != nil {
+				return "", fmt.Errorf("rect[%d]: coordinate %q is not a non-negative integer and cannot be packed", i, value)
+			}
+			buf = binary.AppendUvarint(buf, n)
+		}
+	}
+	return base64.RawStdEncoding.EncodeToString(buf), nil
+}
+
+// UnpackRects decodes the value of a packed Rect, see PackedRectsType
+func UnpackRects(packed string) ([]Rect, error) {
+	data, err := base64.RawStdEncoding.DecodeString(packed)
+	if err != nil {
+		return nil, fmt.Errorf("packed rects: %w", err)
+	}
+	rects := []Rect{}
+	var coordinates [4]uint64
+	for len(data) > 0 {
+		for i := range coordinates {
+			n, size := binary.Uvarint(data)
+			if size <= 0 {
+				return nil, fmt.Errorf("packed rects: rect[%d] is truncated or overflows", len(rects))
+			}
+			coordinates[i] = n
+			data = data[size:]
+		}
+		rects = append(rects, unpackedRect(coordinates[0], coordinates[1], coordinates[2], coordinates[3]))
+	}
+	return rects, nil
+}
+
+// IsPacked returns true if the rects of the group are packed, see PackedRectsType
+func (g *GElem) IsPacked() bool {
+	return g.Type == PackedRectsType
+}
+
+// packedRect returns the Rect that carries packed rects in its value
+func packedRect(packed string) Rect {
+	return Rect{
+		Name:       "rect",
+		Type:       PackedRectsType,
+		Value:      packed,
+		Attributes: RectAttributes{},
+	}
+}
+
+// unpackChildren decodes the packed rects of a packed group. Children of
+// other types are kept as they are.
+func (g *GElem) unpackChildren() ([]Rect, error) {
+	rects := []Rect{}
+	for i, child := range g.Children {
+		if child.Type != PackedRectsType {
+			rects = append(rects, child)
+			continue
+		}
+		unpacked, err := UnpackRects(child.Value)
+		if err != nil {
+			return nil, fmt.Errorf("rect[%d]: %w", i, err)
+		}
+		rects = append(rects, unpacked...)
+	}
+	return rects, nil
+}
+
+// Pack returns a copy of the SVG with the rects of every group packed into a
+// single Rect. Groups with coordinates that cannot be packed, and the
+// metadata, are kept as they are.
+func (svg *Svg) Pack() *Svg {
+	packed := &Svg{Name: svg.Name, Attributes: svg.Attributes, Children: make([]GElem, 0, len(svg.Children))}
+	for _, g := range svg.Children {
+		if g.IsPacked() || g.IsMetadata() {
+			packed.Children = append(packed.Children, g)
+			continue
+		}
+		value, err := PackRects(g.Children)
+		if err != nil {
+			packed.Children = append(packed.Children, g)
+			continue
+		}
+		packed.Children = append(packed.Children, GElem{
+			Name:       g.Name,
+			Type:       PackedRectsType,
+			Value:      g.Value,
+			Attributes: g.Attributes,
+			Children:   []Rect{packedRect(value)},
+		})
+	}
+	return packed
+}
+
+// Unpack returns a copy of the SVG with every packed group expanded back into
+// rects, the same way IaNFTAnalogsPacked.unpack does
+func (svg *Svg) Unpack() (*Svg, error) {
+	unpacked := &Svg{Name: svg.Name, Attributes: svg.Attributes, Children: make([]GElem, 0, len(svg.Children))}
+	for i, g := range svg.Children {
+		if !g.IsPacked() {
+			unpacked.Children = append(unpacked.Children, g)
+			continue
+		}
+		rects, err := g.unpackChildren()
+		if err != nil {
+			return nil, fmt.Errorf("svg > g[%d]: %w", i, err)
+		}
+		unpacked.Children = append(unpacked.Children, GElem{
+			Name:       g.Name,
+			Type:       "element",
+			Value:      g.Value,
+			Attributes: g.Attributes,
+			Children:   rects,
+		})
+	}
+	return unpacked, nil
+}
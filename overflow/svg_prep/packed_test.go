@@ -0,0 +1,118 @@
+package svg_prep
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// artDir holds the accessory artwork at the root of the repository
+var artDir = filepath.Join("..", "..", "art", "accessories", "svg")
+
+func readArt(tb testing.TB) []*Svg {
+	tb.Helper()
+	paths, err := filepath.Glob(filepath.Join(artDir, "*.svg"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if len(paths) == 0 {
+		tb.Skipf("no artwork in %s", artDir)
+	}
+	svgs := []*Svg{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		svg, err := ParseSvg(string(data))
+		if err != nil {
+			tb.Fatalf("%s: %v", path, err)
+		}
+		svgs = append(svgs, svg)
+	}
+	return svgs
+}
+
+// TestPackRoundTrip checks that unpacking restores every group as it was
+func TestPackRoundTrip(t *testing.T) {
+	for _, svg := range readArt(t) {
+		packed := svg.Pack()
+		for i, g := range packed.Children {
+			if !g.IsPacked() || len(g.Children) != 1 || g.Children[0].Type != PackedRectsType {
+				t.Errorf("svg > g[%d] was not packed into a single rect", i)
+			}
+			if g.Value != svg.Children[i].Value {
+				t.Errorf("svg > g[%d]: packing changed the value of the group", i)
+			}
+		}
+
+		unpacked, err := packed.Unpack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range svg.Children {
+			if !reflect.DeepEqual(unpacked.Children[i], svg.Children[i]) {
+				t.Errorf("svg > g[%d] does not round-trip", i)
+			}
+		}
+		if unpacked.Attributes != svg.Attributes {
+			t.Errorf("svg attributes do not round-trip")
+		}
+	}
+}
+
+func TestPackKeepsUnpackableGroups(t *testing.T) {
+	g := newGElem("#ff0000")
+	g.Children = append(g.Children, newRect(map[string]string{"x": "1.5", "y": "0", "width": "1", "height": "1"}))
+	svg := &Svg{Name: "svg", Children: []GElem{g}}
+
+	packed := svg.Pack()
+	if packed.Children[0].IsPacked() || !reflect.DeepEqual(packed.Children[0], g) {
+		t.Errorf("a group with a fractional coordinate should be kept as is")
+	}
+}
+
+func TestUnpackRectsRejectsTruncatedValues(t *testing.T) {
+	packed, err := PackRects([]Rect{unpackedRect(1, 2, 300, 4)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UnpackRects(packed[:len(packed)-2]); err == nil {
+		t.Errorf("expected an error for a truncated value")
+	}
+}
+
+func BenchmarkPackRects(b *testing.B) {
+	groups := [][]Rect{}
+	for _, svg := range readArt(b) {
+		for _, g := range svg.Children {
+			groups = append(groups, g.Children)
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, rects := range groups {
+			if _, err := PackRects(rects); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkUnpackRects(b *testing.B) {
+	values := []string{}
+	for _, svg := range readArt(b) {
+		for _, g := range svg.Pack().Children {
+			values = append(values, g.Children[0].Value)
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, value := range values {
+			if _, err := UnpackRects(value); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package svg_prep
+
+import (
+	"bytes"
+	"encoding/xml"
+	"floasis-items/flow/overflow/png2svg"
+	"os"
+)
+
+// svgNamespace is written when the on-chain xmlns attribute is empty, so that
+// the rendered file is still a valid standalone SVG document
+const svgNamespace = "http://www.w3.org/2000/svg"
+
+// Svg is the Go model of an IaNFTAnalogs.Svg, see cadence_codec for the tags
+type Svg struct {
+	Name       string        `cadence:"name"`
+	Attributes SvgAttributes `cadence:"attributes"`
+	Children   []GElem       `cadence:"children"`
+}
+
+func (Svg) CadenceType() string { return "IaNFTAnalogs.Svg" }
+
+// SvgAttributes is the Go model of an IaNFTAnalogs.SvgAttributes
+type SvgAttributes struct {
+	Width       string `cadence:"width"`
+	Height      string `cadence:"height"`
+	BaseProfile string `cadence:"baseProfile"`
+	Version     string `cadence:"version"`
+	ViewBox     string `cadence:"viewBox"`
+	Xmlns       string `cadence:"xmlns"`
+	Style       string `cadence:"style"`
+}
+
+func (SvgAttributes) CadenceType() string { return "IaNFTAnalogs.SvgAttributes" }
+
+// GElem is the Go model of an IaNFTAnalogs.GElem
+type GElem struct {
+	Name       string          `cadence:"name"`
+	Type       string          `cadence:"type"`
+	Value      string          `cadence:"value"`
+	Attributes GElemAttributes `cadence:"attributes"`
+	Children   []Rect          `cadence:"children"`
+}
+
+func (GElem) CadenceType() string { return "IaNFTAnalogs.GElem" }
+
+// GElemAttributes is the Go model of an IaNFTAnalogs.GElemAttributes
+type GElemAttributes struct {
+	Fill string `cadence:"fill"`
+}
+
+func (GElemAttributes) CadenceType() string { return "IaNFTAnalogs.GElemAttributes" }
+
+// Rect is the Go model of an IaNFTAnalogs.Rect
+type Rect struct {
+	Name       string         `cadence:"name"`
+	Type       string         `cadence:"type"`
+	Value      string         `cadence:"value"`
+	Attributes RectAttributes `cadence:"attributes"`
+}
+
+func (Rect) CadenceType() string { return "IaNFTAnalogs.Rect" }
+
+// RectAttributes is the Go model of an IaNFTAnalogs.RectAttributes
+type RectAttributes struct {
+	X      string `cadence:"x"`
+	Y      string `cadence:"y"`
+	Width  string `cadence:"width"`
+	Height string `cadence:"height"`
+}
+
+func (RectAttributes) CadenceType() string { return "IaNFTAnalogs.RectAttributes" }
+
+// writeAttribute writes ` name="value"`, escaped, unless value is empty
+func writeAttribute(buf *bytes.Buffer, name string, value string) {
+	if value == "" {
+		return
+	}
+	buf.WriteString(" " + name + "=\"")
+	xml.EscapeText(buf, []byte(value))
+	buf.WriteString("\"")
+}
+
+// Bytes renders the SVG as a standalone SVG document. Attributes are written
+// in a fixed order, one element per line, so that renders can be diffed.
+// Empty attributes, such as the zero coordinates png2svg strips, are left out,
+// which is what they stand for in SVG. Packed groups are rendered unpacked.
+// The bounding boxes of art converted with them are written back as data-bbox
+// attributes, see ContentBoundingBox.
+func (svg *Svg) Bytes() []byte {
+	if unpacked, err := svg.Unpack(); err == nil {
+		svg = unpacked
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+
+	attributes := svg.Attributes
+	xmlns := attributes.Xmlns
+	if xmlns == "" {
+		xmlns = svgNamespace
+	}
+	buf.WriteString("<svg")
+	writeAttribute(&buf, "xmlns", xmlns)
+	writeAttribute(&buf, "version", attributes.Version)
+	writeAttribute(&buf, "baseProfile", attributes.BaseProfile)
+	writeAttribute(&buf, "width", attributes.Width)
+	writeAttribute(&buf, "height", attributes.Height)
+	writeAttribute(&buf, "viewBox", attributes.ViewBox)
+	writeAttribute(&buf, "style", attributes.Style)
+	content, hasBoundingBox := svg.ContentBoundingBox()
+	if hasBoundingBox {
+		writeAttribute(&buf, png2svg.BoundingBoxAttribute, content.String())
+	}
+	buf.WriteString(">\n")
+
+	for _, g := range svg.Children {
+		if g.IsMetadata() {
+			continue
+		}
+		buf.WriteString("  <g")
+		writeAttribute(&buf, "fill", g.Attributes.Fill)
+		if hasBoundingBox {
+			if bb := g.BoundingBox(); !bb.Empty() {
+				writeAttribute(&buf, png2svg.BoundingBoxAttribute, bb.String())
+			}
+		}
+		buf.WriteString(">\n")
+		for _, rect := range g.Children {
+			buf.WriteString("    <rect")
+			writeAttribute(&buf, "x", rect.Attributes.X)
+			writeAttribute(&buf, "y", rect.Attributes.Y)
+			writeAttribute(&buf, "width", rect.Attributes.Width)
+			writeAttribute(&buf, "height", rect.Attributes.Height)
+			buf.WriteString("/>\n")
+		}
+		buf.WriteString("  </g>\n")
+	}
+
+	buf.WriteString("</svg>\n")
+	return buf.Bytes()
+}
+
+// WriteSVG saves the rendered SVG document to a file
+func (svg *Svg) WriteSVG(filename string) error {
+	return os.WriteFile(filename, svg.Bytes(), 0644)
+}
@@ -5,12 +5,12 @@ Encapsulates various automation tasks used in go testing and setup scripts.
 package svg_prep
 
 import (
-	"log"
-	"os"
+	"floasis-items/flow/overflow/cadence_codec"
+	"floasis-items/flow/overflow/flow_config"
+	"floasis-items/flow/overflow/png2svg"
+	"fmt"
 	"strings"
 
-	"github.com/joho/godotenv"
-
 	"github.com/JoshVarga/svgparser"
 	"github.com/onflow/cadence"
 )
@@ -24,279 +24,170 @@ func MakeRange(min, max int) []int {
 	return a
 }
 
-func getGElemStruct(ianft_deployer_address string, attributes cadence.Struct, children cadence.Array) cadence.Struct {
-	gSvgChildStruct := cadence.Struct{
-		Fields: []cadence.Value{cadence.String("g"), cadence.String("element"), cadence.String(""), attributes, children},
-		StructType: &cadence.StructType{
-			QualifiedIdentifier: "A." + ianft_deployer_address + ".IaNFTAnalogs.GElem",
-			Fields: []cadence.Field{{
-				Identifier: "name",
-				Type:       cadence.StringType{},
-			}, {
-				Identifier: "type",
-				Type:       cadence.StringType{},
-			}, {
-				Identifier: "value",
-				Type:       cadence.StringType{},
-			}, {
-				Identifier: "attributes",
-				Type:       &cadence.StructType{},
-			}, {
-				Identifier: "children",
-				Type: cadence.VariableSizedArrayType{
-					ElementType: &cadence.StructType{},
-				},
-			}},
-		},
-	}
-	return gSvgChildStruct
+// Converter builds IaNFTAnalogs structs for the IaNFTAnalogs contract
+// deployed at a single address
+type Converter struct {
+	ianftDeployerAddress string
+	codec                *cadence_codec.Codec
+	packRects            bool
 }
 
-func getGElemAttributesStruct(ianft_deployer_address string, fill string) cadence.Struct {
-	gSvgChildAttributesStruct := cadence.Struct{
-		Fields: []cadence.Value{cadence.String(fill)},
-		StructType: &cadence.StructType{
-			QualifiedIdentifier: "A." + ianft_deployer_address + ".IaNFTAnalogs.GElemAttributes",
-			Fields: []cadence.Field{{
-				Identifier: "fill",
-				Type:       cadence.StringType{},
-			}},
-		},
+// NewConverter returns a converter for the IaNFTAnalogs contract at the given
+// address, with or without the 0x prefix
+func NewConverter(ianftDeployerAddress string) *Converter {
+	address := strings.TrimPrefix(ianftDeployerAddress, "0x")
+	return &Converter{
+		ianftDeployerAddress: address,
+		codec:                cadence_codec.NewCodec(map[string]string{"IaNFTAnalogs": address}),
 	}
-	return gSvgChildAttributesStruct
 }
 
-func getRectStruct(ianft_deployer_address string, name string, rectType string, value string, attributes cadence.Struct) cadence.Struct {
-	rectSvgChildStruct := cadence.Struct{
-		Fields: []cadence.Value{cadence.String(name), cadence.String(rectType), cadence.String(value), attributes},
-		StructType: &cadence.StructType{
-			QualifiedIdentifier: "A." + ianft_deployer_address + ".IaNFTAnalogs.Rect",
-			Fields: []cadence.Field{{
-				Identifier: "name",
-				Type:       cadence.StringType{},
-			}, {
-				Identifier: "type",
-				Type:       cadence.StringType{},
-			}, {
-				Identifier: "value",
-				Type:       cadence.StringType{},
-			}, {
-				Identifier: "attributes",
-				Type:       &cadence.StructType{},
-			}},
-		},
+// NewConverterForNetwork resolves the IaNFTAnalogs address for the network
+// ("emulator", "testnet" or "mainnet") from the contract aliases in flow.json
+func NewConverterForNetwork(flowJSONPath string, flowNetwork string) (*Converter, error) {
+	address, err := flow_config.ResolveContractAddress(flowJSONPath, "IaNFTAnalogs", flowNetwork)
+	if err != nil {
+		return nil, err
 	}
-	return rectSvgChildStruct
+	return NewConverter(address), nil
 }
 
-func getRectAttributesStruct(ianft_deployer_address string, x string, y string, width string, height string) cadence.Struct {
-	rectAttributesStruct := cadence.Struct{
-		Fields: []cadence.Value{cadence.String(x), cadence.String(y), cadence.String(width), cadence.String(height)},
-		StructType: &cadence.StructType{
-			QualifiedIdentifier: "A." + ianft_deployer_address + ".IaNFTAnalogs.RectAttributes",
-			Fields: []cadence.Field{{
-				Identifier: "x",
-				Type:       cadence.StringType{},
-			}, {
-				Identifier: "y",
-				Type:       cadence.StringType{},
-			}, {
-				Identifier: "width",
-				Type:       cadence.StringType{},
-			}, {
-				Identifier: "height",
-				Type:       cadence.StringType{},
-			}},
-		},
-	}
-	return rectAttributesStruct
+// SetPackRects can be used to pack the rects of every group into the group's
+// value, which cuts the storage the art takes on chain, see PackedRectsType.
+// Readers have to expand packed art with IaNFTAnalogsPacked.unpack.
+func (converter *Converter) SetPackRects(enabled bool) {
+	converter.packRects = enabled
 }
 
-// func GetSvgStruct(svgString string, metaDataString []string) cadence.Struct {
-func GetSvgStruct(svgString string, flowNetwork string) cadence.Struct {
-	// fmt.Println("META DAT STRING FROM GET SNVG STRUC:", metaDataString)
-
-	err := godotenv.Load(".env")
-	if err != nil {
-		log.Fatal("Error in svg_prep.go when loading .env file")
-	}
-
-	var ianft_deployer_address string
-
-	if flowNetwork == "testnet" {
-		full_deployer_address := os.Getenv("NEXT_PUBLIC_FLOASIS_TESTNET_ACCOUNT")
-		ianft_deployer_address = full_deployer_address[2:]
-	} else {
-		full_deployer_address := os.Getenv("NEXT_PUBLIC_FLOASIS_MAINNET_ACCOUNT")
-		ianft_deployer_address = full_deployer_address[2:]
-	}
+// Address returns the IaNFTAnalogs address the converter builds structs for
+func (converter *Converter) Address() string {
+	return converter.ianftDeployerAddress
+}
 
+// ParseSvg converts an SVG into the Go model of an IaNFTAnalogs.Svg. The SVG is
+// validated against the IaNFTAnalogs schema first, see ValidateSvg, and
+// stripped defaults are written out, see Canonicalize.
+func ParseSvg(svgString string) (*Svg, error) {
 	// CREATE READER FOR THE SVG STRING
 	reader := strings.NewReader(svgString)
 
 	// CREATE THE PARSER ELEMENT FROM THE SVG READER
 	// https://github.com/JoshVarga/svgparser/blob/5eaba627a7d11a384dde3802ac251442e14d87ef/parser.go#L22
-	parentParserElement, _ := svgparser.Parse(reader, false)
+	parentParserElement, err := svgparser.Parse(reader, false)
+	if err != nil {
+		return nil, fmt.Errorf("svg_prep: parsing svg: %w", err)
+	}
+
+	// REJECT ANYTHING THAT DOES NOT FIT THE IaNFTAnalogs SCHEMA, INSTEAD OF DROPPING IT
+	if err := ValidateSvg(parentParserElement); err != nil {
+		return nil, err
+	}
 
 	// PULL OUT A MAP OF THE PARENT SVG'S ATTRIBUTES
 	parentElementAttributes := parentParserElement.Attributes
 
-	// PULL OUT THE PARSER ELEMENT FOR THE CHILDREN OF THE SVG PARENT ELEMENT
-	childrenParserElement := parentParserElement.Children
-	// fmt.Println(reflect.TypeOf(childrenParserElement))
-
-	// // inspect
-	// // https: //stackoverflow.com/questions/27117896/how-to-pretty-print-variables
-	// a, err := json.MarshalIndent(parentParserElement, "", "  ")
-	// if err != nil {
-	// 	fmt.Println("error:", err)
-	// }
-	// fmt.Println("parentParserElement: #####################")
-	// fmt.Print(string(a))
+	// bounding boxes are only recorded for SVGs that png2svg converted with
+	// them. The boxes of the groups are left out, since they follow from the
+	// rects, see GElem.BoundingBox.
+	var metadata []GElem
+	if bbox, ok := parentElementAttributes[png2svg.BoundingBoxAttribute]; ok {
+		content, err := png2svg.ParseBoundingBox(bbox)
+		if err != nil {
+			return nil, SvgErrors{{Path: "svg", Message: err.Error()}}
+		}
+		metadata = append(metadata, newMetadata(content))
+	}
 
-	// MAKE A SINGLE ATTRIBUTES STRUCT FOR THE PARENT SVG
-	svgAttributesStruct := cadence.Struct{
+	svg := &Svg{
+		Name: "svg",
 		// style attribute uses 'shape-rendering' attribute added to correct browser anti-aliazing issue
 		// (lines showing up at different resize values for svg)
-		Fields: []cadence.Value{
-			cadence.String(parentElementAttributes["width"]),
-			cadence.String(parentElementAttributes["height"]),
-			cadence.String(parentElementAttributes["baseProfile"]),
-			cadence.String(parentElementAttributes["version"]),
-			cadence.String(parentElementAttributes["viewBox"]),
-			cadence.String(parentElementAttributes["xmlns"]),
-			cadence.String("shape-rendering:crispEdges"),
-		},
-		StructType: &cadence.StructType{
-			QualifiedIdentifier: "A." + ianft_deployer_address + ".IaNFTAnalogs.SvgAttributes",
-			Fields: []cadence.Field{{
-				Identifier: "width",
-				Type:       cadence.StringType{},
-			}, {
-				Identifier: "height",
-				Type:       cadence.StringType{},
-			}, {
-				Identifier: "baseProfile",
-				Type:       cadence.StringType{},
-			}, {
-				Identifier: "version",
-				Type:       cadence.StringType{},
-			}, {
-				Identifier: "viewBox",
-				Type:       cadence.StringType{},
-			}, {
-				Identifier: "xmlns",
-				Type:       cadence.StringType{},
-			}, {
-				Identifier: "style",
-				Type:       cadence.StringType{},
-			}},
+		Attributes: SvgAttributes{
+			Width:       parentElementAttributes["width"],
+			Height:      parentElementAttributes["height"],
+			BaseProfile: parentElementAttributes["baseProfile"],
+			Version:     parentElementAttributes["version"],
+			ViewBox:     parentElementAttributes["viewBox"],
+			Xmlns:       parentElementAttributes["xmlns"],
+			Style:       "shape-rendering:crispEdges",
 		},
+		Children: []GElem{},
 	}
 
-	// slice of g structs
-	cadenceStructSlice := []cadence.Value{}
-
 	// ITERATE OVER THE PARENT PARSER ELEMENT'S CHILDREN
-	for _, svgChildParserElem := range childrenParserElement {
-		// fmt.Println("$$$$$$$$$$$$$$$$$$$$$$$$$$$$$$$$  G PARSER PARSER ELEM $$$$$$$$$$$$$$$$$$$$$$$$$$$$$$$$$$$")
-		// fmt.Println(svgChildParserElem.Name)
-
-		childElemName := svgChildParserElem.Name
-
-		if childElemName == "g" {
-
-			childElemChildren := svgChildParserElem.Children
-
-			// slice of rect structs
-			rectStructSlice := []cadence.Value{}
-
-			// iterate over each rect parser element child from the g parser element
-			for _, rectParserElem := range childElemChildren {
-				// fmt.Println("$$$$$$$$$$$$$$$$$$$$$$$$$$$$$$$$  RECT PARSER ELEM $$$$$$$$$$$$$$$$$$$$$$$$$$$$$$$$$$$")
-				// fmt.Println(rectParserElem)
-
-				// pull out the attributes for each rect
-				rectAttr := rectParserElem.Attributes
-
-				// create a new rect attributes struct
-				rectAttributesStruct := getRectAttributesStruct(ianft_deployer_address, rectAttr["x"], rectAttr["y"], rectAttr["width"], rectAttr["height"])
-
-				// create a new rect struct
-				rectStruct := getRectStruct(ianft_deployer_address, "rect", "type", "value", rectAttributesStruct)
-
-				// append the slice of rect structs
-				rectStructSlice = append(rectStructSlice, rectStruct)
-			}
-
-			// create the cadence array of rect structs
-			rectStructArray := cadence.NewArray(rectStructSlice)
-
-			// create the g attributes struct
-			// if svgChildParserElem's 'fill' attribute's value is absent, an empty string will be resolved and created for the gSvgChildAttributesStruct
+	for _, svgChildParserElem := range parentParserElement.Children {
+		switch svgChildParserElem.Name {
+		case "g":
+			// if svgChildParserElem's 'fill' attribute's value is absent, an empty string is read for the fill.
 			// for 'rect' element, default value is black: https://developer.mozilla.org/en-US/docs/Web/SVG/Attribute/fill#rect
-			// so empty string as the 'fill' color will render as black.
-			// the svg scour library appears to leave the 'fill' attribute out for black elements
-			gAttributes := getGElemAttributesStruct(ianft_deployer_address, svgChildParserElem.Attributes["fill"])
-
-			// create the g struct
-			gStruct := getGElemStruct(ianft_deployer_address, gAttributes, rectStructArray)
-
-			// add the g struct to the cadenve struct slice just outside of this scope
-			cadenceStructSlice = append(cadenceStructSlice, gStruct)
-		} else if childElemName == "rect" { // some svgs may come in with orphaned rect elements
-			// fmt.Println("%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%%")
-			// fmt.Println(svgChildParserElem.Attributes)
-
+			// the svg scour library appears to leave the 'fill' attribute out for black elements,
+			// so Canonicalize replaces the empty string with DefaultFill below.
+			g := newGElem(svgChildParserElem.Attributes["fill"])
+			for _, rectParserElem := range svgChildParserElem.Children {
+				g.Children = append(g.Children, newRect(rectParserElem.Attributes))
+			}
+			svg.Children = append(svg.Children, g)
+		case "rect": // some svgs may come in with orphaned rect elements
 			rectAttr := svgChildParserElem.Attributes
 
-			// create the g attributes struct using hte fill from the rect
-			gAttributes := getGElemAttributesStruct(ianft_deployer_address, rectAttr["fill"])
-
-			// create a new rect attributes struct
-			rectAttributesStruct := getRectAttributesStruct(ianft_deployer_address, rectAttr["x"], rectAttr["y"], rectAttr["width"], rectAttr["height"])
-
-			// create a new rect struct
-			// rectStruct := getRectStruct("rect", "type", "value", rectAttributesStruct, emptyValueArray)
-			rectStruct := getRectStruct(ianft_deployer_address, "rect", "type", "value", rectAttributesStruct)
+			// the g takes the fill from the rect
+			g := newGElem(rectAttr["fill"])
+			g.Children = append(g.Children, newRect(rectAttr))
+			svg.Children = append(svg.Children, g)
+		}
+	}
 
-			// slice of rect structs
-			rectStructSlice := []cadence.Value{rectStruct}
+	// WRITE OUT THE ZERO COORDINATES AND BLACK FILLS THAT WERE LEFT OUT
+	svg.Canonicalize()
 
-			// create the cadence array of rect structs
-			rectStructArray := cadence.NewArray(rectStructSlice)
+	// THE METADATA GOES AFTER THE GROUPS, WHICH KEEP THEIR INDEXES
+	svg.Children = append(svg.Children, metadata...)
 
-			// create the g struct
-			gStruct := getGElemStruct(ianft_deployer_address, gAttributes, rectStructArray)
+	return svg, nil
+}
 
-			// add the rect struct to the cadenve struct slice just outside of this scope
-			cadenceStructSlice = append(cadenceStructSlice, gStruct)
-		}
+// newGElem returns an empty g
+func newGElem(fill string) GElem {
+	return GElem{
+		Name:       "g",
+		Type:       "element",
+		Value:      "",
+		Attributes: GElemAttributes{Fill: fill},
+		Children:   []Rect{},
 	}
+}
 
-	// add the g structs slice to a cadence array
-	cadenceGStructArray := cadence.NewArray(cadenceStructSlice)
-
-	// create a single/parent svg struct
-	svgStruct := cadence.Struct{
-		Fields: []cadence.Value{cadence.String("svg"), svgAttributesStruct, cadenceGStructArray},
-		StructType: &cadence.StructType{
-			QualifiedIdentifier: "A." + ianft_deployer_address + ".IaNFTAnalogs.Svg",
-			Fields: []cadence.Field{{
-				Identifier: "name",
-				Type:       cadence.StringType{},
-			}, {
-				Identifier: "attributes",
-				Type:       &cadence.StructType{},
-			}, {
-				Identifier: "children",
-				Type: cadence.VariableSizedArrayType{
-					ElementType: &cadence.StructType{},
-				},
-			}},
+func newRect(rectAttr map[string]string) Rect {
+	return Rect{
+		Name:  "rect",
+		Type:  "type",
+		Value: "value",
+		Attributes: RectAttributes{
+			X:      rectAttr["x"],
+			Y:      rectAttr["y"],
+			Width:  rectAttr["width"],
+			Height: rectAttr["height"],
 		},
 	}
+}
 
-	return svgStruct
+// GetSvgStruct converts an SVG into an IaNFTAnalogs.Svg struct, see ParseSvg
+func (converter *Converter) GetSvgStruct(svgString string) (cadence.Struct, error) {
+	svg, err := ParseSvg(svgString)
+	if err != nil {
+		return cadence.Struct{}, err
+	}
+	return converter.SvgStruct(svg)
+}
 
+// SvgStruct converts the Go model of an SVG into an IaNFTAnalogs.Svg struct
+func (converter *Converter) SvgStruct(svg *Svg) (cadence.Struct, error) {
+	if converter.packRects {
+		svg = svg.Pack()
+	}
+	value, err := converter.codec.Marshal(*svg)
+	if err != nil {
+		return cadence.Struct{}, err
+	}
+	return value.(cadence.Struct), nil
 }
@@ -0,0 +1,206 @@
+package svg_prep
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/JoshVarga/svgparser"
+)
+
+// SvgError points at the element of an SVG that does not fit the IaNFTAnalogs schema.
+// Path locates the element from the root, like "svg > g[2] > rect[14]", with
+// indices counting the element's siblings of the same name from 0.
+type SvgError struct {
+	Path    string
+	Message string
+}
+
+func (err SvgError) Error() string {
+	return err.Path + ": " + err.Message
+}
+
+// SvgErrors collects every problem found in a single SVG
+type SvgErrors []SvgError
+
+func (errs SvgErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d problem(s) in svg:\n  %s", len(errs), strings.Join(messages, "\n  "))
+}
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// namedColors are the SVG color keywords. png2svg shortens some hex colors to these names.
+var namedColors = map[string]bool{}
+
+func init() {
+	for _, name := range strings.Fields(`
+		aliceblue antiquewhite aqua aquamarine azure beige bisque black blanchedalmond blue
+		blueviolet brown burlywood cadetblue chartreuse chocolate coral cornflowerblue cornsilk
+		crimson cyan darkblue darkcyan darkgoldenrod darkgray darkgreen darkgrey darkkhaki
+		darkmagenta darkolivegreen darkorange darkorchid darkred darksalmon darkseagreen
+		darkslateblue darkslategray darkslategrey darkturquoise darkviolet deeppink deepskyblue
+		dimgray dimgrey dodgerblue firebrick floralwhite forestgreen fuchsia gainsboro ghostwhite
+		gold goldenrod gray grey green greenyellow honeydew hotpink indianred indigo ivory khaki
+		lavender lavenderblush lawngreen lemonchiffon lightblue lightcoral lightcyan
+		lightgoldenrodyellow lightgray lightgreen lightgrey lightpink lightsalmon lightseagreen
+		lightskyblue lightslategray lightslategrey lightsteelblue lightyellow lime limegreen linen
+		magenta maroon mediumaquamarine mediumblue mediumorchid mediumpurple mediumseagreen
+		mediumslateblue mediumspringgreen mediumturquoise mediumvioletred midnightblue mintcream
+		mistyrose moccasin navajowhite navy oldlace olive olivedrab orange orangered orchid
+		palegoldenrod palegreen paleturquoise palevioletred papayawhip peachpuff peru pink plum
+		powderblue purple red rosybrown royalblue saddlebrown salmon sandybrown seagreen seashell
+		sienna silver skyblue slateblue slategray slategrey snow springgreen steelblue tan teal
+		thistle tomato turquoise violet wheat white whitesmoke yellow yellowgreen`) {
+		namedColors[name] = true
+	}
+}
+
+// validFill returns true for the fill values the front end can render and recolor.
+// An empty fill is allowed, since png2svg leaves it out for black.
+func validFill(fill string) bool {
+	return fill == "" || hexColorPattern.MatchString(fill) || namedColors[strings.ToLower(fill)]
+}
+
+// viewBox is the user coordinate system rects have to fit in
+type viewBox struct {
+	minX, minY, width, height float64
+}
+
+func parseViewBox(value string) (viewBox, error) {
+	fields := strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == ',' })
+	if len(fields) != 4 {
+		return viewBox{}, fmt.Errorf("viewBox %q must have four numbers", value)
+	}
+	var numbers [4]float64
+	for i, field := range fields {
+		n, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return viewBox{}, fmt.Errorf("viewBox %q must have four numbers", value)
+		}
+		numbers[i] = n
+	}
+	if numbers[2] <= 0 || numbers[3] <= 0 {
+		return viewBox{}, fmt.Errorf("viewBox %q must have a positive width and height", value)
+	}
+	return viewBox{numbers[0], numbers[1], numbers[2], numbers[3]}, nil
+}
+
+// validator walks a parsed SVG and collects every schema violation
+type validator struct {
+	viewBox viewBox
+	errs    SvgErrors
+}
+
+func (v *validator) fail(path string, format string, args ...interface{}) {
+	v.errs = append(v.errs, SvgError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// childPaths names every child by its position among the siblings of the same name
+func childPaths(parentPath string, children []*svgparser.Element) []string {
+	counts := map[string]int{}
+	paths := make([]string, len(children))
+	for i, child := range children {
+		paths[i] = fmt.Sprintf("%s > %s[%d]", parentPath, child.Name, counts[child.Name])
+		counts[child.Name]++
+	}
+	return paths
+}
+
+// checkRect validates the coordinates of a rect. Missing coordinates are 0,
+// since png2svg strips zero values.
+func (v *validator) checkRect(path string, rect *svgparser.Element) {
+	numbers := map[string]float64{}
+	for _, name := range []string{"x", "y", "width", "height"} {
+		value := rect.Attributes[name]
+		if value == "" {
+			numbers[name] = 0
+			continue
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			v.fail(path, "%s %q is not a number", name, value)
+			return
+		}
+		if n < 0 {
+			v.fail(path, "%s %q is negative", name, value)
+			return
+		}
+		numbers[name] = n
+	}
+
+	vb := v.viewBox
+	if numbers["x"] < vb.minX || numbers["y"] < vb.minY ||
+		numbers["x"]+numbers["width"] > vb.minX+vb.width ||
+		numbers["y"]+numbers["height"] > vb.minY+vb.height {
+		v.fail(path, "rect at %v,%v of size %vx%v is outside of the viewBox %v %v %v %v",
+			numbers["x"], numbers["y"], numbers["width"], numbers["height"], vb.minX, vb.minY, vb.width, vb.height)
+	}
+
+	if len(rect.Children) > 0 {
+		v.fail(path, "rect must not have child elements, found <%s>", rect.Children[0].Name)
+	}
+}
+
+func (v *validator) checkFill(path string, element *svgparser.Element) {
+	if fill := element.Attributes["fill"]; !validFill(fill) {
+		v.fail(path, "fill %q is not a hex color or a color name", fill)
+	}
+}
+
+func (v *validator) checkGElem(path string, g *svgparser.Element) {
+	v.checkFill(path, g)
+	for i, rectPath := range childPaths(path, g.Children) {
+		child := g.Children[i]
+		if child.Name != "rect" {
+			v.fail(rectPath, "only rect is supported inside g, nesting beyond g > rect is not part of the IaNFTAnalogs schema")
+			continue
+		}
+		if fill, ok := child.Attributes["fill"]; ok && fill != g.Attributes["fill"] {
+			v.fail(rectPath, "rect fill %q differs from the fill of its g, which would be lost", fill)
+		}
+		v.checkRect(rectPath, child)
+	}
+}
+
+// ValidateSvg checks that a parsed SVG fits the IaNFTAnalogs schema: an svg
+// root with a viewBox, and only g elements of rects, or orphaned rects, below it.
+// Rect coordinates must be numbers inside the viewBox, and fills must be colors.
+func ValidateSvg(root *svgparser.Element) error {
+	if root == nil || root.Name != "svg" {
+		name := ""
+		if root != nil {
+			name = root.Name
+		}
+		return SvgErrors{{Path: "/", Message: fmt.Sprintf("root element must be <svg>, found <%s>", name)}}
+	}
+
+	v := validator{}
+	vb, err := parseViewBox(root.Attributes["viewBox"])
+	if err != nil {
+		return SvgErrors{{Path: "svg", Message: err.Error()}}
+	}
+	v.viewBox = vb
+
+	for i, path := range childPaths("svg", root.Children) {
+		child := root.Children[i]
+		switch child.Name {
+		case "g":
+			v.checkGElem(path, child)
+		case "rect":
+			v.checkFill(path, child)
+			v.checkRect(path, child)
+		default:
+			v.fail(path, "<%s> is not supported, only g and rect are part of the IaNFTAnalogs schema", child.Name)
+		}
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package svg_prep
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/JoshVarga/svgparser"
+)
+
+func TestValidateSvg(t *testing.T) {
+	tests := []struct {
+		name     string
+		svg      string
+		expected []string
+	}{
+		{
+			"valid",
+			`<svg viewBox="0 0 8 8"><g fill="#f00"><rect x="0" y="0" width="8" height="8"/><rect width="1" height="1" fill="#f00"/></g><g><rect x="1"/></g><rect fill="Navy" width="1" height="1"/></svg>`,
+			nil,
+		},
+		{
+			"not an svg",
+			`<g/>`,
+			[]string{"/: root element must be <svg>, found <g>"},
+		},
+		{
+			"viewBox",
+			`<svg viewBox="0 0 8"></svg>`,
+			[]string{`svg: viewBox "0 0 8" must have four numbers`},
+		},
+		{
+			"empty viewBox",
+			`<svg viewBox="0 0 0 8"></svg>`,
+			[]string{`svg: viewBox "0 0 0 8" must have a positive width and height`},
+		},
+		{
+			"coordinates",
+			`<svg viewBox="0 0 8 8"><g><rect x="1"/><rect x="one"/></g><g><rect y="-1" width="1" height="1"/></g><rect height="1e"/></svg>`,
+			[]string{
+				`svg > g[0] > rect[1]: x "one" is not a number`,
+				`svg > g[1] > rect[0]: y "-1" is negative`,
+				`svg > rect[0]: height "1e" is not a number`,
+			},
+		},
+		{
+			"outside of the viewBox",
+			`<svg viewBox="2 2 8 8"><g><rect x="2" y="2" width="8" height="8"/><rect x="1" y="2" width="1" height="1"/></g><rect x="9" y="9" width="2" height="1"/></svg>`,
+			[]string{
+				"svg > g[0] > rect[1]: rect at 1,2 of size 1x1 is outside of the viewBox 2 2 8 8",
+				"svg > rect[0]: rect at 9,9 of size 2x1 is outside of the viewBox 2 2 8 8",
+			},
+		},
+		{
+			"fills",
+			`<svg viewBox="0 0 8 8"><g fill="#ff000"><rect/></g><g fill="#ff0000"><rect fill="#00ff00"/><rect fill="#ff0000"/></g><g><rect fill="red"/></g><rect fill="url(#a)"/></svg>`,
+			[]string{
+				`svg > g[0]: fill "#ff000" is not a hex color or a color name`,
+				`svg > g[1] > rect[0]: rect fill "#00ff00" differs from the fill of its g, which would be lost`,
+				`svg > g[2] > rect[0]: rect fill "red" differs from the fill of its g, which would be lost`,
+				`svg > rect[0]: fill "url(#a)" is not a hex color or a color name`,
+			},
+		},
+		{
+			"unsupported elements",
+			`<svg viewBox="0 0 8 8"><rect/><circle r="1"/><path d="M0 0"/><circle r="2"/></svg>`,
+			[]string{
+				"svg > circle[0]: <circle> is not supported, only g and rect are part of the IaNFTAnalogs schema",
+				"svg > path[0]: <path> is not supported, only g and rect are part of the IaNFTAnalogs schema",
+				"svg > circle[1]: <circle> is not supported, only g and rect are part of the IaNFTAnalogs schema",
+			},
+		},
+		{
+			"nested groups",
+			`<svg viewBox="0 0 8 8"><g><rect/><g><rect/></g><rect><title>hat</title></rect></g></svg>`,
+			[]string{
+				"svg > g[0] > g[0]: only rect is supported inside g, nesting beyond g > rect is not part of the IaNFTAnalogs schema",
+				"svg > g[0] > rect[1]: rect must not have child elements, found <title>",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, err := svgparser.Parse(strings.NewReader(test.svg), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = ValidateSvg(root)
+			if test.expected == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			var errs SvgErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("expected SvgErrors, found %v", err)
+			}
+			actual := []string{}
+			for _, e := range errs {
+				actual = append(actual, e.Error())
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected errors\n  %s\nfound\n  %s", strings.Join(test.expected, "\n  "), strings.Join(actual, "\n  "))
+			}
+		})
+	}
+}
@@ -3,76 +3,148 @@ package main
 import (
 	"floasis-items/flow/overflow/art_prep"
 	"floasis-items/flow/overflow/inventory_prep"
+	"floasis-items/flow/overflow/ipfs_pin"
+	"floasis-items/flow/overflow/manifest"
+	"floasis-items/flow/overflow/tx_args"
+	"floasis-items/flow/overflow/tx_batch"
+	"log"
+	"os"
+	"path/filepath"
 
 	o "github.com/bjartek/overflow"
+	"github.com/onflow/cadence"
 )
 
+// statePath is where the progress of setup is recorded under state
+func statePath(flowNetwork string, state string) string {
+	return filepath.Join("batch_state", flowNetwork, state+".json")
+}
+
+// runSteps sends the single transactions a batch depends on, once. They are
+// recorded in ./batch_state under state, like the chunks of the batch.
+func runSteps(flowNetwork string, state string, steps []tx_batch.Step, send tx_batch.StepSender) {
+	if err := tx_batch.RunSteps(statePath(flowNetwork, state), steps, send); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// sendBatch sends a batch transaction in chunks within the Flow limits. The
+// chunks that went through are recorded in ./batch_state under state, and
+// running setup again after a failure resumes with the next chunk. The ids of
+// the createdEvent events of every chunk are recorded with it and returned.
+func sendBatch(c *o.OverflowState, flowNetwork string, name string, state string, args tx_args.Args, createdEvent string) []uint64 {
+	code, err := os.ReadFile(filepath.Join("transactions", name+".cdc"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	batch, err := tx_batch.NewBatch(name, code, args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	path := statePath(flowNetwork, state)
+	if err := batch.Run(path, tx_batch.DefaultLimits(), tx_batch.OverflowSender(c, "account", createdEvent)); err != nil {
+		log.Fatal(err)
+	}
+	sent, err := tx_batch.LoadState(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return sent.CreatedIDs()
+}
+
 func main() {
 
 	flow_network := "testnet"
 	c := o.Overflow(o.WithNetwork(flow_network))
 
-	artist_name := "floasis-items-official"
-	series_name := "series0"
-
-	// service account adds artist to art libarary in FLOASISItemsStore
-	c.Tx(
-		"FLOASISItemsStore/add_artist_to_art_library",
-		o.WithArg("artistName", artist_name),
-		o.WithArg("artistAddress", "account"),
-		o.WithSigner("account")).
-		Print()
-
-	// service account adds series to art libarary in FLOASISItemsStore
-	c.Tx(
-		"FLOASISItemsStore/add_series",
-		o.WithArg("artistName", artist_name),
-		o.WithArg("seriesName", series_name),
-		o.WithSigner("account")).
-		Print()
-
-	// upload on-chain artwork to art library
-	artNamesItems, planetNamesItems, baseArtworkItems, cardArtworkItems, artDescriptionsItems, artThumbnailsItems, artThumbnailPathsItems := art_prep.PrepareArt("./art/accessories", "art_list.csv", flow_network)
-	c.Tx(
-		"FLOASISItemsStore/batch_add_art_to_artLibrary",
-		o.WithArg("artistName", artist_name),
-		o.WithArg("seriesName", series_name),
-		o.WithArg("artNames", artNamesItems),
-		o.WithArg("planetNames", planetNamesItems),
-		o.WithArg("baseArtwork", baseArtworkItems),
-		o.WithArg("cardArtwork", cardArtworkItems),
-		o.WithArg("artDescriptions", artDescriptionsItems),
-		o.WithArg("artThumbnails", artThumbnailsItems),
-		o.WithArg("artThumbnailPaths", artThumbnailPathsItems),
-		o.WithSigner("account")).
-		Print()
-
-	// create inventory items
-	item_names, item_descriptions, item_thumbnails, item_thumbnail_paths, item_quantities, item_prices, item_artist_names, item_series_names, item_art_names, item_art_categories := inventory_prep.PrepareInventory("./art/accessories/store_inventory_list.csv", "./art/accessories", flow_network)
-	c.Tx(
-		"FLOASISItemsStore/batch_add_inventory",
-		o.WithArg("itemNames", item_names),
-		o.WithArg("itemDescriptions", item_descriptions),
-		o.WithArg("itemCategories", item_art_categories),
-		o.WithArg("itemThumbnails", item_thumbnails),
-		o.WithArg("itemThumbnailPaths", item_thumbnail_paths),
-		o.WithArg("itemQuantities", item_quantities),
-		o.WithArg("itemPrices", item_prices),
-		o.WithArg("itemArtistNames", item_artist_names),
-		o.WithArg("itemSeriesNames", item_series_names),
-		o.WithArg("itemArtNames", item_art_names),
-		o.WithArg("allPaymentsRecipient", "account"),
-		o.WithArg("allRoyaltiesRecipient", "account"),
-		o.WithSigner("account")).
-		Print()
-
-	inventory_item_ids := []uint64{0, 1, 2}
+	// thumbnails pinned so far, shared by the art and the inventory
+	pin_lock_path := filepath.Join("./art/accessories", ipfs_pin.LockFileName)
+
+	// the series of the art library, with their art, and the inventory are
+	// checked against each other, so that a typo does not panic on-chain
+	library, err := manifest.LoadLibrary("./art/accessories/series_list.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	inventory_entries, err := manifest.LoadInventoryEntries("./art/accessories/store_inventory_list.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	report := manifest.CheckReferences(library, inventory_entries)
+	for _, unused := range report.Unused {
+		log.Printf("warning: %v", unused)
+	}
+	if err := report.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	// prepare the artwork and inventory and pin their thumbnails before
+	// sending anything, so that a failed upload leaves the store untouched
+	art_batches := []art_prep.ArtBatch{}
+	for _, series := range library.Series {
+		art, err := art_prep.PrepareArt("./art/accessories", series.Art, flow_network)
+		if err != nil {
+			log.Fatal(err)
+		}
+		art_batches = append(art_batches, art_prep.ArtBatch{ArtistName: series.Series.ArtistName, SeriesName: series.Series.SeriesName, Art: art})
+	}
+	items, err := inventory_prep.PrepareInventory("./art/accessories", inventory_entries)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, art_batch := range art_batches {
+		if err := art_prep.PinThumbnails(art_batch.Art, pin_lock_path); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := inventory_prep.PinThumbnails(items, pin_lock_path); err != nil {
+		log.Fatal(err)
+	}
+
+	store_address := cadence.Address(c.Account("account").Address())
+
+	added_artists := map[string]bool{}
+	for _, art_batch := range art_batches {
+		state := "batch_add_art_to_artLibrary-" + art_batch.ArtistName + "-" + art_batch.SeriesName
+
+		// service account adds artist and series to art libarary in
+		// FLOASISItemsStore, recorded with the art so that they are not
+		// added twice when setup resumes
+		steps := []tx_batch.Step{}
+		if !added_artists[art_batch.ArtistName] {
+			steps = append(steps, tx_batch.Step{
+				Name: "FLOASISItemsStore/add_artist_to_art_library",
+				Args: tx_args.Args{"artistName": cadence.String(art_batch.ArtistName), "artistAddress": store_address},
+			})
+			added_artists[art_batch.ArtistName] = true
+		}
+		steps = append(steps, tx_batch.Step{
+			Name: "FLOASISItemsStore/add_series",
+			Args: tx_args.Args{"artistName": cadence.String(art_batch.ArtistName), "seriesName": cadence.String(art_batch.SeriesName)},
+		})
+		runSteps(flow_network, state, steps, tx_batch.OverflowStepSender(c, "account"))
+
+		// upload on-chain artwork to art library
+		sendBatch(c, flow_network, "FLOASISItemsStore/batch_add_art_to_artLibrary", state, art_batch.Args(), "")
+	}
+
+	// create inventory items, paying the store account
+	inventory_batch := inventory_prep.InventoryBatch{Items: items, AllPaymentsRecipient: store_address, AllRoyaltiesRecipient: store_address}
+	created_ids := sendBatch(c, flow_network, "FLOASISItemsStore/batch_add_inventory", "batch_add_inventory", inventory_batch.Args(), "InventoryItemCreated")
+	if len(created_ids) != len(items) {
+		log.Fatalf("batch_add_inventory recorded %d created inventory items for %d items, see %s; remove it and the items to start over",
+			len(created_ids), len(items), statePath(flow_network, "batch_add_inventory"))
+	}
+
+	inventory_item_ids := []cadence.Value{}
+	for _, id := range created_ids {
+		inventory_item_ids = append(inventory_item_ids, cadence.UInt64(id))
+	}
 
 	// batch mark inventory items active
-	c.Tx(
-		"FLOASISItemsStore/batch_set_inventory_active",
-		o.WithArg("inventoryItemIDs", inventory_item_ids),
-		o.WithSigner("account")).
-		Print()
+	runSteps(flow_network, "batch_add_inventory", []tx_batch.Step{{
+		Name: "FLOASISItemsStore/batch_set_inventory_active",
+		Args: tx_args.Args{"inventoryItemIDs": cadence.NewArray(inventory_item_ids)},
+	}}, tx_batch.OverflowStepSender(c, "account"))
 
 }
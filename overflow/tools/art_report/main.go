@@ -0,0 +1,72 @@
+/*
+Reports conversion statistics and the estimated on-chain cost of the art of
+every series of series_list.csv, one row per art with its base and card art
+added up, and exits with status 1 if any art is over budget. With -svg, every
+SVG of a directory is reported on its own instead, leaving out thumbnails.
+Nothing is read from the network.
+
+	go run ./overflow/tools/art_report -max-storage 20000
+	go run ./overflow/tools/art_report -json > report.json
+	go run ./overflow/tools/art_report -pack
+	go run ./overflow/tools/art_report -svg ./art/accessories/svg
+*/
+
+package main
+
+import (
+	"flag"
+	"floasis-items/flow/overflow/art_report"
+	"floasis-items/flow/overflow/manifest"
+	"fmt"
+	"log"
+	"os"
+)
+
+func main() {
+	var budgets art_report.Budgets
+
+	seriesListPath := flag.String("series", "./art/accessories/series_list.csv", "series list naming the art list of every series")
+	svgDirPath := flag.String("svg", "", "report on every SVG of this directory instead of the art of the series")
+	asJSON := flag.Bool("json", false, "write the report as JSON instead of a table")
+	packRects := flag.Bool("pack", false, "estimate costs with the rects of every group packed")
+	flag.IntVar(&budgets.MaxRects, "max-rects", 0, "maximum rects per art, 0 for no limit")
+	flag.IntVar(&budgets.MaxGroups, "max-groups", 0, "maximum color groups per art, 0 for no limit")
+	flag.IntVar(&budgets.MaxColors, "max-colors", 0, "maximum unique colors per art, 0 for no limit")
+	flag.IntVar(&budgets.MaxSVGBytes, "max-svg", 0, "maximum SVG bytes per art, 0 for no limit")
+	flag.IntVar(&budgets.MaxArgumentBytes, "max-arg", 0, "maximum encoded Cadence argument bytes per art, 0 for no limit")
+	flag.IntVar(&budgets.MaxStorageBytes, "max-storage", 0, "maximum estimated storage bytes per minted NFT, 0 for no limit")
+	flag.Parse()
+
+	converter := art_report.NewConverter()
+	converter.SetPackRects(*packRects)
+
+	var report *art_report.Report
+	if *svgDirPath != "" {
+		var err error
+		report, err = art_report.AnalyzeDir(*svgDirPath, converter, budgets)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		library, err := manifest.LoadLibrary(*seriesListPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		report = art_report.AnalyzeLibrary(library, converter, budgets)
+	}
+
+	var err error
+	if *asJSON {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteTable(os.Stdout)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if overBudget := report.OverBudget(); len(overBudget) > 0 {
+		fmt.Fprintf(os.Stderr, "%d item(s) over budget\n", len(overBudget))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Backs up the artwork NFTs hold on-chain, including owner recolors and
+composites, as SVG files that can be inspected and diffed.
+
+	go run ./overflow/tools/backup_art -address 0x01cf0e2f2f715450
+	go run ./overflow/tools/backup_art -address 0x01cf0e2f2f715450 -floasis-nft-id 42
+*/
+
+package main
+
+import (
+	"flag"
+	"floasis-items/flow/overflow/svg_prep"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	o "github.com/bjartek/overflow"
+)
+
+var unsafeFileNameCharacters = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// fileName turns the path of an SVG inside a script result into a file name
+func fileName(prefix string, path string) string {
+	name := strings.Trim(unsafeFileNameCharacters.ReplaceAllString(path, "_"), "_.")
+	if name == "" {
+		return prefix + ".svg"
+	}
+	return prefix + "_" + name + ".svg"
+}
+
+func main() {
+	flowNetwork := flag.String("network", "testnet", "network to read from")
+	address := flag.String("address", "", "address of the collection owner")
+	floasisNFTID := flag.Int64("floasis-nft-id", -1, "back up the composites of this FLOASIS NFT instead of the FLOASIS Items collection")
+	outDirPath := flag.String("out", "./art/backup", "directory the SVGs are written to")
+	flag.Parse()
+
+	if *address == "" {
+		log.Fatal("-address is required")
+	}
+
+	c := o.Overflow(o.WithNetwork(*flowNetwork))
+
+	var result *o.OverflowScriptResult
+	var prefix string
+	if *floasisNFTID >= 0 {
+		prefix = fmt.Sprintf("floasis-nft-%d-composites", *floasisNFTID)
+		result = c.Script(
+			"FLOASISNFT/get_nft_composites",
+			o.WithArg("address", *address),
+			o.WithArg("nftID", uint64(*floasisNFTID)))
+	} else {
+		prefix = "floasis-items"
+		result = c.Script(
+			"FLOASISItems/get_collection_data",
+			o.WithArg("address", *address))
+	}
+	if result.Err != nil {
+		log.Fatal(result.Err)
+	}
+
+	found, err := svg_prep.FindSvgs(result.Result)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.MkdirAll(*outDirPath, 0755); err != nil {
+		log.Fatal(err)
+	}
+	for _, f := range found {
+		svg_file_path := filepath.Join(*outDirPath, fileName(prefix, f.Path))
+		if err := f.Svg.WriteSVG(svg_file_path); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("on-chain svg written to:", svg_file_path)
+	}
+}
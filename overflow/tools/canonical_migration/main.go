@@ -0,0 +1,92 @@
+/*
+Reports on-chain art that was stored before canonicalization, with empty
+strings standing for zero coordinates and black fills, see
+svg_prep.Canonicalize. The store inventory is always checked, collections
+are checked for every -address given.
+
+	go run ./overflow/tools/canonical_migration -network testnet
+	go run ./overflow/tools/canonical_migration -network testnet -address 0x01cf0e2f2f715450 -v
+*/
+
+package main
+
+import (
+	"flag"
+	"floasis-items/flow/overflow/svg_prep"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	o "github.com/bjartek/overflow"
+)
+
+// addresses collects repeated -address flags
+type addresses []string
+
+func (a *addresses) String() string {
+	return strings.Join(*a, ",")
+}
+
+func (a *addresses) Set(value string) error {
+	*a = append(*a, value)
+	return nil
+}
+
+// source is a script result that holds art
+type source struct {
+	name   string
+	result *o.OverflowScriptResult
+}
+
+func main() {
+	flowNetwork := flag.String("network", "testnet", "network to read from")
+	var collectionAddresses addresses
+	flag.Var(&collectionAddresses, "address", "also check the FLOASIS Items collection of this address, may be repeated")
+	verbose := flag.Bool("v", false, "list every empty attribute, not just the count per svg")
+	flag.Parse()
+
+	c := o.Overflow(o.WithNetwork(*flowNetwork))
+
+	sources := []source{
+		{"active inventory", c.Script("FLOASISItemsStore/get_all_active_inventory")},
+		{"inactive inventory", c.Script("FLOASISItemsStore/get_all_inactive_inventory")},
+	}
+	for _, address := range collectionAddresses {
+		sources = append(sources, source{
+			"collection " + address,
+			c.Script("FLOASISItems/get_collection_data", o.WithArg("address", address)),
+		})
+	}
+
+	svgCount, affectedCount, attributeCount := 0, 0, 0
+	for _, s := range sources {
+		if s.result.Err != nil {
+			log.Fatalf("%s: %s", s.name, s.result.Err)
+		}
+		found, err := svg_prep.FindSvgs(s.result.Result)
+		if err != nil {
+			log.Fatalf("%s: %s", s.name, err)
+		}
+		for _, f := range found {
+			svgCount++
+			stripped := f.Svg.StrippedDefaults()
+			if len(stripped) == 0 {
+				continue
+			}
+			affectedCount++
+			attributeCount += len(stripped)
+			fmt.Printf("%s %s: %d empty attribute(s)\n", s.name, f.Path, len(stripped))
+			if *verbose {
+				for _, attribute := range stripped {
+					fmt.Println("  " + attribute.String())
+				}
+			}
+		}
+	}
+
+	fmt.Printf("%d of %d svg(s) store %d empty attribute(s) that need migration\n", affectedCount, svgCount, attributeCount)
+	if affectedCount > 0 {
+		os.Exit(1)
+	}
+}
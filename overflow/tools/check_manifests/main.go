@@ -0,0 +1,66 @@
+/*
+Checks the manifests of an art repo against each other before anything is
+sent: every inventory item has to name an artist and series of
+series_list.csv and art of the art list of that series, and art names have to
+be unique within a series, and every item has to be in a category of
+categories.csv. Art no item mints is reported too, and fails the check with
+-strict.
+
+	go run ./overflow/tools/check_manifests
+	go run ./overflow/tools/check_manifests -series ./art/accessories/series_list.csv -inventory ./art/accessories/store_inventory_list.csv -strict
+*/
+
+package main
+
+import (
+	"flag"
+	"floasis-items/flow/overflow/manifest"
+	"fmt"
+	"os"
+)
+
+func main() {
+	seriesPath := flag.String("series", "./art/accessories/series_list.csv", "series list naming the art list of every series")
+	inventoryPath := flag.String("inventory", "./art/accessories/store_inventory_list.csv", "inventory manifest")
+	categoriesPath := flag.String("categories", "./art/accessories/"+manifest.CategoriesFileName, "category taxonomy")
+	strict := flag.Bool("strict", false, "fail on art no inventory item mints")
+	flag.Parse()
+
+	// both manifests are read before failing, so that every problem is
+	// reported in one run
+	library, libraryErr := manifest.LoadLibrary(*seriesPath)
+	inventory, inventoryErr := manifest.LoadInventoryEntries(*inventoryPath)
+	taxonomy, taxonomyErr := manifest.LoadTaxonomy(*categoriesPath)
+	if libraryErr != nil || inventoryErr != nil || taxonomyErr != nil {
+		for _, err := range []error{libraryErr, inventoryErr, taxonomyErr} {
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+		os.Exit(1)
+	}
+
+	report := manifest.CheckReferences(library, inventory)
+	for _, unused := range report.Unused {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", unused)
+	}
+	categoriesErr := taxonomy.CheckInventory(inventory)
+	if report.Err() != nil || categoriesErr != nil {
+		for _, err := range []error{report.Err(), categoriesErr} {
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+		os.Exit(1)
+	}
+	if *strict && len(report.Unused) > 0 {
+		fmt.Fprintf(os.Stderr, "%d art not minted by any inventory item\n", len(report.Unused))
+		os.Exit(1)
+	}
+
+	art := 0
+	for _, series := range library.Series {
+		art += len(series.Art)
+	}
+	fmt.Printf("%d series, %d art and %d inventory items check out\n", len(library.Series), art, len(inventory))
+}
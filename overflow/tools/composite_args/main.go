@@ -0,0 +1,127 @@
+/*
+Writes the arguments of FLOASISNFT/composite_multiple_layers for a FLOASIS
+NFT wearing FLOASIS Items NFTs, with the item layers stacked in the z-order
+of their categories in categories.csv, above the base art of the FLOASIS NFT.
+Items of mutually exclusive categories are refused. The categories of the
+items are read from the store inventory they were bought from.
+
+	go run ./overflow/tools/composite_args -address 0x01cf0e2f2f715450 -floasis-nft-id 42 -items 7,3 -name "beach day"
+*/
+
+package main
+
+import (
+	"flag"
+	"floasis-items/flow/overflow/manifest"
+	"floasis-items/flow/overflow/tx_args"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	o "github.com/bjartek/overflow"
+	"github.com/onflow/cadence"
+)
+
+// Project types composite_multiple_layers takes layers from
+const (
+	floasisProjectType = "FLOASIS"
+	itemsProjectType   = "ITEMS"
+)
+
+// itemCategories reads the category of every FLOASIS Items NFT of address
+func itemCategories(c *o.OverflowState, address string) (map[uint64]string, error) {
+	result := c.Script("FLOASISItems/get_item_categories", o.WithArg("address", address))
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	dictionary, ok := result.Result.(cadence.Dictionary)
+	if !ok {
+		return nil, fmt.Errorf("expected a dictionary of categories, found %T", result.Result)
+	}
+	categories := map[uint64]string{}
+	for _, pair := range dictionary.Pairs {
+		id, idOk := pair.Key.(cadence.UInt64)
+		category, categoryOk := pair.Value.(cadence.String)
+		if !idOk || !categoryOk {
+			return nil, fmt.Errorf("expected categories by UInt64 NFT id, found %T: %T", pair.Key, pair.Value)
+		}
+		categories[uint64(id)] = string(category)
+	}
+	return categories, nil
+}
+
+func main() {
+	flowNetwork := flag.String("network", "testnet", "network the items are read from")
+	address := flag.String("address", "", "address of the owner of the NFTs")
+	floasisNFTID := flag.Int64("floasis-nft-id", -1, "FLOASIS NFT the composite is added to")
+	itemIDs := flag.String("items", "", "comma separated ids of the FLOASIS Items NFTs to wear, in any order")
+	groupName := flag.String("group", "outfits", "composite group the composite is added to")
+	compositeName := flag.String("name", "", "name of the composite")
+	categoriesPath := flag.String("categories", "./art/accessories/"+manifest.CategoriesFileName, "category taxonomy")
+	outPath := flag.String("out", "./args/composite_multiple_layers.json", "file the arguments are written to")
+	flag.Parse()
+
+	if *address == "" || *floasisNFTID < 0 || *itemIDs == "" || *compositeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ids := []uint64{}
+	for _, field := range strings.Split(*itemIDs, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			log.Fatalf("-items: %q is not an NFT id", field)
+		}
+		ids = append(ids, id)
+	}
+
+	taxonomy, err := manifest.LoadTaxonomy(*categoriesPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c := o.Overflow(o.WithNetwork(*flowNetwork))
+	owned, err := itemCategories(c, *address)
+	if err != nil {
+		log.Fatal(err)
+	}
+	categories := []string{}
+	for _, id := range ids {
+		category, ok := owned[id]
+		if !ok {
+			log.Fatalf("%s owns no FLOASIS Items NFT %d", *address, id)
+		}
+		categories = append(categories, category)
+	}
+	order, err := taxonomy.OrderLayers(categories)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// the base art of the FLOASIS NFT is always the bottom layer
+	projectTypes := []cadence.Value{cadence.String(floasisProjectType)}
+	layerIDs := []cadence.Value{cadence.UInt64(*floasisNFTID)}
+	fmt.Printf("layer 1: FLOASIS NFT %d\n", *floasisNFTID)
+	for i, index := range order {
+		projectTypes = append(projectTypes, cadence.String(itemsProjectType))
+		layerIDs = append(layerIDs, cadence.UInt64(ids[index]))
+		fmt.Printf("layer %d: FLOASIS Items NFT %d, %s\n", i+2, ids[index], categories[index])
+	}
+
+	args := tx_args.Args{
+		"floasisNFTID":       cadence.UInt64(*floasisNFTID),
+		"projectTypes":       cadence.NewArray(projectTypes),
+		"ids":                cadence.NewArray(layerIDs),
+		"compositeGroupName": cadence.String(*groupName),
+		"compositeName":      cadence.String(*compositeName),
+	}
+	if err := os.MkdirAll(filepath.Dir(*outPath), 0755); err != nil {
+		log.Fatal(err)
+	}
+	if err := tx_args.WriteFile(*outPath, filepath.Join("transactions", "FLOASISNFT", "composite_multiple_layers.cdc"), args); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("composite arguments written to:", *outPath)
+}
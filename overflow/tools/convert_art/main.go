@@ -0,0 +1,30 @@
+/*
+Converts every PNG of the art repo that has no SVG yet, the way the setup
+scripts do, with options for cleaning up the artwork on the way.
+
+	go run ./overflow/tools/convert_art
+	go run ./overflow/tools/convert_art -png ./art/accessories/png -svg ./art/accessories/svg -chroma-key auto -chroma-flood-fill
+	go run ./overflow/tools/convert_art -outline "#000000" -outline-connectivity 8
+*/
+
+package main
+
+import (
+	"flag"
+	"floasis-items/flow/overflow/convert"
+	"log"
+	"os"
+)
+
+func main() {
+	conversion := convert.DefaultConfig()
+	pngDirPath := flag.String("png", "./art/accessories/png", "directory of PNGs to convert")
+	svgDirPath := flag.String("svg", "./art/accessories/svg", "directory the SVGs are written to, PNGs with an SVG there are skipped")
+	conversion.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if err := os.MkdirAll(*svgDirPath, 0755); err != nil {
+		log.Fatal(err)
+	}
+	convert.ConvertWithConfig(*pngDirPath, *svgDirPath, conversion)
+}
@@ -0,0 +1,61 @@
+/*
+Converts an art or inventory manifest between CSV and YAML, JSON or TOML
+catalogs, picking both formats by file extension. Entries are validated on the
+way, and an existing output file is only replaced with -force.
+
+	go run ./overflow/tools/convert_manifest -kind art -in ./art/accessories/art_list.csv -out ./art/accessories/art_list.yaml
+	go run ./overflow/tools/convert_manifest -kind inventory -in ./art/accessories/store_inventory_list.csv -out ./art/accessories/store_inventory_list.toml
+*/
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"floasis-items/flow/overflow/manifest"
+	"fmt"
+	"log"
+	"os"
+)
+
+func main() {
+	kind := flag.String("kind", "art", "manifest kind, art or inventory")
+	inPath := flag.String("in", "", "manifest to convert, .csv, .yaml, .yml, .json or .toml")
+	outPath := flag.String("out", "", "manifest to write, .csv, .yaml, .yml, .json or .toml")
+	force := flag.Bool("force", false, "replace the output file if it exists")
+	flag.Parse()
+
+	if *inPath == "" || *outPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if _, err := os.Stat(*outPath); !*force && !errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("%s already exists, use -force to replace it", *outPath)
+	}
+
+	var count int
+	switch *kind {
+	case "art":
+		entries, err := manifest.LoadArtEntries(*inPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := manifest.WriteArtEntries(*outPath, entries); err != nil {
+			log.Fatal(err)
+		}
+		count = len(entries)
+	case "inventory":
+		entries, err := manifest.LoadInventoryEntries(*inPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := manifest.WriteInventoryEntries(*outPath, entries); err != nil {
+			log.Fatal(err)
+		}
+		count = len(entries)
+	default:
+		log.Fatalf("unknown -kind %q, expected art or inventory", *kind)
+	}
+
+	fmt.Printf("Wrote %d %s entries to %s\n", count, *kind, *outPath)
+}
@@ -0,0 +1,164 @@
+/*
+Writes the arguments setup_store sends for the art library and the store
+inventory as JSON-Cadence files, without sending anything, so that they can
+be reviewed in git and sent with the flow CLI, see tx_args. The CIDs of the
+thumbnails are computed offline, so nothing touches the network unless -pin
+is given to also pin the thumbnails with the backend configured in the
+environment or the .env next to flow.json, skipping those already in the pin
+lockfile of the art repo.
+
+The art of every series of series_list.csv is exported to its own files,
+named after the artist and series, once the inventory is checked against the
+series and their art, see manifest.CheckReferences. Batches are split into
+chunks that fit the Flow transaction size limit and the command line argument
+size limit of --args-json, see tx_batch.ExportLimits, and every chunk is
+written to its own numbered file, to be sent in order.
+
+	go run ./overflow/tools/export_args -network testnet -out ./args/testnet
+	go run ./overflow/tools/export_args -network testnet -out ./args/testnet -pin
+*/
+
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"floasis-items/flow/overflow/art_prep"
+	"floasis-items/flow/overflow/flow_config"
+	"floasis-items/flow/overflow/inventory_prep"
+	"floasis-items/flow/overflow/ipfs_pin"
+	"floasis-items/flow/overflow/manifest"
+	"floasis-items/flow/overflow/tx_args"
+	"floasis-items/flow/overflow/tx_batch"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/onflow/cadence"
+)
+
+func main() {
+	flowNetwork := flag.String("network", "testnet", "network the arguments are prepared for")
+	flowJSONPath := flag.String("flow-json", "flow.json", "flow.json the addresses are resolved from")
+	outDir := flag.String("out", "./args", "directory the argument files are written to")
+	seriesListPath := flag.String("series", "./art/accessories/series_list.csv", "series list naming the art list of every series")
+	recipient := flag.String("recipient", "", "flow.json account receiving payments and royalties, defaults to <network>-account")
+	pin := flag.Bool("pin", false, "pin the thumbnails, checking they are pinned under the exported CIDs")
+	flag.Parse()
+
+	if *recipient == "" {
+		*recipient = *flowNetwork + "-account"
+	}
+	config, err := flow_config.Load(*flowJSONPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	recipientAddress, err := config.AccountAddress(*recipient)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// flow_config returns 16 hex digits
+	addressBytes, err := hex.DecodeString(recipientAddress)
+	if err != nil {
+		log.Fatal(err)
+	}
+	address := cadence.BytesToAddress(addressBytes)
+
+	pinLockPath := filepath.Join("./art/accessories", ipfs_pin.LockFileName)
+
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		log.Fatal(err)
+	}
+
+	library, err := manifest.LoadLibrary(*seriesListPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	inventoryEntries, err := manifest.LoadInventoryEntries("./art/accessories/store_inventory_list.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	report := manifest.CheckReferences(library, inventoryEntries)
+	for _, unused := range report.Unused {
+		log.Printf("warning: %v", unused)
+	}
+	if err := report.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	type export struct {
+		transactionName string
+		fileName        string // of the chunk files, without their number
+		args            func() (tx_args.Args, error)
+	}
+	exports := []export{}
+	for _, series := range library.Series {
+		series := series
+		exports = append(exports, export{
+			transactionName: "batch_add_art_to_artLibrary",
+			fileName:        fmt.Sprintf("batch_add_art_to_artLibrary-%s-%s", series.Series.ArtistName, series.Series.SeriesName),
+			args: func() (tx_args.Args, error) {
+				art, err := art_prep.PrepareArt("./art/accessories", series.Art, *flowNetwork)
+				if err != nil {
+					return nil, err
+				}
+				if *pin {
+					if err := art_prep.PinThumbnails(art, pinLockPath); err != nil {
+						return nil, err
+					}
+				}
+				return art_prep.ArtBatch{ArtistName: series.Series.ArtistName, SeriesName: series.Series.SeriesName, Art: art}.Args(), nil
+			},
+		})
+	}
+	exports = append(exports, export{
+		transactionName: "batch_add_inventory",
+		fileName:        "batch_add_inventory",
+		args: func() (tx_args.Args, error) {
+			items, err := inventory_prep.PrepareInventory("./art/accessories", inventoryEntries)
+			if err != nil {
+				return nil, err
+			}
+			if *pin {
+				if err := inventory_prep.PinThumbnails(items, pinLockPath); err != nil {
+					return nil, err
+				}
+			}
+			return inventory_prep.InventoryBatch{Items: items, AllPaymentsRecipient: address, AllRoyaltiesRecipient: address}.Args(), nil
+		},
+	})
+
+	// every thumbnail is pinned before any file is written, so that a failed
+	// upload leaves no arguments behind referring to it
+	exportArgs := make([]tx_args.Args, len(exports))
+	for i, export := range exports {
+		args, err := export.args()
+		if err != nil {
+			log.Fatal(err)
+		}
+		exportArgs[i] = args
+	}
+	for i, export := range exports {
+		transactionPath := filepath.Join("transactions", "FLOASISItemsStore", export.transactionName+".cdc")
+		code, err := os.ReadFile(transactionPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		batch, err := tx_batch.NewBatch("FLOASISItemsStore/"+export.transactionName, code, exportArgs[i])
+		if err != nil {
+			log.Fatal(err)
+		}
+		chunks, err := batch.Chunks(0, tx_batch.ExportLimits())
+		if err != nil {
+			log.Fatal(err)
+		}
+		for i, chunk := range chunks {
+			path := filepath.Join(*outDir, fmt.Sprintf("%s-%03d.json", export.fileName, i+1))
+			if err := tx_args.WriteFile(path, transactionPath, chunk.Args); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("%s: items %d to %d for %s\n", path, chunk.Start, chunk.End, transactionPath)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Normalizes SVGs from drawing tools into the rect-only IaNFTAnalogs schema,
+so that they can go through art_prep like png2svg output.
+
+	go run ./overflow/tools/normalize_svg -in ./art/inkscape -out ./art/accessories/svg
+	go run ./overflow/tools/normalize_svg -in hat.svg -out ./art/accessories/svg -no-rasterize
+*/
+
+package main
+
+import (
+	"flag"
+	"floasis-items/flow/overflow/svg_normalize"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	inPath := flag.String("in", "", "SVG file, or directory of SVG files, to normalize")
+	outDirPath := flag.String("out", "", "directory the normalized SVGs are written to")
+	noRasterize := flag.Bool("no-rasterize", false, "drop shapes that are not rectangles instead of rasterizing them")
+	maxRasterSize := flag.Int("max-raster-size", svg_normalize.DefaultOptions().MaxRasterSize, "largest viewBox width or height to rasterize")
+	strict := flag.Bool("strict", false, "exit with an error if anything had to be approximated")
+	flag.Parse()
+
+	if *inPath == "" || *outDirPath == "" {
+		log.Fatal("-in and -out are required")
+	}
+
+	options := svg_normalize.DefaultOptions()
+	options.Rasterize = !*noRasterize
+	options.MaxRasterSize = *maxRasterSize
+
+	svgPaths := []string{*inPath}
+	if info, err := os.Stat(*inPath); err != nil {
+		log.Fatal(err)
+	} else if info.IsDir() {
+		svgPaths, err = filepath.Glob(filepath.Join(*inPath, "*.svg"))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if err := os.MkdirAll(*outDirPath, 0755); err != nil {
+		log.Fatal(err)
+	}
+
+	approximated := 0
+	for _, svgPath := range svgPaths {
+		outPath := filepath.Join(*outDirPath, filepath.Base(svgPath))
+		if filepath.Clean(outPath) == filepath.Clean(svgPath) {
+			log.Fatalf("%s: -out must not be the directory of the source SVGs", svgPath)
+		}
+		report, err := svg_normalize.NormalizeFile(svgPath, outPath, options)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s -> %s: ", svgPath, outPath)
+		report.WriteText(os.Stdout)
+		if !report.Exact() {
+			approximated++
+		}
+	}
+
+	if approximated > 0 {
+		fmt.Printf("%d of %d svg(s) were approximated\n", approximated, len(svgPaths))
+		if *strict {
+			os.Exit(1)
+		}
+	}
+}
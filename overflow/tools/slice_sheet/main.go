@@ -0,0 +1,91 @@
+/*
+Slices a sprite sheet into the accessories art repo.
+
+	go run ./overflow/tools/slice_sheet -sheet hats.png -atlas hats.json
+	go run ./overflow/tools/slice_sheet -sheet hats.png -cell 100x100 -names "hat-a,hat-b,hat-c"
+	go run ./overflow/tools/slice_sheet -sheet hats.png -atlas hats.json -chroma-key auto -chroma-flood-fill
+*/
+
+package main
+
+import (
+	"flag"
+	"floasis-items/flow/overflow/convert"
+	"floasis-items/flow/overflow/sprite_slice"
+	"fmt"
+	"log"
+	"strings"
+)
+
+func main() {
+	defaults := sprite_slice.DefaultOptions()
+	conversion := convert.DefaultConfig()
+	conversion.RegisterFlags(flag.CommandLine)
+
+	sheetPath := flag.String("sheet", "", "sprite sheet PNG to slice")
+	atlasPath := flag.String("atlas", "", "TexturePacker/Aseprite JSON atlas describing the cells")
+	cellSize := flag.String("cell", "", "grid cell size as WIDTHxHEIGHT, used when no atlas is given")
+	margin := flag.Int("margin", 0, "grid margin around the sheet in pixels")
+	spacing := flag.Int("spacing", 0, "grid spacing between cells in pixels")
+	names := flag.String("names", "", "comma separated grid cell names, row-wise from the top left")
+	prefix := flag.String("prefix", "cell", "grid cell name prefix when no names are given")
+	artRepoPath := flag.String("art", "./art/accessories", "art repo containing the png and svg folders and the manifests")
+	noSVG := flag.Bool("no-svg", false, "do not convert the sliced cells to SVG")
+	noManifests := flag.Bool("no-manifests", false, "do not append rows to the art and inventory manifests")
+	flag.StringVar(&defaults.Placeholders.ArtistName, "artist", defaults.Placeholders.ArtistName, "artist name placeholder")
+	flag.StringVar(&defaults.Placeholders.SeriesName, "series", defaults.Placeholders.SeriesName, "series name placeholder")
+	flag.StringVar(&defaults.Placeholders.PlanetName, "planet", defaults.Placeholders.PlanetName, "planet name placeholder")
+	flag.StringVar(&defaults.Placeholders.Category, "category", defaults.Placeholders.Category, "inventory category placeholder")
+	flag.Parse()
+
+	if *sheetPath == "" {
+		log.Fatal("-sheet is required")
+	}
+
+	var cells []sprite_slice.Cell
+	if *atlasPath != "" {
+		atlasCells, err := sprite_slice.ReadAtlas(*atlasPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cells = atlasCells
+	} else {
+		var cellWidth, cellHeight int
+		if _, err := fmt.Sscanf(*cellSize, "%dx%d", &cellWidth, &cellHeight); err != nil {
+			log.Fatal("-cell must look like 100x100 when no -atlas is given")
+		}
+		spec := sprite_slice.GridSpec{
+			CellWidth:  cellWidth,
+			CellHeight: cellHeight,
+			Margin:     *margin,
+			Spacing:    *spacing,
+			NamePrefix: *prefix,
+		}
+		if *names != "" {
+			spec.Names = strings.Split(*names, ",")
+			for i := range spec.Names {
+				spec.Names[i] = strings.TrimSpace(spec.Names[i])
+			}
+		}
+		sheet, err := sprite_slice.ReadSheet(*sheetPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		gridCells, err := spec.Cells(sheet.Bounds().Dx(), sheet.Bounds().Dy())
+		if err != nil {
+			log.Fatal(err)
+		}
+		cells = gridCells
+	}
+
+	defaults.ConvertToSVG = !*noSVG
+	defaults.Conversion = conversion
+	defaults.AppendArtList = !*noManifests
+	defaults.AppendInventory = !*noManifests
+
+	written, err := sprite_slice.SliceToArtRepo(*sheetPath, cells, *artRepoPath, defaults)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Sliced %d cells from %s\n", len(written), *sheetPath)
+}
@@ -0,0 +1,175 @@
+/*
+Writes transaction arguments as JSON-Cadence files, the format the flow CLI
+takes with --args-json, so that batches can be reviewed in git and sent by
+any signer:
+
+	flow transactions send ./transactions/FLOASISItemsStore/batch_add_inventory.cdc \
+		--args-json "$(cat args/testnet/batch_add_inventory-001.json)" \
+		--network testnet --signer testnet-account
+
+--args-json takes the JSON itself rather than a path, and Linux limits a
+single command line argument to 128 KiB (MAX_ARG_STRLEN), so the arguments
+are written as compact JSON, and tx_batch splits batches to fit.
+*/
+
+package tx_args
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/onflow/cadence"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime/parser"
+)
+
+// MaxCommandLineBytes is the largest argument file that can be passed to
+// --args-json, since MAX_ARG_STRLEN counts the terminating null byte too
+const MaxCommandLineBytes = 128*1024 - 1
+
+// Args are named transaction arguments, like the o.WithArg calls overflow takes
+type Args map[string]cadence.Value
+
+// Map returns the arguments in the form overflow's WithArgsMap takes
+func (args Args) Map() map[string]interface{} {
+	m := make(map[string]interface{}, len(args))
+	for name, value := range args {
+		m[name] = value
+	}
+	return m
+}
+
+// ParameterNames returns the parameter names of a transaction in order
+func ParameterNames(code []byte) ([]string, error) {
+	program, err := parser.ParseProgram(code, nil)
+	if err != nil {
+		return nil, fmt.Errorf("tx_args: %w", err)
+	}
+	transactions := program.TransactionDeclarations()
+	if len(transactions) != 1 {
+		return nil, fmt.Errorf("tx_args: expected one transaction declaration, found %d", len(transactions))
+	}
+	names := []string{}
+	if transactions[0].ParameterList == nil {
+		return names, nil
+	}
+	for _, parameter := range transactions[0].ParameterList.Parameters {
+		names = append(names, parameter.Identifier.Identifier)
+	}
+	return names, nil
+}
+
+// Order puts the arguments in the order the transaction declares its
+// parameters. Missing and unknown arguments are errors, since the flow CLI
+// only matches arguments by position.
+func Order(code []byte, args Args) ([]cadence.Value, error) {
+	names, err := ParameterNames(code)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make([]cadence.Value, 0, len(names))
+	missing := []string{}
+	for _, name := range names {
+		value, ok := args[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		ordered = append(ordered, value)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("tx_args: missing arguments %s", strings.Join(missing, ", "))
+	}
+	if len(args) != len(names) {
+		unknown := []string{}
+		for name := range args {
+			if !contains(names, name) {
+				unknown = append(unknown, name)
+			}
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("tx_args: the transaction has no parameters %s", strings.Join(unknown, ", "))
+	}
+	return ordered, nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode writes the values as a compact JSON-Cadence array, see the package
+// doc for why it is not indented
+func Encode(values []cadence.Value) ([]byte, error) {
+	encoded := make([]json.RawMessage, 0, len(values))
+	for i, value := range values {
+		data, err := jsoncdc.Encode(value)
+		if err != nil {
+			return nil, fmt.Errorf("tx_args: argument %d: %w", i, err)
+		}
+		encoded = append(encoded, bytes.TrimSpace(data))
+	}
+
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("tx_args: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Decode reads a JSON-Cadence array written by Encode or by hand
+func Decode(data []byte) ([]cadence.Value, error) {
+	var encoded []json.RawMessage
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("tx_args: %w", err)
+	}
+	values := make([]cadence.Value, 0, len(encoded))
+	for i, raw := range encoded {
+		value, err := jsoncdc.Decode(nil, raw)
+		if err != nil {
+			return nil, fmt.Errorf("tx_args: argument %d: %w", i, err)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// WriteFile orders the arguments for the transaction at transactionPath and
+// writes them to path
+func WriteFile(path string, transactionPath string, args Args) error {
+	code, err := os.ReadFile(transactionPath)
+	if err != nil {
+		return err
+	}
+	values, err := Order(code, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", transactionPath, err)
+	}
+	data, err := Encode(values)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ReadFile reads the arguments WriteFile wrote
+func ReadFile(path string) ([]cadence.Value, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
@@ -0,0 +1,183 @@
+package tx_args
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/onflow/cadence"
+)
+
+const testTransaction = `
+import FLOASISItemsStore from "../../contracts/FLOASISItemsStore.cdc"
+
+transaction(itemName: String, inventoryItemIDs: [UInt64], price: UFix64, recipient: Address?) {
+	prepare(signer: AuthAccount) {}
+}
+`
+
+func testArgs() Args {
+	return Args{
+		"price":            cadence.UFix64(50_00000000),
+		"recipient":        cadence.NewOptional(nil),
+		"itemName":         cadence.String("Athletian Hat 0"),
+		"inventoryItemIDs": cadence.NewArray([]cadence.Value{cadence.UInt64(3), cadence.UInt64(4)}),
+	}
+}
+
+func TestParameterNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected []string
+	}{
+		{"parameters", testTransaction, []string{"itemName", "inventoryItemIDs", "price", "recipient"}},
+		{"no parameter list", "transaction { execute {} }", []string{}},
+		{"empty parameter list", "transaction() { execute {} }", []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			names, err := ParameterNames([]byte(test.code))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(names, test.expected) {
+				t.Errorf("expected %v, found %v", test.expected, names)
+			}
+		})
+	}
+
+	for _, code := range []string{"pub fun main(): Int { return 1 }", "transaction {} transaction {}"} {
+		if _, err := ParameterNames([]byte(code)); err == nil || !strings.Contains(err.Error(), "expected one transaction declaration") {
+			t.Errorf("expected %q to be refused, found %v", code, err)
+		}
+	}
+	if _, err := ParameterNames([]byte("transaction(")); err == nil {
+		t.Error("expected a syntax error")
+	}
+}
+
+func TestOrder(t *testing.T) {
+	args := testArgs()
+	values, err := Order([]byte(testTransaction), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []cadence.Value{args["itemName"], args["inventoryItemIDs"], args["price"], args["recipient"]}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, found %v", expected, values)
+	}
+
+	tests := []struct {
+		name     string
+		change   func(args Args)
+		expected string
+	}{
+		{
+			"missing",
+			func(args Args) {
+				delete(args, "price")
+				delete(args, "itemName")
+			},
+			"tx_args: missing arguments itemName, price",
+		},
+		{
+			"unknown",
+			func(args Args) {
+				args["quantity"] = cadence.UInt64(1)
+				args["artName"] = cadence.String("Athletian Hat 0")
+			},
+			"tx_args: the transaction has no parameters artName, quantity",
+		},
+		{
+			// the missing arguments are reported first
+			"missing and unknown",
+			func(args Args) {
+				delete(args, "recipient")
+				args["recipients"] = cadence.NewOptional(nil)
+			},
+			"tx_args: missing arguments recipient",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args := testArgs()
+			test.change(args)
+			_, err := Order([]byte(testTransaction), args)
+			if err == nil || err.Error() != test.expected {
+				t.Errorf("expected %q, found %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestWriteFileRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	transactionPath := filepath.Join(dir, "batch_set_inventory_active.cdc")
+	if err := os.WriteFile(transactionPath, []byte(testTransaction), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "args.json")
+	if err := WriteFile(path, transactionPath, testArgs()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the flow CLI takes the file as a single command line argument
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Count(data, []byte("\n")) != 1 || !bytes.HasSuffix(data, []byte("\n")) || bytes.Contains(data, []byte("  ")) {
+		t.Errorf("expected compact JSON on a single line, found %s", data)
+	}
+
+	values, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := Order([]byte(testTransaction), testArgs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != len(expected) {
+		t.Fatalf("expected %d arguments, found %d", len(expected), len(values))
+	}
+	for i := range values {
+		if values[i].String() != expected[i].String() {
+			t.Errorf("argument %d: expected %s, found %s", i, expected[i], values[i])
+		}
+	}
+
+	// an argument that does not fit the transaction is not written
+	args := testArgs()
+	delete(args, "price")
+	err = WriteFile(filepath.Join(dir, "missing.json"), transactionPath, args)
+	if err == nil || err.Error() != transactionPath+": tx_args: missing arguments price" {
+		t.Errorf("expected a missing argument error, found %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("expected no file to be written, found %v", err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{"not an array", `{"type": "String", "value": "hat"}`, "tx_args: json: cannot unmarshal object"},
+		{"not JSON-Cadence", `[{"type": "String", "value": "hat"}, {"type": "Hat"}]`, "tx_args: argument 1: "},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Decode([]byte(test.data))
+			if err == nil || !strings.HasPrefix(err.Error(), test.expected) {
+				t.Errorf("expected an error starting with %q, found %v", test.expected, err)
+			}
+		})
+	}
+}
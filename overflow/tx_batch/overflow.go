@@ -0,0 +1,37 @@
+package tx_batch
+
+import (
+	"fmt"
+
+	o "github.com/bjartek/overflow"
+)
+
+// OverflowSender sends every chunk through overflow and prints the result,
+// the way setup_store sends single transactions. If createdEvent is set, the
+// id fields of the events with that name are returned as the created ids.
+func OverflowSender(c *o.OverflowState, signer string, createdEvent string) Sender {
+	return func(chunk Chunk) (string, []uint64, error) {
+		fmt.Printf("%s: sending items %d to %d, about %d bytes\n", chunk.Name, chunk.Start, chunk.End, chunk.Bytes)
+		result := c.Tx(
+			chunk.Name,
+			o.WithArgsMap(chunk.Args.Map()),
+			o.WithSigner(signer)).
+			Print()
+		if result.Err != nil || createdEvent == "" {
+			return result.Id.String(), nil, result.Err
+		}
+		return result.Id.String(), result.GetIdsFromEvent(createdEvent, "id"), nil
+	}
+}
+
+// OverflowStepSender sends every step through overflow and prints the result
+func OverflowStepSender(c *o.OverflowState, signer string) StepSender {
+	return func(step Step) (string, error) {
+		result := c.Tx(
+			step.Name,
+			o.WithArgsMap(step.Args.Map()),
+			o.WithSigner(signer)).
+			Print()
+		return result.Id.String(), result.Err
+	}
+}
@@ -0,0 +1,252 @@
+package tx_batch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"floasis-items/flow/overflow/tx_args"
+
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+)
+
+// SentChunk records a chunk that went through
+type SentChunk struct {
+	Start         int    `json:"start"`
+	End           int    `json:"end"`
+	TransactionID string `json:"transactionId"`
+	// CreatedIDs are the ids of what the chunk created on-chain, like the
+	// inventory items it added, see OverflowSender
+	CreatedIDs []uint64 `json:"createdIds,omitempty"`
+}
+
+// SentStep records a step that went through, see RunSteps
+type SentStep struct {
+	Name string `json:"name"`
+	// ArgsHash fingerprints the arguments, so that the same transaction
+	// with other arguments is a step of its own
+	ArgsHash      string `json:"argsHash"`
+	TransactionID string `json:"transactionId"`
+}
+
+// State is what Run writes after every successful chunk, and RunSteps after
+// every successful step
+type State struct {
+	Name string `json:"name"`
+	// Sent is the number of items that went through
+	Sent int `json:"sent"`
+	// SentHash fingerprints the arguments of the items that went through,
+	// so that a batch is not resumed against different art. Items can still
+	// be appended to a batch that was sent before.
+	SentHash string `json:"sentHash"`
+	// MaxItems is the chunk size that fit the computation limit, once a
+	// chunk ran out of computation
+	MaxItems int         `json:"maxItems,omitempty"`
+	Chunks   []SentChunk `json:"chunks"`
+	Steps    []SentStep  `json:"steps,omitempty"`
+}
+
+// itemsHash fingerprints the arguments of items 0 to end, see State.SentHash
+func (b *Batch) itemsHash(end int) string {
+	hash := sha256.New()
+	hash.Write(b.fixedDigest)
+	for _, digest := range b.itemDigests[:end] {
+		hash.Write(digest)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// LoadState reads the state Run left at path, or returns an empty state if
+// there is none
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{Chunks: []SentChunk{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("tx_batch: %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// Save writes the state to path, through a temporary file so that an
+// interrupted run never leaves half a state behind
+func (state *State) Save(path string) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// resumeFrom checks that the state belongs to the batch and returns the
+// first item that still has to be sent
+func (b *Batch) resumeFrom(state *State, path string) (int, error) {
+	if state.Sent == 0 {
+		return 0, nil
+	}
+	if state.Name != b.Name {
+		return 0, fmt.Errorf("tx_batch: %s holds the state of %s, not %s", path, state.Name, b.Name)
+	}
+	if state.Sent > b.items {
+		return 0, fmt.Errorf("tx_batch: %s records %d items of %s as sent, but the batch only has %d; remove it to start over",
+			path, state.Sent, b.Name, b.items)
+	}
+	if b.itemsHash(state.Sent) != state.SentHash {
+		return 0, fmt.Errorf("tx_batch: the first %d items of %s changed since they were sent, see %s; remove it to start over",
+			state.Sent, b.Name, path)
+	}
+	return state.Sent, nil
+}
+
+// Step is a single transaction a batch depends on, like adding the series its
+// art goes in, which is sent once and not again when the batch is resumed
+type Step struct {
+	// Name is the overflow name of the transaction, see Batch
+	Name string
+	Args tx_args.Args
+}
+
+// argsHash fingerprints the arguments of the step, see SentStep.ArgsHash
+func (step Step) argsHash() (string, error) {
+	names := []string{}
+	for name := range step.Args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	hash := sha256.New()
+	for _, name := range names {
+		data, err := jsoncdc.Encode(step.Args[name])
+		if err != nil {
+			return "", fmt.Errorf("tx_batch: %s: %s: %w", step.Name, name, err)
+		}
+		hash.Write([]byte(name))
+		hash.Write(data)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// StepSender sends one step and returns the id of its transaction, see
+// OverflowStepSender
+type StepSender func(step Step) (transactionID string, err error)
+
+// RunSteps sends the steps in order, skipping those the state at statePath
+// records as sent, and records every step that goes through. The state can
+// be the one of the batch the steps are for.
+func RunSteps(statePath string, steps []Step, send StepSender) error {
+	state, err := LoadState(statePath)
+	if err != nil {
+		return err
+	}
+	for _, step := range steps {
+		argsHash, err := step.argsHash()
+		if err != nil {
+			return err
+		}
+		if state.sentStep(step.Name, argsHash) {
+			continue
+		}
+		transactionID, err := send(step)
+		if err != nil {
+			return fmt.Errorf("tx_batch: %s: %w", step.Name, err)
+		}
+		state.Steps = append(state.Steps, SentStep{Name: step.Name, ArgsHash: argsHash, TransactionID: transactionID})
+		if err := state.Save(statePath); err != nil {
+			return fmt.Errorf("tx_batch: %s was sent, but saving %s failed: %w", step.Name, statePath, err)
+		}
+	}
+	return nil
+}
+
+// CreatedIDs returns the ids the chunks that went through created, in the
+// order they were sent
+func (state *State) CreatedIDs() []uint64 {
+	ids := []uint64{}
+	for _, chunk := range state.Chunks {
+		ids = append(ids, chunk.CreatedIDs...)
+	}
+	return ids
+}
+
+// sentStep returns true if the state records the step as sent
+func (state *State) sentStep(name string, argsHash string) bool {
+	for _, sent := range state.Steps {
+		if sent.Name == name && sent.ArgsHash == argsHash {
+			return true
+		}
+	}
+	return false
+}
+
+// Sender sends one chunk and returns the id of its transaction and the ids
+// of what it created, see OverflowSender
+type Sender func(chunk Chunk) (transactionID string, createdIDs []uint64, err error)
+
+// isComputationLimit reports a transaction that ran out of computation
+func isComputationLimit(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "Error Code: 1110") || strings.Contains(message, "computation exceeds limit")
+}
+
+// Run sends the batch in chunks within the limits, in order. After every
+// chunk the state at statePath is updated, and a later Run with the same
+// statePath resumes after the last chunk that went through. A chunk that runs
+// out of computation is split in half and retried, and the smaller size is
+// kept for the rest of the batch and recorded for resumed runs.
+func (b *Batch) Run(statePath string, limits Limits, send Sender) error {
+	state, err := LoadState(statePath)
+	if err != nil {
+		return err
+	}
+	start, err := b.resumeFrom(state, statePath)
+	if err != nil {
+		return err
+	}
+	state.Name = b.Name
+	if state.MaxItems > 0 && (limits.MaxItems == 0 || state.MaxItems < limits.MaxItems) {
+		limits.MaxItems = state.MaxItems
+	}
+
+	for start < b.items {
+		end, err := b.nextEnd(start, limits)
+		if err != nil {
+			return err
+		}
+
+		transactionID, createdIDs, err := send(b.chunk(start, end))
+		if err != nil {
+			if isComputationLimit(err) && end-start > 1 {
+				limits.MaxItems = (end - start) / 2
+				state.MaxItems = limits.MaxItems
+				continue
+			}
+			return fmt.Errorf("tx_batch: %s items %d to %d: %w", b.Name, start, end, err)
+		}
+
+		state.Sent = end
+		state.SentHash = b.itemsHash(end)
+		state.Chunks = append(state.Chunks, SentChunk{Start: start, End: end, TransactionID: transactionID, CreatedIDs: createdIDs})
+		if err := state.Save(statePath); err != nil {
+			return fmt.Errorf("tx_batch: items %d to %d of %s were sent, but saving %s failed: %w", start, end, b.Name, statePath, err)
+		}
+		start = end
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+/*
+Splits batch transactions such as FLOASISItemsStore/batch_add_art_to_artLibrary
+into chunks that stay within Flow's transaction limits, sends them in order
+and records every chunk that went through, so that a failed run resumes after
+the last successful chunk instead of adding the same art twice.
+
+Every array argument of a batch is split item by item, so they all have to
+be of the same length. Other arguments, like the artist name, are sent with
+every chunk.
+
+Single transactions a batch depends on, like adding the series its art goes
+in, are sent as steps, which are recorded the same way, see RunSteps.
+*/
+
+package tx_batch
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+
+	"floasis-items/flow/overflow/tx_args"
+
+	"github.com/onflow/cadence"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+)
+
+// MaxTransactionBytes is the largest transaction Flow accepts, counting the
+// script, the JSON-Cadence arguments and the envelope
+const MaxTransactionBytes = 1_500_000
+
+// envelopeBytes is room for the parts of a transaction other than the script
+// and the arguments: reference block, keys, authorizers and signatures
+const envelopeBytes = 2_000
+
+// Limits bound the chunks of a batch. A zero value disables a limit.
+type Limits struct {
+	// MaxTransactionBytes bounds the script, arguments and envelope of a chunk
+	MaxTransactionBytes int
+	// MaxArgsBytes bounds the arguments of a chunk as tx_args writes them,
+	// for chunks sent with the flow CLI
+	MaxArgsBytes int
+	// MaxItems bounds the number of items per chunk. Computation cannot be
+	// measured before a transaction runs, so this is the knob for it, and
+	// chunks that still run out of computation are split further, see Run.
+	MaxItems int
+}
+
+// DefaultLimits stay within the Flow transaction size limit and leave the
+// number of items to the size limit and computation retries
+func DefaultLimits() Limits {
+	return Limits{MaxTransactionBytes: MaxTransactionBytes}
+}
+
+// ExportLimits also keep the argument file of every chunk small enough to
+// be passed to flow transactions send --args-json, see tx_args
+func ExportLimits() Limits {
+	limits := DefaultLimits()
+	limits.MaxArgsBytes = tx_args.MaxCommandLineBytes
+	return limits
+}
+
+// Batch is a transaction whose array arguments are sent in chunks
+type Batch struct {
+	// Name is the overflow name of the transaction, like
+	// "FLOASISItemsStore/batch_add_inventory"
+	Name string
+	// Code is the transaction script, which counts toward its size
+	Code []byte
+	Args tx_args.Args
+
+	// names of the array arguments, sorted
+	itemArgs []string
+	// length of the array arguments
+	items int
+	// encoded size of every item of every array argument, by argument name
+	itemBytes map[string][]int
+	// digest of the arguments of every item, see State.SentHash
+	itemDigests [][]byte
+	// digest of the arguments that are not split
+	fixedDigest []byte
+	// encoded size of the script and the arguments of an empty chunk
+	fixedBytes int
+	// size of the argument file of an empty chunk, see tx_args.Encode
+	fixedArgsBytes int
+}
+
+// NewBatch checks that the array arguments line up and measures every item
+func NewBatch(name string, code []byte, args tx_args.Args) (*Batch, error) {
+	b := &Batch{Name: name, Code: code, Args: args, items: -1, itemBytes: map[string][]int{}}
+
+	for argName, value := range args {
+		if _, ok := value.(cadence.Array); ok {
+			b.itemArgs = append(b.itemArgs, argName)
+		}
+	}
+	sort.Strings(b.itemArgs)
+	if len(b.itemArgs) == 0 {
+		return nil, fmt.Errorf("tx_batch: %s has no array arguments to split", name)
+	}
+
+	lengths := []string{}
+	for _, argName := range b.itemArgs {
+		length := len(args[argName].(cadence.Array).Values)
+		lengths = append(lengths, fmt.Sprintf("%s %d", argName, length))
+		if b.items == -1 {
+			b.items = length
+		} else if length != b.items {
+			return nil, fmt.Errorf("tx_batch: the array arguments of %s differ in length: %s", name, strings.Join(lengths, ", "))
+		}
+	}
+
+	itemHashes := make([]hash.Hash, b.items)
+	for i := range itemHashes {
+		itemHashes[i] = sha256.New()
+	}
+	for _, argName := range b.itemArgs {
+		sizes := make([]int, b.items)
+		for i, value := range args[argName].(cadence.Array).Values {
+			data, err := jsoncdc.Encode(value)
+			if err != nil {
+				return nil, fmt.Errorf("tx_batch: %s[%d]: %w", argName, i, err)
+			}
+			sizes[i] = len(data)
+			itemHashes[i].Write([]byte(argName))
+			itemHashes[i].Write(data)
+		}
+		b.itemBytes[argName] = sizes
+	}
+	for _, itemHash := range itemHashes {
+		b.itemDigests = append(b.itemDigests, itemHash.Sum(nil))
+	}
+
+	fixedArgs := []string{}
+	for argName, value := range args {
+		if _, ok := value.(cadence.Array); !ok {
+			fixedArgs = append(fixedArgs, argName)
+		}
+	}
+	sort.Strings(fixedArgs)
+	fixedHash := sha256.New()
+	for _, argName := range fixedArgs {
+		data, err := jsoncdc.Encode(args[argName])
+		if err != nil {
+			return nil, fmt.Errorf("tx_batch: %s: %w", argName, err)
+		}
+		fixedHash.Write([]byte(argName))
+		fixedHash.Write(data)
+	}
+	b.fixedDigest = fixedHash.Sum(nil)
+
+	empty, err := encodedArgsBytes(b.chunkArgs(0, 0))
+	if err != nil {
+		return nil, err
+	}
+	b.fixedBytes = len(code) + empty
+	// tx_args writes the arguments without the newlines jsoncdc ends them
+	// with, separated by commas, in brackets and with a newline at the end
+	b.fixedArgsBytes = empty + 2
+	return b, nil
+}
+
+// encodedArgsBytes is the size of the arguments the way they are sent
+func encodedArgsBytes(args tx_args.Args) (int, error) {
+	size := 0
+	for argName, value := range args {
+		data, err := jsoncdc.Encode(value)
+		if err != nil {
+			return 0, fmt.Errorf("tx_batch: %s: %w", argName, err)
+		}
+		size += len(data)
+	}
+	return size, nil
+}
+
+// Len returns the number of items in the batch
+func (b *Batch) Len() int {
+	return b.items
+}
+
+// chunkArgs returns the arguments for items start to end
+func (b *Batch) chunkArgs(start int, end int) tx_args.Args {
+	args := tx_args.Args{}
+	for argName, value := range b.Args {
+		if array, ok := value.(cadence.Array); ok {
+			args[argName] = cadence.NewArray(array.Values[start:end]).WithType(array.ArrayType)
+			continue
+		}
+		args[argName] = value
+	}
+	return args
+}
+
+// itemSize is the encoded size of item i across the array arguments. Items
+// are measured with the newline jsoncdc ends them with, which makes up for
+// the commas between them.
+func (b *Batch) itemSize(i int) int {
+	size := 0
+	for _, argName := range b.itemArgs {
+		size += b.itemBytes[argName][i]
+	}
+	return size
+}
+
+// Bytes estimates the transaction size of the chunk with items start to end
+func (b *Batch) Bytes(start int, end int) int {
+	size := b.fixedBytes + envelopeBytes
+	for i := start; i < end; i++ {
+		size += b.itemSize(i)
+	}
+	return size
+}
+
+// ArgsBytes estimates the size of the argument file of the chunk with items
+// start to end, see tx_args.Encode
+func (b *Batch) ArgsBytes(start int, end int) int {
+	size := b.fixedArgsBytes
+	for i := start; i < end; i++ {
+		size += b.itemSize(i)
+	}
+	return size
+}
+
+// nextEnd returns where the chunk starting at start ends, taking as many
+// items as the limits allow
+func (b *Batch) nextEnd(start int, limits Limits) (int, error) {
+	end := start
+	size, argsSize := b.Bytes(start, start), b.ArgsBytes(start, start)
+	for end < b.items {
+		if limits.MaxItems > 0 && end-start >= limits.MaxItems {
+			break
+		}
+		itemSize := b.itemSize(end)
+		over := ""
+		switch {
+		case limits.MaxTransactionBytes > 0 && size+itemSize > limits.MaxTransactionBytes:
+			over = fmt.Sprintf("a transaction of %d bytes, over the limit of %d", size+itemSize, limits.MaxTransactionBytes)
+		case limits.MaxArgsBytes > 0 && argsSize+itemSize > limits.MaxArgsBytes:
+			over = fmt.Sprintf("arguments of %d bytes, over the limit of %d", argsSize+itemSize, limits.MaxArgsBytes)
+		}
+		if over != "" {
+			if end == start {
+				return 0, fmt.Errorf("tx_batch: item %d of %s takes %s on its own", start, b.Name, over)
+			}
+			break
+		}
+		size += itemSize
+		argsSize += itemSize
+		end++
+	}
+	return end, nil
+}
+
+// Chunk is a part of a batch, sent as one transaction
+type Chunk struct {
+	// Name is the overflow name of the transaction, see Batch
+	Name string
+	// Start and End are the range of items in the chunk, End excluded
+	Start int
+	End   int
+	Args  tx_args.Args
+	// Bytes is the estimated transaction size
+	Bytes int
+	// ArgsBytes is the estimated size of the argument file
+	ArgsBytes int
+}
+
+func (b *Batch) chunk(start int, end int) Chunk {
+	return Chunk{Name: b.Name, Start: start, End: end, Args: b.chunkArgs(start, end), Bytes: b.Bytes(start, end), ArgsBytes: b.ArgsBytes(start, end)}
+}
+
+// Chunks splits the items from start on into chunks within the limits
+func (b *Batch) Chunks(start int, limits Limits) ([]Chunk, error) {
+	chunks := []Chunk{}
+	for start < b.items {
+		end, err := b.nextEnd(start, limits)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, b.chunk(start, end))
+		start = end
+	}
+	return chunks, nil
+}
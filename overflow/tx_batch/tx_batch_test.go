@@ -0,0 +1,279 @@
+package tx_batch
+
+import (
+	"errors"
+	"floasis-items/flow/overflow/tx_args"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/onflow/cadence"
+)
+
+const testCode = "transaction(names: [String], sizes: [UInt64], artist: String) { prepare(account: AuthAccount) {} }"
+
+// newTestBatch returns a batch of items named after their index, with the
+// names of the items in oversized taking 1000 bytes
+func newTestBatch(t *testing.T, items int, oversized ...int) *Batch {
+	t.Helper()
+	names := []cadence.Value{}
+	sizes := []cadence.Value{}
+	for i := 0; i < items; i++ {
+		name := fmt.Sprintf("art %03d", i)
+		for _, o := range oversized {
+			if o == i {
+				name = strings.Repeat("x", 1000)
+			}
+		}
+		names = append(names, cadence.String(name))
+		sizes = append(sizes, cadence.UInt64(i))
+	}
+	b, err := NewBatch("Test/batch", []byte(testCode), tx_args.Args{
+		"names":  cadence.NewArray(names),
+		"sizes":  cadence.NewArray(sizes),
+		"artist": cadence.String("hichana"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// ranges returns the item ranges of chunks on the "start-end" form
+func ranges(chunks []Chunk) []string {
+	r := []string{}
+	for _, chunk := range chunks {
+		r = append(r, fmt.Sprintf("%d-%d", chunk.Start, chunk.End))
+	}
+	return r
+}
+
+func TestChunks(t *testing.T) {
+	b := newTestBatch(t, 7)
+	tests := []struct {
+		name     string
+		limits   Limits
+		expected []string
+	}{
+		{"no limits", Limits{}, []string{"0-7"}},
+		{"transaction size fits exactly", Limits{MaxTransactionBytes: b.Bytes(0, 3)}, []string{"0-3", "3-6", "6-7"}},
+		{"transaction size one byte short", Limits{MaxTransactionBytes: b.Bytes(0, 3) - 1}, []string{"0-2", "2-4", "4-6", "6-7"}},
+		{"argument size fits exactly", Limits{MaxArgsBytes: b.ArgsBytes(0, 4)}, []string{"0-4", "4-7"}},
+		{"argument size below transaction size", Limits{MaxTransactionBytes: b.Bytes(0, 7), MaxArgsBytes: b.ArgsBytes(0, 2)}, []string{"0-2", "2-4", "4-6", "6-7"}},
+		{"items", Limits{MaxItems: 3}, []string{"0-3", "3-6", "6-7"}},
+		{"items below size", Limits{MaxTransactionBytes: b.Bytes(0, 5), MaxItems: 2}, []string{"0-2", "2-4", "4-6", "6-7"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chunks, err := b.Chunks(0, test.limits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := ranges(chunks); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected chunks %v, found %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+// TestArgsBytes checks that the estimate is an upper bound of the argument
+// file tx_args writes, off by no more than a byte per array argument
+func TestArgsBytes(t *testing.T) {
+	b := newTestBatch(t, 5)
+	chunks, err := b.Chunks(0, Limits{MaxItems: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, chunk := range chunks {
+		values, err := tx_args.Order([]byte(testCode), chunk.Args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := tx_args.Encode(values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > chunk.ArgsBytes || len(data) < chunk.ArgsBytes-len(b.itemArgs) {
+			t.Errorf("items %d to %d: estimated %d bytes of arguments, written %d", chunk.Start, chunk.End, chunk.ArgsBytes, len(data))
+		}
+	}
+}
+
+func TestChunksRejectOversizedItem(t *testing.T) {
+	b := newTestBatch(t, 4, 2)
+	tests := []struct {
+		name   string
+		limits Limits
+	}{
+		{"transaction size", Limits{MaxTransactionBytes: b.Bytes(0, 2) + 500}},
+		{"argument size", Limits{MaxArgsBytes: b.ArgsBytes(0, 2) + 500}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := b.Chunks(0, test.limits)
+			if err == nil || !strings.Contains(err.Error(), "item 2 of Test/batch") {
+				t.Errorf("expected an error for item 2, found %v", err)
+			}
+		})
+	}
+}
+
+// recorder is a Sender that records the chunks it sends, and fails those
+// fail returns an error for. Every item sent creates the id of its index.
+type recorder struct {
+	sent []string
+	fail func(chunk Chunk) error
+}
+
+func (r *recorder) send(chunk Chunk) (string, []uint64, error) {
+	if r.fail != nil {
+		if err := r.fail(chunk); err != nil {
+			return "", nil, err
+		}
+	}
+	r.sent = append(r.sent, fmt.Sprintf("%d-%d", chunk.Start, chunk.End))
+	created := []uint64{}
+	for i := chunk.Start; i < chunk.End; i++ {
+		created = append(created, uint64(i))
+	}
+	return fmt.Sprintf("tx%d", len(r.sent)), created, nil
+}
+
+// failAt fails the chunk starting at start
+func failAt(start int) func(chunk Chunk) error {
+	return func(chunk Chunk) error {
+		if chunk.Start == start {
+			return errors.New("connection reset")
+		}
+		return nil
+	}
+}
+
+func TestRunResumesAfterPartialRun(t *testing.T) {
+	tests := []struct {
+		name string
+		fail func(chunk Chunk) error
+		// chunks sent by the first run and by the run resuming it
+		first   []string
+		resumed []string
+		// the chunk size recorded for resumed runs
+		maxItems int
+	}{
+		{"network error", failAt(4), []string{"0-2", "2-4"}, []string{"4-6", "6-7"}, 0},
+		{"nothing sent", failAt(0), []string{}, []string{"0-2", "2-4", "4-6", "6-7"}, 0},
+		{
+			"computation limit",
+			func(chunk Chunk) error {
+				if chunk.Start == 2 && chunk.End-chunk.Start > 1 {
+					return errors.New("[Error Code: 1110] computation exceeds limit")
+				}
+				return failAt(4)(chunk)
+			},
+			[]string{"0-2", "2-3", "3-4"},
+			[]string{"4-5", "5-6", "6-7"},
+			1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statePath := filepath.Join(t.TempDir(), "state.json")
+			b := newTestBatch(t, 7)
+
+			first := &recorder{sent: []string{}, fail: test.fail}
+			if err := b.Run(statePath, Limits{MaxItems: 2}, first.send); err == nil {
+				t.Fatal("expected the first run to fail")
+			}
+			if !reflect.DeepEqual(first.sent, test.first) {
+				t.Errorf("expected the first run to send %v, found %v", test.first, first.sent)
+			}
+
+			resumed := &recorder{sent: []string{}}
+			if err := b.Run(statePath, Limits{MaxItems: 2}, resumed.send); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(resumed.sent, test.resumed) {
+				t.Errorf("expected the resumed run to send %v, found %v", test.resumed, resumed.sent)
+			}
+
+			state, err := LoadState(statePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state.Name != b.Name || state.Sent != b.Len() || state.SentHash != b.itemsHash(b.Len()) || state.MaxItems != test.maxItems {
+				t.Errorf("unexpected state %+v", state)
+			}
+			if len(state.Chunks) != len(test.first)+len(test.resumed) {
+				t.Errorf("expected every chunk sent to be recorded, found %+v", state.Chunks)
+			}
+			// the ids created before the failure are kept for the resumed run
+			if expected := []uint64{0, 1, 2, 3, 4, 5, 6}; !reflect.DeepEqual(state.CreatedIDs(), expected) {
+				t.Errorf("expected created ids %v, found %v", expected, state.CreatedIDs())
+			}
+		})
+	}
+}
+
+func TestRunRefusesChangedItems(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	r := &recorder{sent: []string{}, fail: failAt(4)}
+	if err := newTestBatch(t, 7).Run(statePath, Limits{MaxItems: 2}, r.send); err == nil {
+		t.Fatal("expected the first run to fail")
+	}
+
+	changed := newTestBatch(t, 7, 1)
+	err := changed.Run(statePath, Limits{MaxItems: 2}, r.send)
+	if err == nil || !strings.Contains(err.Error(), "changed since they were sent") {
+		t.Errorf("expected an error for changed items, found %v", err)
+	}
+}
+
+func TestRunStepsSendsStepsOnce(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	steps := []Step{
+		{Name: "Test/add_artist", Args: tx_args.Args{"artistName": cadence.String("hichana")}},
+		{Name: "Test/add_series", Args: tx_args.Args{"seriesName": cadence.String("series0")}},
+	}
+
+	sent := []string{}
+	failing := func(step Step) (string, error) {
+		if step.Name == "Test/add_series" {
+			return "", errors.New("connection reset")
+		}
+		sent = append(sent, step.Name)
+		return "tx1", nil
+	}
+	if err := RunSteps(statePath, steps, failing); err == nil || !strings.Contains(err.Error(), "Test/add_series: connection reset") {
+		t.Fatalf("expected the error of add_series, found %v", err)
+	}
+
+	send := func(step Step) (string, error) {
+		sent = append(sent, step.Name)
+		return "tx2", nil
+	}
+	// a series of its own is a step of its own
+	steps = append(steps, Step{Name: "Test/add_series", Args: tx_args.Args{"seriesName": cadence.String("series1")}})
+	for i := 0; i < 2; i++ {
+		if err := RunSteps(statePath, steps, send); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := []string{"Test/add_artist", "Test/add_series", "Test/add_series"}
+	if !reflect.DeepEqual(sent, expected) {
+		t.Errorf("expected %v to be sent, found %v", expected, sent)
+	}
+
+	// the batch the steps are for keeps them in the state it shares
+	b := newTestBatch(t, 3)
+	if err := b.Run(statePath, Limits{}, (&recorder{}).send); err != nil {
+		t.Fatal(err)
+	}
+	state, err := LoadState(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Steps) != 3 || state.Sent != 3 {
+		t.Errorf("unexpected state %+v", state)
+	}
+}